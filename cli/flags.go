@@ -21,6 +21,70 @@ import "code.cloudfoundry.org/cli/cf/flags"
 
 const CfInstanceIndexUsage = "Operate on a specific instance in the Eureka registry. The instance index number can be found by using the service-registry-list command."
 const FileNameUsage = "A text file (with UTF-8 encoding) whose contents are to be encrypted. Cannot be used with VALUE_TO_ENCRYPT parameter."
+const OfflineUsage = "Encrypt locally with the configuration server's RSA public key instead of calling the configuration server. Requires --public-key."
+const PublicKeyUsage = "A file containing the configuration server's RSA public key, as displayed by config-server-key-info."
+const FormatUsage = "Print the flattened configuration in the given format: properties, yaml or json."
+const ToConfigServerUsage = "Compare with this configuration server instance. Defaults to CONFIG_SERVER_INSTANCE_NAME."
+const ToProfileUsage = "Compare with this profile. Defaults to PROFILE."
+const ToLabelUsage = "Compare with this label. Defaults to LABEL."
+const RevealUsage = "Show the values of secrets instead of masking them."
+const RepositoryUsage = "Only report and wait for the mirror of this repository. Accepts a URI or a pattern containing '*'. May be repeated."
+const WaitUsage = "Wait until the configuration server serves the refreshed commit for --application. Requires a single selected mirror."
+const LifecycleWaitUsage = "Wait until every backing app of the service instance has reached the requested state, with all its instances running if started."
+const ApplicationUsage = "Application whose configuration is checked when waiting."
+const ProfileUsage = "Profile of --application to check when waiting. Defaults to \"default\"."
+const LabelUsage = "Label of --application to check when waiting. Defaults to the configuration server's default label."
+const TimeoutUsage = "Maximum number of seconds to wait. Defaults to 300."
+const SecretFileUsage = "A file containing the JSON secret, or - to read it from standard input. Cannot be used with JSON_SECRET parameter."
+const CredHubAppUsage = "Application of the secret. Use with --profile, --label and --property instead of CREDHUB_PATH."
+const CredHubProfileUsage = "Profile of the secret."
+const CredHubLabelUsage = "Label of the secret. May contain \"/\"."
+const CredHubPropertyUsage = "Property name of the secret."
+const SecretKeyUsage = "Key of the secret's JSON object whose value is replaced. Defaults to the property name of the secret."
+const GenerateUsage = "Replace the value with a generated password. Cannot be used with NEW_VALUE parameter."
+const LengthUsage = "Length of the generated password. Defaults to 32."
+const ValueFileUsage = "A file containing the new value, or - to read it from standard input. Cannot be used with NEW_VALUE parameter."
+const RestartAppUsage = "Restart this application, which is bound to the configuration server, after rotating the secret. Instances are replaced one at a time with a rolling restart, so the application stays available. Requires cf CLI v7 or later. May be repeated."
+const RefreshAppUsage = "Trigger /actuator/refresh of this application, which is bound to the configuration server, after rotating the secret. A request is sent to each running instance through the application's first route, without credentials. May be repeated."
+const SecretsFileUsage = "A properties, YAML or JSON file whose properties are to be imported as secrets."
+const DryRunUsage = "Report which secrets would be created or updated without importing them."
+const BackupFileUsage = "The encrypted backup file of the secrets."
+const ExportFormatUsage = "Write the properties in the given format: dotenv, json (for SPRING_APPLICATION_JSON) or yaml. Defaults to dotenv."
+const OutputFileUsage = "Write the properties to this file instead of the terminal."
+const ExcludeSecretsUsage = "Leave out secrets, such as passwords, tokens and properties served from CredHub."
+const EnvironmentUsage = "Compare the configuration of this environment, given as APP_NAME[/PROFILE[/LABEL]]. May be repeated."
+const EnvironmentsFileUsage = "A file listing environments to compare, one APP_NAME[/PROFILE[/LABEL]] per line."
+const ParametersUsage = "Also compare the configuration parameters of the instances, such as Git URIs, search paths and labels."
+const CopySecretsUsage = "After comparing, copy the CredHub secrets of each environment to OTHER_CONFIG_SERVER_INSTANCE_NAME, creating or updating them."
+const CopySecretsDryRunUsage = "With --copy-secrets, report which secrets would be created or updated without copying them."
+const ViewOutputUsage = "Print the service instance in the given format, json or yaml, for use by scripts."
+const WatchUsage = "Refresh the view until interrupted, showing the minimum, average and maximum CPU and memory usage of each instance since watching started, with trends over the last 20 refreshes, and highlighting instances which restart or change state."
+const IntervalUsage = "Number of seconds between refreshes when watching. Defaults to 5."
+const AllSpacesUsage = "List the service instances of every space of the targeted org instead of only the targeted space."
+const ParametersOutputUsage = "Print the configuration parameters in the given format, json or yaml, instead of grouping them into sections. Credentials are masked unless --reveal is given."
+const QueryUsage = "Print only the configuration parameter at this path, such as git.uri or composite[0].type."
+const ParametersFileUsage = "A JSON file of configuration parameters, such as one saved with scs-config --output json, to compare with instead of another service instance."
+const UpdateParametersFileUsage = "A YAML or JSON file of configuration parameters to merge into the current parameters. A null value removes a parameter."
+const UpdateParametersDryRunUsage = "Show and validate the changes without updating the service instance."
+const PlanUsage = "Service plan of the new service instance. Defaults to the plan of the source service instance."
+const BindAppsUsage = "Bind the apps which are bound to the source service instance to the new service instance."
+const ManifestFileUsage = "Write the manifest to this file instead of the terminal."
+const EncryptUsage = "Encrypt credentials with a passphrase instead of redacting them, so that scs-apply can restore them."
+const ManifestPassphraseFileUsage = "A file containing the passphrase which encrypts the credentials in the manifest. Defaults to the value of the SCS_BACKUP_PASSPHRASE environment variable."
+const ApplyDryRunUsage = "Show the plan of changes without making them."
+const FixUsage = "Encrypt the secrets found, in place, using this configuration server instance."
+const PassphraseFileUsage = "A file containing the passphrase which encrypts the backup. Defaults to the value of the SCS_BACKUP_PASSPHRASE environment variable."
+
+const DefaultTimeoutSeconds = 300
+const DefaultPasswordLength = 32
+const DefaultIntervalSeconds = 5
+
+type DiffFlags struct {
+	ToConfigServer string
+	ToProfile      string
+	ToLabel        string
+	Reveal         bool
+}
 
 func ParseFlags(args []string) (*int, []string, error) {
 	const instanceIndexFlagName = "cf-instance-index"
@@ -42,23 +106,609 @@ func ParseFlags(args []string) (*int, []string, error) {
 	return cfInstanceIndex, fc.Args(), nil
 }
 
-func ParseStringFlags(args []string) (string, []string, error) {
-	const fileFlagName = "file-to-encrypt"
+type EncryptFlags struct {
+	FileToEncrypt string
+	Offline       bool
+	PublicKey     string
+}
+
+func ParseEncryptFlags(args []string) (EncryptFlags, []string, error) {
+	const (
+		fileFlagName      = "file-to-encrypt"
+		offlineFlagName   = "offline"
+		publicKeyFlagName = "public-key"
+	)
 	fc := flags.New()
 	fc.NewStringFlag(fileFlagName, "f", FileNameUsage)
+	fc.NewBoolFlag(offlineFlagName, "", OfflineUsage)
+	fc.NewStringFlag(publicKeyFlagName, "", PublicKeyUsage)
 	err := fc.Parse(args...)
 
+	if err != nil {
+		return EncryptFlags{}, nil, fmt.Errorf("Error parsing arguments: %s", err)
+	}
+
+	return EncryptFlags{
+		FileToEncrypt: fc.String(fileFlagName),
+		Offline:       fc.Bool(offlineFlagName),
+		PublicKey:     fc.String(publicKeyFlagName),
+	}, fc.Args(), nil
+}
+
+type SyncMirrorsFlags struct {
+	Repositories   []string
+	Wait           bool
+	Application    string
+	Profile        string
+	Label          string
+	TimeoutSeconds int
+}
+
+func ParseSyncMirrorsFlags(args []string) (SyncMirrorsFlags, []string, error) {
+	const (
+		repositoryFlagName  = "repository"
+		waitFlagName        = "wait"
+		applicationFlagName = "application"
+		profileFlagName     = "profile"
+		labelFlagName       = "label"
+		timeoutFlagName     = "timeout"
+	)
+	fc := flags.New()
+	fc.NewStringSliceFlag(repositoryFlagName, "r", RepositoryUsage)
+	fc.NewBoolFlag(waitFlagName, "w", WaitUsage)
+	fc.NewStringFlag(applicationFlagName, "a", ApplicationUsage)
+	fc.NewStringFlag(profileFlagName, "p", ProfileUsage)
+	fc.NewStringFlag(labelFlagName, "l", LabelUsage)
+	fc.NewIntFlagWithDefault(timeoutFlagName, "t", TimeoutUsage, DefaultTimeoutSeconds)
+	err := fc.Parse(args...)
+	if err != nil {
+		return SyncMirrorsFlags{}, nil, fmt.Errorf("Error parsing arguments: %s", err)
+	}
+
+	return SyncMirrorsFlags{
+		Repositories:   fc.StringSlice(repositoryFlagName),
+		Wait:           fc.Bool(waitFlagName),
+		Application:    fc.String(applicationFlagName),
+		Profile:        fc.String(profileFlagName),
+		Label:          fc.String(labelFlagName),
+		TimeoutSeconds: fc.Int(timeoutFlagName),
+	}, fc.Args(), nil
+}
+
+func ParseFormatFlags(args []string) (string, []string, error) {
+	const formatFlagName = "format"
+	fc := flags.New()
+	fc.NewStringFlag(formatFlagName, "", FormatUsage)
+	err := fc.Parse(args...)
 	if err != nil {
 		return "", nil, fmt.Errorf("Error parsing arguments: %s", err)
 	}
 
-	var fileToEncrypt string
-	if fc.IsSet(fileFlagName) {
-		var fileName string
-		fileName = fc.String(fileFlagName)
-		fileToEncrypt = fileName
+	return fc.String(formatFlagName), fc.Args(), nil
+}
+
+func ParseRevealFlags(args []string) (bool, []string, error) {
+	const revealFlagName = "reveal"
+	fc := flags.New()
+	fc.NewBoolFlag(revealFlagName, "", RevealUsage)
+	err := fc.Parse(args...)
+	if err != nil {
+		return false, nil, fmt.Errorf("Error parsing arguments: %s", err)
+	}
+
+	return fc.Bool(revealFlagName), fc.Args(), nil
+}
+
+type ConfigurationFlags struct {
+	Output string
+	Query  string
+	Reveal bool
+}
+
+func ParseConfigurationFlags(args []string) (ConfigurationFlags, []string, error) {
+	const (
+		outputFlagName = "output"
+		queryFlagName  = "query"
+		revealFlagName = "reveal"
+	)
+	fc := flags.New()
+	fc.NewStringFlag(outputFlagName, "o", ParametersOutputUsage)
+	fc.NewStringFlag(queryFlagName, "q", QueryUsage)
+	fc.NewBoolFlag(revealFlagName, "", RevealUsage)
+	err := fc.Parse(args...)
+	if err != nil {
+		return ConfigurationFlags{}, nil, fmt.Errorf("Error parsing arguments: %s", err)
+	}
+
+	return ConfigurationFlags{
+		Output: fc.String(outputFlagName),
+		Query:  fc.String(queryFlagName),
+		Reveal: fc.Bool(revealFlagName),
+	}, fc.Args(), nil
+}
+
+type ConfigurationDiffFlags struct {
+	File   string
+	Reveal bool
+}
+
+func ParseConfigurationDiffFlags(args []string) (ConfigurationDiffFlags, []string, error) {
+	const (
+		fileFlagName   = "file"
+		revealFlagName = "reveal"
+	)
+	fc := flags.New()
+	fc.NewStringFlag(fileFlagName, "f", ParametersFileUsage)
+	fc.NewBoolFlag(revealFlagName, "", RevealUsage)
+	err := fc.Parse(args...)
+	if err != nil {
+		return ConfigurationDiffFlags{}, nil, fmt.Errorf("Error parsing arguments: %s", err)
+	}
+
+	return ConfigurationDiffFlags{
+		File:   fc.String(fileFlagName),
+		Reveal: fc.Bool(revealFlagName),
+	}, fc.Args(), nil
+}
+
+type ConfigurationSetFlags struct {
+	File           string
+	DryRun         bool
+	Reveal         bool
+	TimeoutSeconds int
+}
+
+func ParseConfigurationSetFlags(args []string) (ConfigurationSetFlags, []string, error) {
+	const (
+		fileFlagName    = "file"
+		dryRunFlagName  = "dry-run"
+		revealFlagName  = "reveal"
+		timeoutFlagName = "timeout"
+	)
+	fc := flags.New()
+	fc.NewStringFlag(fileFlagName, "f", UpdateParametersFileUsage)
+	fc.NewBoolFlag(dryRunFlagName, "", UpdateParametersDryRunUsage)
+	fc.NewBoolFlag(revealFlagName, "", RevealUsage)
+	fc.NewIntFlagWithDefault(timeoutFlagName, "t", TimeoutUsage, DefaultTimeoutSeconds)
+	err := fc.Parse(args...)
+	if err != nil {
+		return ConfigurationSetFlags{}, nil, fmt.Errorf("Error parsing arguments: %s", err)
+	}
+
+	return ConfigurationSetFlags{
+		File:           fc.String(fileFlagName),
+		DryRun:         fc.Bool(dryRunFlagName),
+		Reveal:         fc.Bool(revealFlagName),
+		TimeoutSeconds: fc.Int(timeoutFlagName),
+	}, fc.Args(), nil
+}
+
+type CloneFlags struct {
+	Plan           string
+	BindApps       bool
+	TimeoutSeconds int
+}
+
+func ParseCloneFlags(args []string) (CloneFlags, []string, error) {
+	const (
+		planFlagName     = "plan"
+		bindAppsFlagName = "bind-apps"
+		timeoutFlagName  = "timeout"
+	)
+	fc := flags.New()
+	fc.NewStringFlag(planFlagName, "p", PlanUsage)
+	fc.NewBoolFlag(bindAppsFlagName, "", BindAppsUsage)
+	fc.NewIntFlagWithDefault(timeoutFlagName, "t", TimeoutUsage, DefaultTimeoutSeconds)
+	err := fc.Parse(args...)
+	if err != nil {
+		return CloneFlags{}, nil, fmt.Errorf("Error parsing arguments: %s", err)
+	}
+
+	return CloneFlags{
+		Plan:           fc.String(planFlagName),
+		BindApps:       fc.Bool(bindAppsFlagName),
+		TimeoutSeconds: fc.Int(timeoutFlagName),
+	}, fc.Args(), nil
+}
+
+type ExportManifestFlags struct {
+	File           string
+	Encrypt        bool
+	PassphraseFile string
+}
+
+func ParseExportManifestFlags(args []string) (ExportManifestFlags, []string, error) {
+	const (
+		fileFlagName           = "file"
+		encryptFlagName        = "encrypt"
+		passphraseFileFlagName = "passphrase-file"
+	)
+	fc := flags.New()
+	fc.NewStringFlag(fileFlagName, "f", ManifestFileUsage)
+	fc.NewBoolFlag(encryptFlagName, "", EncryptUsage)
+	fc.NewStringFlag(passphraseFileFlagName, "", ManifestPassphraseFileUsage)
+	err := fc.Parse(args...)
+	if err != nil {
+		return ExportManifestFlags{}, nil, fmt.Errorf("Error parsing arguments: %s", err)
+	}
+
+	return ExportManifestFlags{
+		File:           fc.String(fileFlagName),
+		Encrypt:        fc.Bool(encryptFlagName),
+		PassphraseFile: fc.String(passphraseFileFlagName),
+	}, fc.Args(), nil
+}
+
+type ApplyManifestFlags struct {
+	DryRun         bool
+	PassphraseFile string
+	TimeoutSeconds int
+}
+
+func ParseApplyManifestFlags(args []string) (ApplyManifestFlags, []string, error) {
+	const (
+		dryRunFlagName         = "dry-run"
+		passphraseFileFlagName = "passphrase-file"
+		timeoutFlagName        = "timeout"
+	)
+	fc := flags.New()
+	fc.NewBoolFlag(dryRunFlagName, "", ApplyDryRunUsage)
+	fc.NewStringFlag(passphraseFileFlagName, "", ManifestPassphraseFileUsage)
+	fc.NewIntFlagWithDefault(timeoutFlagName, "t", TimeoutUsage, DefaultTimeoutSeconds)
+	err := fc.Parse(args...)
+	if err != nil {
+		return ApplyManifestFlags{}, nil, fmt.Errorf("Error parsing arguments: %s", err)
+	}
+
+	return ApplyManifestFlags{
+		DryRun:         fc.Bool(dryRunFlagName),
+		PassphraseFile: fc.String(passphraseFileFlagName),
+		TimeoutSeconds: fc.Int(timeoutFlagName),
+	}, fc.Args(), nil
+}
+
+func ParseListFlags(args []string) (bool, []string, error) {
+	const allSpacesFlagName = "all-spaces"
+	fc := flags.New()
+	fc.NewBoolFlag(allSpacesFlagName, "", AllSpacesUsage)
+	err := fc.Parse(args...)
+	if err != nil {
+		return false, nil, fmt.Errorf("Error parsing arguments: %s", err)
 	}
-	return fileToEncrypt, fc.Args(), nil
+
+	return fc.Bool(allSpacesFlagName), fc.Args(), nil
+}
+
+func ParseDiffFlags(args []string) (DiffFlags, []string, error) {
+	const (
+		toConfigServerFlagName = "to-config-server"
+		toProfileFlagName      = "to-profile"
+		toLabelFlagName        = "to-label"
+		revealFlagName         = "reveal"
+	)
+	fc := flags.New()
+	fc.NewStringFlag(toConfigServerFlagName, "", ToConfigServerUsage)
+	fc.NewStringFlag(toProfileFlagName, "", ToProfileUsage)
+	fc.NewStringFlag(toLabelFlagName, "", ToLabelUsage)
+	fc.NewBoolFlag(revealFlagName, "", RevealUsage)
+	err := fc.Parse(args...)
+	if err != nil {
+		return DiffFlags{}, nil, fmt.Errorf("Error parsing arguments: %s", err)
+	}
+
+	return DiffFlags{
+		ToConfigServer: fc.String(toConfigServerFlagName),
+		ToProfile:      fc.String(toProfileFlagName),
+		ToLabel:        fc.String(toLabelFlagName),
+		Reveal:         fc.Bool(revealFlagName),
+	}, fc.Args(), nil
+}
+
+// CredHubPathFlags are the fields of a CredHub path given as flags rather than as a single CREDHUB_PATH argument.
+type CredHubPathFlags struct {
+	Application string
+	Profile     string
+	Label       string
+	Property    string
+}
+
+func (f CredHubPathFlags) IsSet() bool {
+	return f != CredHubPathFlags{}
+}
+
+const (
+	credHubAppFlagName      = "app"
+	credHubProfileFlagName  = "profile"
+	credHubLabelFlagName    = "label"
+	credHubPropertyFlagName = "property"
+)
+
+func newCredHubPathFlags(fc flags.FlagContext) {
+	fc.NewStringFlag(credHubAppFlagName, "", CredHubAppUsage)
+	fc.NewStringFlag(credHubProfileFlagName, "", CredHubProfileUsage)
+	fc.NewStringFlag(credHubLabelFlagName, "", CredHubLabelUsage)
+	fc.NewStringFlag(credHubPropertyFlagName, "", CredHubPropertyUsage)
+}
+
+func credHubPathFlags(fc flags.FlagContext) CredHubPathFlags {
+	return CredHubPathFlags{
+		Application: fc.String(credHubAppFlagName),
+		Profile:     fc.String(credHubProfileFlagName),
+		Label:       fc.String(credHubLabelFlagName),
+		Property:    fc.String(credHubPropertyFlagName),
+	}
+}
+
+type AddSecretFlags struct {
+	File string
+	Path CredHubPathFlags
+}
+
+func ParseAddSecretFlags(args []string) (AddSecretFlags, []string, error) {
+	const fileFlagName = "file"
+	fc := flags.New()
+	fc.NewStringFlag(fileFlagName, "f", SecretFileUsage)
+	newCredHubPathFlags(fc)
+	err := fc.Parse(args...)
+	if err != nil {
+		return AddSecretFlags{}, nil, fmt.Errorf("Error parsing arguments: %s", err)
+	}
+
+	return AddSecretFlags{
+		File: fc.String(fileFlagName),
+		Path: credHubPathFlags(fc),
+	}, fc.Args(), nil
+}
+
+func ParseRemoveSecretFlags(args []string) (CredHubPathFlags, []string, error) {
+	fc := flags.New()
+	newCredHubPathFlags(fc)
+	err := fc.Parse(args...)
+	if err != nil {
+		return CredHubPathFlags{}, nil, fmt.Errorf("Error parsing arguments: %s", err)
+	}
+
+	return credHubPathFlags(fc), fc.Args(), nil
+}
+
+type RotateSecretFlags struct {
+	Path     CredHubPathFlags
+	Key      string
+	Generate bool
+	Length   int
+	File     string
+	Restart  []string
+	Refresh  []string
+}
+
+func ParseRotateSecretFlags(args []string) (RotateSecretFlags, []string, error) {
+	const (
+		keyFlagName      = "key"
+		generateFlagName = "generate"
+		lengthFlagName   = "length"
+		fileFlagName     = "file"
+		restartFlagName  = "restart"
+		refreshFlagName  = "refresh"
+	)
+	fc := flags.New()
+	newCredHubPathFlags(fc)
+	fc.NewStringFlag(keyFlagName, "", SecretKeyUsage)
+	fc.NewBoolFlag(generateFlagName, "g", GenerateUsage)
+	fc.NewIntFlagWithDefault(lengthFlagName, "", LengthUsage, DefaultPasswordLength)
+	fc.NewStringFlag(fileFlagName, "f", ValueFileUsage)
+	fc.NewStringSliceFlag(restartFlagName, "", RestartAppUsage)
+	fc.NewStringSliceFlag(refreshFlagName, "", RefreshAppUsage)
+	err := fc.Parse(args...)
+	if err != nil {
+		return RotateSecretFlags{}, nil, fmt.Errorf("Error parsing arguments: %s", err)
+	}
+
+	return RotateSecretFlags{
+		Path:     credHubPathFlags(fc),
+		Key:      fc.String(keyFlagName),
+		Generate: fc.Bool(generateFlagName),
+		Length:   fc.Int(lengthFlagName),
+		File:     fc.String(fileFlagName),
+		Restart:  fc.StringSlice(restartFlagName),
+		Refresh:  fc.StringSlice(refreshFlagName),
+	}, fc.Args(), nil
+}
+
+type LifecycleFlags struct {
+	Wait           bool
+	TimeoutSeconds int
+}
+
+func ParseLifecycleFlags(args []string) (LifecycleFlags, []string, error) {
+	const (
+		waitFlagName    = "wait"
+		timeoutFlagName = "timeout"
+	)
+	fc := flags.New()
+	fc.NewBoolFlag(waitFlagName, "w", LifecycleWaitUsage)
+	fc.NewIntFlagWithDefault(timeoutFlagName, "t", TimeoutUsage, DefaultTimeoutSeconds)
+	err := fc.Parse(args...)
+	if err != nil {
+		return LifecycleFlags{}, nil, fmt.Errorf("Error parsing arguments: %s", err)
+	}
+
+	return LifecycleFlags{
+		Wait:           fc.Bool(waitFlagName),
+		TimeoutSeconds: fc.Int(timeoutFlagName),
+	}, fc.Args(), nil
+}
+
+type ViewFlags struct {
+	Output          string
+	Watch           bool
+	IntervalSeconds int
+}
+
+func ParseViewFlags(args []string) (ViewFlags, []string, error) {
+	const (
+		outputFlagName   = "output"
+		watchFlagName    = "watch"
+		intervalFlagName = "interval"
+	)
+	fc := flags.New()
+	fc.NewStringFlag(outputFlagName, "o", ViewOutputUsage)
+	fc.NewBoolFlag(watchFlagName, "w", WatchUsage)
+	fc.NewIntFlagWithDefault(intervalFlagName, "i", IntervalUsage, DefaultIntervalSeconds)
+	err := fc.Parse(args...)
+	if err != nil {
+		return ViewFlags{}, nil, fmt.Errorf("Error parsing arguments: %s", err)
+	}
+
+	return ViewFlags{
+		Output:          fc.String(outputFlagName),
+		Watch:           fc.Bool(watchFlagName),
+		IntervalSeconds: fc.Int(intervalFlagName),
+	}, fc.Args(), nil
+}
+
+type ImportSecretsFlags struct {
+	File   string
+	DryRun bool
+}
+
+func ParseImportSecretsFlags(args []string) (ImportSecretsFlags, []string, error) {
+	const (
+		fileFlagName   = "file"
+		dryRunFlagName = "dry-run"
+	)
+	fc := flags.New()
+	fc.NewStringFlag(fileFlagName, "f", SecretsFileUsage)
+	fc.NewBoolFlag(dryRunFlagName, "", DryRunUsage)
+	err := fc.Parse(args...)
+	if err != nil {
+		return ImportSecretsFlags{}, nil, fmt.Errorf("Error parsing arguments: %s", err)
+	}
+
+	return ImportSecretsFlags{
+		File:   fc.String(fileFlagName),
+		DryRun: fc.Bool(dryRunFlagName),
+	}, fc.Args(), nil
+}
+
+type BackupFlags struct {
+	File           string
+	PassphraseFile string
+	DryRun         bool
+}
+
+func ParseExportSecretsFlags(args []string) (BackupFlags, []string, error) {
+	return parseBackupFlags(args, false)
+}
+
+func ParseRestoreSecretsFlags(args []string) (BackupFlags, []string, error) {
+	return parseBackupFlags(args, true)
+}
+
+func parseBackupFlags(args []string, restore bool) (BackupFlags, []string, error) {
+	const (
+		fileFlagName           = "file"
+		passphraseFileFlagName = "passphrase-file"
+		dryRunFlagName         = "dry-run"
+	)
+	fc := flags.New()
+	fc.NewStringFlag(fileFlagName, "f", BackupFileUsage)
+	fc.NewStringFlag(passphraseFileFlagName, "", PassphraseFileUsage)
+	if restore {
+		fc.NewBoolFlag(dryRunFlagName, "", DryRunUsage)
+	}
+	err := fc.Parse(args...)
+	if err != nil {
+		return BackupFlags{}, nil, fmt.Errorf("Error parsing arguments: %s", err)
+	}
+
+	backupFlags := BackupFlags{
+		File:           fc.String(fileFlagName),
+		PassphraseFile: fc.String(passphraseFileFlagName),
+	}
+	if restore {
+		backupFlags.DryRun = fc.Bool(dryRunFlagName)
+	}
+	return backupFlags, fc.Args(), nil
+}
+
+type ExportEnvFlags struct {
+	Format         string
+	File           string
+	ExcludeSecrets bool
+}
+
+func ParseExportEnvFlags(args []string) (ExportEnvFlags, []string, error) {
+	const (
+		formatFlagName         = "format"
+		fileFlagName           = "file"
+		excludeSecretsFlagName = "exclude-secrets"
+	)
+	fc := flags.New()
+	fc.NewStringFlagWithDefault(formatFlagName, "", ExportFormatUsage, "dotenv")
+	fc.NewStringFlag(fileFlagName, "f", OutputFileUsage)
+	fc.NewBoolFlag(excludeSecretsFlagName, "", ExcludeSecretsUsage)
+	err := fc.Parse(args...)
+	if err != nil {
+		return ExportEnvFlags{}, nil, fmt.Errorf("Error parsing arguments: %s", err)
+	}
+
+	return ExportEnvFlags{
+		Format:         fc.String(formatFlagName),
+		File:           fc.String(fileFlagName),
+		ExcludeSecrets: fc.Bool(excludeSecretsFlagName),
+	}, fc.Args(), nil
+}
+
+type CompareFlags struct {
+	Environments     []string
+	EnvironmentsFile string
+	Parameters       bool
+	Reveal           bool
+	CopySecrets      bool
+	DryRun           bool
+}
+
+func ParseCompareFlags(args []string) (CompareFlags, []string, error) {
+	const (
+		environmentFlagName      = "environment"
+		environmentsFileFlagName = "environments-file"
+		parametersFlagName       = "parameters"
+		revealFlagName           = "reveal"
+		copySecretsFlagName      = "copy-secrets"
+		dryRunFlagName           = "dry-run"
+	)
+	fc := flags.New()
+	fc.NewStringSliceFlag(environmentFlagName, "e", EnvironmentUsage)
+	fc.NewStringFlag(environmentsFileFlagName, "", EnvironmentsFileUsage)
+	fc.NewBoolFlag(parametersFlagName, "", ParametersUsage)
+	fc.NewBoolFlag(revealFlagName, "", RevealUsage)
+	fc.NewBoolFlag(copySecretsFlagName, "", CopySecretsUsage)
+	fc.NewBoolFlag(dryRunFlagName, "", CopySecretsDryRunUsage)
+	err := fc.Parse(args...)
+	if err != nil {
+		return CompareFlags{}, nil, fmt.Errorf("Error parsing arguments: %s", err)
+	}
+
+	return CompareFlags{
+		Environments:     fc.StringSlice(environmentFlagName),
+		EnvironmentsFile: fc.String(environmentsFileFlagName),
+		Parameters:       fc.Bool(parametersFlagName),
+		Reveal:           fc.Bool(revealFlagName),
+		CopySecrets:      fc.Bool(copySecretsFlagName),
+		DryRun:           fc.Bool(dryRunFlagName),
+	}, fc.Args(), nil
+}
+
+func ParseLintFlags(args []string) (string, []string, error) {
+	const fixFlagName = "fix"
+	fc := flags.New()
+	fc.NewStringFlag(fixFlagName, "", FixUsage)
+	err := fc.Parse(args...)
+	if err != nil {
+		return "", nil, fmt.Errorf("Error parsing arguments: %s", err)
+	}
+
+	return fc.String(fixFlagName), fc.Args(), nil
 }
 
 func ParseNoFlags(args []string) ([]string, error) {
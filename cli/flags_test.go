@@ -77,6 +77,401 @@ var _ = Describe("Flags", func() {
 		})
 	})
 
+	Describe("ParseSyncMirrorsFlags", func() {
+		var (
+			syncMirrorsFlags          cli.SyncMirrorsFlags
+			syncMirrorsPositionalArgs []string
+		)
+
+		BeforeEach(func() {
+			args = []string{"config-server-sync-mirrors", "config-server", "-r", "https://github.com/org/a", "--repository", "*/b", "--wait", "-a", "app"}
+		})
+
+		JustBeforeEach(func() {
+			syncMirrorsFlags, syncMirrorsPositionalArgs, err = cli.ParseSyncMirrorsFlags(args)
+		})
+
+		It("should return the flags and the positional arguments", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(syncMirrorsFlags).To(Equal(cli.SyncMirrorsFlags{
+				Repositories:   []string{"https://github.com/org/a", "*/b"},
+				Wait:           true,
+				Application:    "app",
+				TimeoutSeconds: cli.DefaultTimeoutSeconds,
+			}))
+			Expect(syncMirrorsPositionalArgs).To(Equal([]string{"config-server-sync-mirrors", "config-server"}))
+		})
+
+		Context("when a timeout is given", func() {
+			BeforeEach(func() {
+				args = []string{"config-server-sync-mirrors", "config-server", "--timeout", "60"}
+			})
+
+			It("should return the timeout", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(syncMirrorsFlags.TimeoutSeconds).To(Equal(60))
+				Expect(syncMirrorsFlags.Repositories).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("ParseFormatFlags", func() {
+		var (
+			outputFormat         string
+			formatPositionalArgs []string
+		)
+
+		BeforeEach(func() {
+			args = []string{"config-server-get", "config-server", "app", "--format", "yaml", "dev"}
+		})
+
+		JustBeforeEach(func() {
+			outputFormat, formatPositionalArgs, err = cli.ParseFormatFlags(args)
+		})
+
+		It("should return the format and the positional arguments", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(outputFormat).To(Equal("yaml"))
+			Expect(formatPositionalArgs).To(Equal([]string{"config-server-get", "config-server", "app", "dev"}))
+		})
+
+		Context("when no format is given", func() {
+			BeforeEach(func() {
+				args = []string{"config-server-get", "config-server", "app"}
+			})
+
+			It("should return an empty format", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(outputFormat).To(Equal(""))
+			})
+		})
+	})
+
+	Describe("ParseRevealFlags", func() {
+		var (
+			reveal               bool
+			revealPositionalArgs []string
+		)
+
+		BeforeEach(func() {
+			args = []string{"config-server-explain", "config-server", "--reveal", "app"}
+		})
+
+		JustBeforeEach(func() {
+			reveal, revealPositionalArgs, err = cli.ParseRevealFlags(args)
+		})
+
+		It("should return the flag and the positional arguments", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reveal).To(BeTrue())
+			Expect(revealPositionalArgs).To(Equal([]string{"config-server-explain", "config-server", "app"}))
+		})
+	})
+
+	Describe("ParseDiffFlags", func() {
+		var (
+			diffFlags          cli.DiffFlags
+			diffPositionalArgs []string
+		)
+
+		BeforeEach(func() {
+			args = []string{"config-server-diff", "config-server", "app", "staging", "--to-profile", "prod", "--to-config-server", "other", "--reveal"}
+		})
+
+		JustBeforeEach(func() {
+			diffFlags, diffPositionalArgs, err = cli.ParseDiffFlags(args)
+		})
+
+		It("should return the flags and the positional arguments", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diffFlags).To(Equal(cli.DiffFlags{ToConfigServer: "other", ToProfile: "prod", Reveal: true}))
+			Expect(diffPositionalArgs).To(Equal([]string{"config-server-diff", "config-server", "app", "staging"}))
+		})
+	})
+
+	Describe("ParseAddSecretFlags", func() {
+		It("should return the secret file and the positional arguments", func() {
+			addSecretFlags, positionalArgs, err := cli.ParseAddSecretFlags([]string{"config-server-add-credhub-secret", "config-server", "app/cloud/master/secret", "--file", "-"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(addSecretFlags).To(Equal(cli.AddSecretFlags{File: "-"}))
+			Expect(addSecretFlags.Path.IsSet()).To(BeFalse())
+			Expect(positionalArgs).To(Equal([]string{"config-server-add-credhub-secret", "config-server", "app/cloud/master/secret"}))
+		})
+
+		It("should return the fields of the CredHub path", func() {
+			addSecretFlags, positionalArgs, err := cli.ParseAddSecretFlags([]string{"config-server-add-credhub-secret", "config-server", "--app", "app", "--profile", "cloud", "--label", "feature/x", "--property", "secret", `{"key":"value"}`})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(addSecretFlags.Path).To(Equal(cli.CredHubPathFlags{Application: "app", Profile: "cloud", Label: "feature/x", Property: "secret"}))
+			Expect(addSecretFlags.Path.IsSet()).To(BeTrue())
+			Expect(positionalArgs).To(Equal([]string{"config-server-add-credhub-secret", "config-server", `{"key":"value"}`}))
+		})
+	})
+
+	Describe("ParseRemoveSecretFlags", func() {
+		It("should return the fields of the CredHub path", func() {
+			pathFlags, positionalArgs, err := cli.ParseRemoveSecretFlags([]string{"config-server-remove-credhub-secret", "config-server", "--label", "feature/x"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pathFlags).To(Equal(cli.CredHubPathFlags{Label: "feature/x"}))
+			Expect(positionalArgs).To(Equal([]string{"config-server-remove-credhub-secret", "config-server"}))
+		})
+	})
+
+	Describe("ParseRotateSecretFlags", func() {
+		It("should return the flags and the positional arguments", func() {
+			rotateFlags, positionalArgs, err := cli.ParseRotateSecretFlags([]string{"config-server-rotate-credhub-secret", "config-server", "app/cloud/master/db", "--generate", "--key", "db.password", "--restart", "one", "--restart", "two", "--refresh", "three"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rotateFlags).To(Equal(cli.RotateSecretFlags{
+				Key:      "db.password",
+				Generate: true,
+				Length:   cli.DefaultPasswordLength,
+				Restart:  []string{"one", "two"},
+				Refresh:  []string{"three"},
+			}))
+			Expect(positionalArgs).To(Equal([]string{"config-server-rotate-credhub-secret", "config-server", "app/cloud/master/db"}))
+		})
+
+		It("should return the length of the generated password", func() {
+			rotateFlags, _, err := cli.ParseRotateSecretFlags([]string{"config-server-rotate-credhub-secret", "-g", "--length", "64"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rotateFlags.Length).To(Equal(64))
+		})
+	})
+
+	Describe("ParseLifecycleFlags", func() {
+		It("should return the flags and the positional arguments", func() {
+			lifecycleFlags, positionalArgs, err := cli.ParseLifecycleFlags([]string{"scs-restart", "config-server", "--wait", "--timeout", "60"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lifecycleFlags).To(Equal(cli.LifecycleFlags{Wait: true, TimeoutSeconds: 60}))
+			Expect(positionalArgs).To(Equal([]string{"scs-restart", "config-server"}))
+		})
+
+		It("should not wait by default", func() {
+			lifecycleFlags, _, err := cli.ParseLifecycleFlags([]string{"scs-restart", "config-server"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lifecycleFlags).To(Equal(cli.LifecycleFlags{TimeoutSeconds: cli.DefaultTimeoutSeconds}))
+		})
+	})
+
+	Describe("ParseConfigurationFlags", func() {
+		It("should return the flags and the positional arguments", func() {
+			configurationFlags, positionalArgs, err := cli.ParseConfigurationFlags([]string{"scs-config", "config-server", "-o", "yaml", "--query", "git.uri", "--reveal"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(configurationFlags).To(Equal(cli.ConfigurationFlags{Output: "yaml", Query: "git.uri", Reveal: true}))
+			Expect(positionalArgs).To(Equal([]string{"scs-config", "config-server"}))
+		})
+
+		It("should render every parameter in sections by default", func() {
+			configurationFlags, _, err := cli.ParseConfigurationFlags([]string{"scs-config", "config-server"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(configurationFlags).To(Equal(cli.ConfigurationFlags{}))
+		})
+	})
+
+	Describe("ParseConfigurationDiffFlags", func() {
+		It("should return the flags and the positional arguments", func() {
+			diffFlags, positionalArgs, err := cli.ParseConfigurationDiffFlags([]string{"scs-config-diff", "config-server", "-f", "params.json", "--reveal"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diffFlags).To(Equal(cli.ConfigurationDiffFlags{File: "params.json", Reveal: true}))
+			Expect(positionalArgs).To(Equal([]string{"scs-config-diff", "config-server"}))
+		})
+	})
+
+	Describe("ParseConfigurationSetFlags", func() {
+		It("should return the flags and the positional arguments", func() {
+			setFlags, positionalArgs, err := cli.ParseConfigurationSetFlags([]string{"scs-config-set", "config-server", "git.label=main", "-f", "params.yml", "--dry-run", "--reveal", "-t", "60"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(setFlags).To(Equal(cli.ConfigurationSetFlags{File: "params.yml", DryRun: true, Reveal: true, TimeoutSeconds: 60}))
+			Expect(positionalArgs).To(Equal([]string{"scs-config-set", "config-server", "git.label=main"}))
+		})
+
+		It("should default the timeout", func() {
+			setFlags, _, err := cli.ParseConfigurationSetFlags([]string{"scs-config-set", "config-server", "count=2"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(setFlags).To(Equal(cli.ConfigurationSetFlags{TimeoutSeconds: cli.DefaultTimeoutSeconds}))
+		})
+	})
+
+	Describe("ParseCloneFlags", func() {
+		It("should return the flags and the positional arguments", func() {
+			cloneFlags, positionalArgs, err := cli.ParseCloneFlags([]string{"scs-clone", "config-server", "config-server-feature", "-p", "large", "--bind-apps", "--timeout", "600"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cloneFlags).To(Equal(cli.CloneFlags{Plan: "large", BindApps: true, TimeoutSeconds: 600}))
+			Expect(positionalArgs).To(Equal([]string{"scs-clone", "config-server", "config-server-feature"}))
+		})
+
+		It("should default the timeout", func() {
+			cloneFlags, _, err := cli.ParseCloneFlags([]string{"scs-clone", "config-server", "config-server-feature"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cloneFlags).To(Equal(cli.CloneFlags{TimeoutSeconds: cli.DefaultTimeoutSeconds}))
+		})
+	})
+
+	Describe("ParseExportManifestFlags", func() {
+		It("should return the flags and the positional arguments", func() {
+			exportFlags, positionalArgs, err := cli.ParseExportManifestFlags([]string{"scs-export", "-f", "scs.yml", "--encrypt", "--passphrase-file", "passphrase.txt"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exportFlags).To(Equal(cli.ExportManifestFlags{File: "scs.yml", Encrypt: true, PassphraseFile: "passphrase.txt"}))
+			Expect(positionalArgs).To(Equal([]string{"scs-export"}))
+		})
+	})
+
+	Describe("ParseApplyManifestFlags", func() {
+		It("should return the flags and the positional arguments", func() {
+			applyFlags, positionalArgs, err := cli.ParseApplyManifestFlags([]string{"scs-apply", "scs.yml", "--dry-run", "--passphrase-file", "passphrase.txt", "-t", "900"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(applyFlags).To(Equal(cli.ApplyManifestFlags{DryRun: true, PassphraseFile: "passphrase.txt", TimeoutSeconds: 900}))
+			Expect(positionalArgs).To(Equal([]string{"scs-apply", "scs.yml"}))
+		})
+
+		It("should default the timeout", func() {
+			applyFlags, _, err := cli.ParseApplyManifestFlags([]string{"scs-apply", "scs.yml"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(applyFlags).To(Equal(cli.ApplyManifestFlags{TimeoutSeconds: cli.DefaultTimeoutSeconds}))
+		})
+	})
+
+	Describe("ParseListFlags", func() {
+		It("should return the flag and the positional arguments", func() {
+			allSpaces, positionalArgs, err := cli.ParseListFlags([]string{"scs-list", "--all-spaces"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(allSpaces).To(BeTrue())
+			Expect(positionalArgs).To(Equal([]string{"scs-list"}))
+		})
+
+		It("should list the targeted space by default", func() {
+			allSpaces, _, err := cli.ParseListFlags([]string{"scs-list"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(allSpaces).To(BeFalse())
+		})
+	})
+
+	Describe("ParseViewFlags", func() {
+		It("should return the flags and the positional arguments", func() {
+			viewFlags, positionalArgs, err := cli.ParseViewFlags([]string{"scs-view", "config-server", "--output", "json"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(viewFlags).To(Equal(cli.ViewFlags{Output: "json", IntervalSeconds: cli.DefaultIntervalSeconds}))
+			Expect(positionalArgs).To(Equal([]string{"scs-view", "config-server"}))
+		})
+
+		It("should render text once by default", func() {
+			viewFlags, _, err := cli.ParseViewFlags([]string{"scs-view", "config-server"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(viewFlags).To(Equal(cli.ViewFlags{IntervalSeconds: cli.DefaultIntervalSeconds}))
+		})
+
+		It("should return the watch flags", func() {
+			viewFlags, _, err := cli.ParseViewFlags([]string{"scs-view", "config-server", "-w", "-i", "2"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(viewFlags).To(Equal(cli.ViewFlags{Watch: true, IntervalSeconds: 2}))
+		})
+	})
+
+	Describe("ParseImportSecretsFlags", func() {
+		var (
+			importSecretsFlags          cli.ImportSecretsFlags
+			importSecretsPositionalArgs []string
+		)
+
+		BeforeEach(func() {
+			args = []string{"config-server-import-credhub-secrets", "config-server", "app", "cloud", "master", "-f", "secrets.yml", "--dry-run"}
+		})
+
+		JustBeforeEach(func() {
+			importSecretsFlags, importSecretsPositionalArgs, err = cli.ParseImportSecretsFlags(args)
+		})
+
+		It("should return the flags and the positional arguments", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(importSecretsFlags).To(Equal(cli.ImportSecretsFlags{File: "secrets.yml", DryRun: true}))
+			Expect(importSecretsPositionalArgs).To(Equal([]string{"config-server-import-credhub-secrets", "config-server", "app", "cloud", "master"}))
+		})
+	})
+
+	Describe("ParseExportSecretsFlags", func() {
+		It("should return the flags and the positional arguments", func() {
+			backupFlags, positionalArgs, err := cli.ParseExportSecretsFlags([]string{"config-server-export-credhub-secrets", "config-server", "app", "cloud", "master", "-f", "backup.json", "--passphrase-file", "passphrase"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(backupFlags).To(Equal(cli.BackupFlags{File: "backup.json", PassphraseFile: "passphrase"}))
+			Expect(positionalArgs).To(Equal([]string{"config-server-export-credhub-secrets", "config-server", "app", "cloud", "master"}))
+		})
+
+		It("should not accept --dry-run", func() {
+			_, _, err := cli.ParseExportSecretsFlags([]string{"config-server-export-credhub-secrets", "--dry-run"})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("ParseRestoreSecretsFlags", func() {
+		It("should return the flags and the positional arguments", func() {
+			backupFlags, positionalArgs, err := cli.ParseRestoreSecretsFlags([]string{"config-server-restore-credhub-secrets", "config-server", "--file", "backup.json", "--dry-run"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(backupFlags).To(Equal(cli.BackupFlags{File: "backup.json", DryRun: true}))
+			Expect(positionalArgs).To(Equal([]string{"config-server-restore-credhub-secrets", "config-server"}))
+		})
+	})
+
+	Describe("ParseEncryptFlags", func() {
+		It("should return the flags and the positional arguments", func() {
+			encryptFlags, positionalArgs, err := cli.ParseEncryptFlags([]string{"config-server-encrypt-value", "--offline", "--public-key", "key.pem", "-f", "secret.txt"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(encryptFlags).To(Equal(cli.EncryptFlags{FileToEncrypt: "secret.txt", Offline: true, PublicKey: "key.pem"}))
+			Expect(positionalArgs).To(Equal([]string{"config-server-encrypt-value"}))
+		})
+
+		It("should return the value to encrypt as a positional argument", func() {
+			encryptFlags, positionalArgs, err := cli.ParseEncryptFlags([]string{"config-server-encrypt-value", "config-server", "s3cret"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(encryptFlags).To(Equal(cli.EncryptFlags{}))
+			Expect(positionalArgs).To(Equal([]string{"config-server-encrypt-value", "config-server", "s3cret"}))
+		})
+	})
+
+	Describe("ParseExportEnvFlags", func() {
+		It("should return the flags and the positional arguments", func() {
+			exportEnvFlags, positionalArgs, err := cli.ParseExportEnvFlags([]string{"config-server-export-env", "config-server", "app", "cloud", "--format", "yaml", "-f", "application-local.yml", "--exclude-secrets"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exportEnvFlags).To(Equal(cli.ExportEnvFlags{Format: "yaml", File: "application-local.yml", ExcludeSecrets: true}))
+			Expect(positionalArgs).To(Equal([]string{"config-server-export-env", "config-server", "app", "cloud"}))
+		})
+
+		It("should default to the dotenv format", func() {
+			exportEnvFlags, _, err := cli.ParseExportEnvFlags([]string{"config-server-export-env", "config-server", "app"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exportEnvFlags).To(Equal(cli.ExportEnvFlags{Format: "dotenv"}))
+		})
+	})
+
+	Describe("ParseCompareFlags", func() {
+		It("should return the flags and the positional arguments", func() {
+			compareFlags, positionalArgs, err := cli.ParseCompareFlags([]string{"config-server-compare", "old-config", "new-config", "-e", "app", "--environment", "app/cloud/feature/x", "--environments-file", "environments.txt", "--parameters", "--reveal", "--copy-secrets", "--dry-run"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(compareFlags).To(Equal(cli.CompareFlags{
+				Environments:     []string{"app", "app/cloud/feature/x"},
+				EnvironmentsFile: "environments.txt",
+				Parameters:       true,
+				Reveal:           true,
+				CopySecrets:      true,
+				DryRun:           true,
+			}))
+			Expect(positionalArgs).To(Equal([]string{"config-server-compare", "old-config", "new-config"}))
+		})
+	})
+
+	Describe("ParseLintFlags", func() {
+		It("should return the configuration server instance to fix with and the positional arguments", func() {
+			fix, positionalArgs, err := cli.ParseLintFlags([]string{"config-server-lint", "config-repo", "--fix", "config-server"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fix).To(Equal("config-server"))
+			Expect(positionalArgs).To(Equal([]string{"config-server-lint", "config-repo"}))
+		})
+
+		It("should not fix by default", func() {
+			fix, _, err := cli.ParseLintFlags([]string{"config-server-lint", "config-repo"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fix).To(BeEmpty())
+		})
+	})
+
 	Describe("ParseNoFlags", func() {
 		var noFlagsPositionalArgs []string
 
@@ -0,0 +1,213 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package config
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"code.cloudfoundry.org/cli/plugin"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/format"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/httpclient"
+)
+
+const (
+	RestartApp = "restart"
+	RefreshApp = "refresh"
+)
+
+const passwordCharacters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// GeneratePassword returns a random alphanumeric password of the given length.
+func GeneratePassword(length int) (string, error) {
+	if length < 1 {
+		return "", fmt.Errorf("invalid password length %d", length)
+	}
+	password := make([]byte, length)
+	max := big.NewInt(int64(len(passwordCharacters)))
+	for i := range password {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		password[i] = passwordCharacters[n.Int64()]
+	}
+	return string(password), nil
+}
+
+// AppRollout is a restart or refresh of an application, and its outcome.
+type AppRollout struct {
+	App    string
+	Action string
+	// Instances lists the instances which were restarted or refreshed.
+	Instances    string
+	ErrorMessage string
+}
+
+func (a AppRollout) Failed() bool {
+	return a.ErrorMessage != ""
+}
+
+type AppRoller interface {
+	// Roll restarts or refreshes the given applications one at a time so that they pick up changed configuration. An
+	// application is restarted with a rolling deployment, which needs cf CLI v7 or later, so that it stays available.
+	// Each running instance of an application is refreshed with an unauthenticated request to its route, routed to the
+	// instance with the X-Cf-App-Instance header, so that no Cloud Foundry credentials are sent to it. An application
+	// which is not bound to the config server, or fails, does not stop the remaining applications.
+	Roll(configServerInstanceName string, rollouts []AppRollout, progressWriter io.Writer) ([]AppRollout, error)
+}
+
+type appRoller struct {
+	cliConnection plugin.CliConnection
+	httpClient    httpclient.Client
+}
+
+func NewAppRoller(cliConnection plugin.CliConnection, httpClient httpclient.Client) AppRoller {
+	return &appRoller{
+		cliConnection: cliConnection,
+		httpClient:    httpClient,
+	}
+}
+
+func (r *appRoller) Roll(configServerInstanceName string, rollouts []AppRollout, progressWriter io.Writer) ([]AppRollout, error) {
+	results := []AppRollout{}
+	failed := 0
+	for _, rollout := range rollouts {
+		err := r.roll(configServerInstanceName, &rollout, progressWriter)
+		if err != nil {
+			rollout.ErrorMessage = err.Error()
+			failed++
+		}
+		results = append(results, rollout)
+	}
+
+	if failed > 0 {
+		return results, fmt.Errorf("failed to %s %d of %d applications\n\n%s", actionsOf(rollouts), failed, len(rollouts), RenderAppRollouts(results))
+	}
+	return results, nil
+}
+
+func (r *appRoller) roll(configServerInstanceName string, rollout *AppRollout, progressWriter io.Writer) error {
+	app, err := r.cliConnection.GetApp(rollout.App)
+	if err != nil {
+		return err
+	}
+
+	bound := false
+	for _, service := range app.Services {
+		if service.Name == configServerInstanceName {
+			bound = true
+		}
+	}
+	if !bound {
+		return fmt.Errorf("not bound to %s", configServerInstanceName)
+	}
+
+	switch rollout.Action {
+	case RestartApp:
+		fmt.Fprintf(progressWriter, "Restarting %s\n", rollout.App)
+		_, err = r.cliConnection.CliCommandWithoutTerminalOutput("restart", rollout.App, "--strategy", "rolling")
+		if err == nil {
+			rollout.Instances = "all"
+		}
+		return err
+	case RefreshApp:
+		if len(app.Routes) == 0 {
+			return fmt.Errorf("%s has no route", rollout.App)
+		}
+		route := app.Routes[0]
+		host := route.Domain.Name
+		if route.Host != "" {
+			host = route.Host + "." + host
+		}
+		url := fmt.Sprintf("https://%s%s/actuator/refresh", host, route.Path)
+
+		refreshed := []string{}
+		failures := []string{}
+		for index, appInstance := range app.Instances {
+			if !strings.EqualFold(appInstance.State, "running") {
+				continue
+			}
+			fmt.Fprintf(progressWriter, "Refreshing %s instance %d\n", rollout.App, index)
+			err = r.refreshInstance(url, app.Guid, index)
+			if err != nil {
+				failures = append(failures, fmt.Sprintf("instance %d: %s", index, err))
+				continue
+			}
+			refreshed = append(refreshed, strconv.Itoa(index))
+		}
+		rollout.Instances = strings.Join(refreshed, ", ")
+		if len(failures) > 0 {
+			return fmt.Errorf("%s", strings.Join(failures, "; "))
+		}
+		if len(refreshed) == 0 {
+			return fmt.Errorf("%s has no running instances", rollout.App)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown action %s", rollout.Action)
+}
+
+// refreshInstance triggers the refresh endpoint of one instance of an application.
+func (r *appRoller) refreshInstance(url string, appGuid string, index int) error {
+	req, err := http.NewRequest("POST", url, nil)
+	if err != nil {
+		return fmt.Errorf("Request creation error: %s", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Cf-App-Instance", fmt.Sprintf("%s:%d", appGuid, index))
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("Post to '%s' failed: %s", url, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("Post to '%s' failed: %s", url, resp.Status)
+	}
+	return nil
+}
+
+func actionsOf(rollouts []AppRollout) string {
+	actions := []string{}
+	for _, action := range []string{RestartApp, RefreshApp} {
+		for _, rollout := range rollouts {
+			if rollout.Action == action {
+				actions = append(actions, action)
+				break
+			}
+		}
+	}
+	return strings.Join(actions, " or ")
+}
+
+func RenderAppRollouts(rollouts []AppRollout) string {
+	tab := &format.Table{}
+	tab.Entitle([]string{"application", "action", "instances", "status", "error"})
+	for _, rollout := range rollouts {
+		status := "succeeded"
+		if rollout.Failed() {
+			status = "failed"
+		}
+		tab.AddRow([]string{rollout.App, rollout.Action, rollout.Instances, status, rollout.ErrorMessage})
+	}
+	return tab.String()
+}
@@ -0,0 +1,180 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package config_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"code.cloudfoundry.org/cli/plugin/models"
+	"code.cloudfoundry.org/cli/plugin/pluginfakes"
+	"github.com/fatih/color"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/config"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/httpclient/httpclientfakes"
+)
+
+var _ = Describe("AppRoller", func() {
+
+	const configServerName = "config-server"
+
+	var (
+		fakeCliConnection *pluginfakes.FakeCliConnection
+		fakeHttpClient    *httpclientfakes.FakeClient
+		rollouts          []config.AppRollout
+		results           []config.AppRollout
+		progress          *bytes.Buffer
+		err               error
+	)
+
+	boundApp := func(name string) plugin_models.GetAppModel {
+		return plugin_models.GetAppModel{
+			Guid:      name + "-guid",
+			Name:      name,
+			Instances: []plugin_models.GetApp_AppInstanceFields{{State: "running"}, {State: "starting"}, {State: "running"}},
+			Services:  []plugin_models.GetApp_ServiceSummary{{Name: configServerName}},
+			Routes: []plugin_models.GetApp_RouteSummary{{
+				Host:   name,
+				Domain: plugin_models.GetApp_DomainFields{Name: "apps.example.com"},
+			}},
+		}
+	}
+
+	BeforeEach(func() {
+		color.NoColor = true
+		fakeCliConnection = &pluginfakes.FakeCliConnection{}
+		fakeCliConnection.GetAppStub = func(name string) (plugin_models.GetAppModel, error) {
+			return boundApp(name), nil
+		}
+		fakeHttpClient = &httpclientfakes.FakeClient{}
+		fakeHttpClient.DoReturns(&http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader("[]"))}, nil)
+		progress = &bytes.Buffer{}
+		rollouts = []config.AppRollout{
+			{App: "one", Action: config.RestartApp},
+			{App: "two", Action: config.RefreshApp},
+		}
+	})
+
+	JustBeforeEach(func() {
+		results, err = config.NewAppRoller(fakeCliConnection, fakeHttpClient).Roll(configServerName, rollouts, progress)
+	})
+
+	It("restarts and refreshes the applications in turn", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeCliConnection.CliCommandWithoutTerminalOutputCallCount()).To(Equal(1))
+		Expect(fakeCliConnection.CliCommandWithoutTerminalOutputArgsForCall(0)).To(Equal([]string{"restart", "one", "--strategy", "rolling"}))
+
+		Expect(fakeHttpClient.DoCallCount()).To(Equal(2))
+		for i, instance := range []string{"two-guid:0", "two-guid:2"} {
+			req := fakeHttpClient.DoArgsForCall(i)
+			Expect(req.Method).To(Equal("POST"))
+			Expect(req.URL.String()).To(Equal("https://two.apps.example.com/actuator/refresh"))
+			Expect(req.Header.Get("X-Cf-App-Instance")).To(Equal(instance))
+			Expect(req.Header.Get("Authorization")).To(BeEmpty())
+		}
+		Expect(fakeCliConnection.AccessTokenCallCount()).To(Equal(0))
+
+		Expect(progress.String()).To(Equal("Restarting one\nRefreshing two instance 0\nRefreshing two instance 2\n"))
+		Expect(results).To(Equal([]config.AppRollout{
+			{App: "one", Action: config.RestartApp, Instances: "all"},
+			{App: "two", Action: config.RefreshApp, Instances: "0, 2"},
+		}))
+	})
+
+	Context("when an application is not bound to the config server", func() {
+		BeforeEach(func() {
+			fakeCliConnection.GetAppStub = func(name string) (plugin_models.GetAppModel, error) {
+				app := boundApp(name)
+				if name == "one" {
+					app.Services = nil
+				}
+				return app, nil
+			}
+		})
+
+		It("does not restart it but carries on with the other applications", func() {
+			Expect(fakeCliConnection.CliCommandWithoutTerminalOutputCallCount()).To(Equal(0))
+			Expect(fakeHttpClient.DoCallCount()).To(Equal(2))
+			Expect(results[0].ErrorMessage).To(Equal("not bound to config-server"))
+			Expect(results[1].Failed()).To(BeFalse())
+			Expect(err).To(MatchError(HavePrefix("failed to restart or refresh 1 of 2 applications\n\n")))
+		})
+	})
+
+	Context("when the refresh of an instance is rejected", func() {
+		BeforeEach(func() {
+			fakeHttpClient.DoReturnsOnCall(1, &http.Response{StatusCode: http.StatusUnauthorized, Status: "401 Unauthorized", Body: ioutil.NopCloser(strings.NewReader(""))}, nil)
+		})
+
+		It("reports which instances were refreshed and which failed", func() {
+			Expect(results[0].Failed()).To(BeFalse())
+			Expect(results[1].Instances).To(Equal("0"))
+			Expect(results[1].ErrorMessage).To(Equal("instance 2: Post to 'https://two.apps.example.com/actuator/refresh' failed: 401 Unauthorized"))
+			Expect(err).To(MatchError(HavePrefix("failed to restart or refresh 1 of 2 applications\n\n")))
+		})
+	})
+
+	Context("when an application has no running instances", func() {
+		BeforeEach(func() {
+			rollouts = rollouts[1:]
+			fakeCliConnection.GetAppStub = func(name string) (plugin_models.GetAppModel, error) {
+				app := boundApp(name)
+				app.Instances = []plugin_models.GetApp_AppInstanceFields{{State: "crashed"}}
+				return app, nil
+			}
+		})
+
+		It("reports the failure", func() {
+			Expect(fakeHttpClient.DoCallCount()).To(Equal(0))
+			Expect(results[0].ErrorMessage).To(Equal("two has no running instances"))
+		})
+	})
+
+	Context("when a restart fails", func() {
+		BeforeEach(func() {
+			fakeCliConnection.CliCommandWithoutTerminalOutputReturns(nil, errors.New("app crashed"))
+		})
+
+		It("reports the failure", func() {
+			Expect(results[0].ErrorMessage).To(Equal("app crashed"))
+			Expect(trimLines(config.RenderAppRollouts(results))).To(Equal(trimLines(
+				"application action  instances status    error\n" +
+					"one         restart           failed    app crashed\n" +
+					"two         refresh 0, 2      succeeded\n")))
+		})
+	})
+})
+
+var _ = Describe("GeneratePassword", func() {
+	It("generates alphanumeric passwords of the given length", func() {
+		password, err := config.GeneratePassword(40)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(password).To(MatchRegexp("^[a-zA-Z0-9]{40}$"))
+
+		other, _ := config.GeneratePassword(40)
+		Expect(other).NotTo(Equal(password))
+	})
+
+	It("rejects an invalid length", func() {
+		_, err := config.GeneratePassword(0)
+		Expect(err).To(MatchError("invalid password length 0"))
+	})
+})
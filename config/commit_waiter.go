@@ -0,0 +1,77 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+type CommitWaiter interface {
+	// WaitForCommit polls the environment at the given coordinates until the config server serves the given Git
+	// commit. An environment reports a single version, that of the repository it is served from, so the commit of only
+	// one repository can be checked: more than one commit is rejected.
+	WaitForCommit(coordinates EnvironmentCoordinates, commitIds []string, progressWriter io.Writer) error
+}
+
+type commitWaiter struct {
+	environmentFetcher EnvironmentFetcher
+	timeout            time.Duration
+	pollInterval       time.Duration
+}
+
+func NewCommitWaiter(environmentFetcher EnvironmentFetcher, timeout time.Duration, pollInterval time.Duration) CommitWaiter {
+	return &commitWaiter{
+		environmentFetcher: environmentFetcher,
+		timeout:            timeout,
+		pollInterval:       pollInterval,
+	}
+}
+
+func (w *commitWaiter) WaitForCommit(coordinates EnvironmentCoordinates, commitIds []string, progressWriter io.Writer) error {
+	if len(commitIds) == 0 {
+		return errors.New("no commit was reported for the refreshed mirrors, so there is nothing to wait for")
+	}
+	if len(commitIds) > 1 {
+		return fmt.Errorf("cannot wait for the commits of %d mirrors, since only the commit of the repository serving %s can be checked: select one mirror with --repository", len(commitIds), coordinates)
+	}
+	commitId := commitIds[0]
+
+	fmt.Fprintf(progressWriter, "Waiting for %s to serve commit %s\n", coordinates, commitId)
+	deadline := time.Now().Add(w.timeout)
+	for {
+		environment, err := FetchEnvironment(w.environmentFetcher, coordinates)
+		if err != nil {
+			return err
+		}
+		if environment.Version == "" {
+			return fmt.Errorf("%s is not served from a Git repository, so its commit cannot be checked", coordinates)
+		}
+		if environment.Version == commitId {
+			fmt.Fprintf(progressWriter, "Serving commit %s\n", commitId)
+			return nil
+		}
+
+		if !time.Now().Add(w.pollInterval).Before(deadline) {
+			return fmt.Errorf("timed out after %s waiting for %s to serve commit %s: still serving %s", w.timeout, coordinates, commitId, environment.Version)
+		}
+		fmt.Fprintf(progressWriter, "Still serving commit %s\n", environment.Version)
+		time.Sleep(w.pollInterval)
+	}
+}
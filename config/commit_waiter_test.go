@@ -0,0 +1,123 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package config_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/plugin/pluginfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/config"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/httpclient/httpclientfakes"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/serviceutil/serviceutilfakes"
+)
+
+var _ = Describe("CommitWaiter", func() {
+
+	var (
+		fakeCliConnection *pluginfakes.FakeCliConnection
+		fakeAuthClient    *httpclientfakes.FakeAuthenticatedClient
+		fakeResolver      *serviceutilfakes.FakeServiceInstanceResolver
+		coordinates       config.EnvironmentCoordinates
+		commitIds         []string
+		timeout           time.Duration
+		progress          *bytes.Buffer
+		err               error
+	)
+
+	environmentWithVersion := func(version string) io.ReadCloser {
+		return ioutil.NopCloser(strings.NewReader(`{"name":"app","version":"` + version + `","propertySources":[]}`))
+	}
+
+	BeforeEach(func() {
+		fakeCliConnection = &pluginfakes.FakeCliConnection{}
+		fakeAuthClient = &httpclientfakes.FakeAuthenticatedClient{}
+		fakeResolver = &serviceutilfakes.FakeServiceInstanceResolver{}
+		fakeCliConnection.AccessTokenReturns("bearer fake-access-token", nil)
+		fakeResolver.GetServiceInstanceUrlReturns("service-uri/", nil)
+
+		coordinates = config.EnvironmentCoordinates{ConfigServerInstanceName: "config-server", Application: "app"}
+		commitIds = []string{"new"}
+		timeout = time.Second
+		progress = &bytes.Buffer{}
+
+		fakeAuthClient.DoAuthenticatedGetReturnsOnCall(0, environmentWithVersion("old"), http.StatusOK, nil)
+		fakeAuthClient.DoAuthenticatedGetReturnsOnCall(1, environmentWithVersion("new"), http.StatusOK, nil)
+	})
+
+	JustBeforeEach(func() {
+		fetcher := config.NewEnvironmentFetcher(fakeCliConnection, fakeAuthClient, fakeResolver)
+		err = config.NewCommitWaiter(fetcher, timeout, time.Millisecond).WaitForCommit(coordinates, commitIds, progress)
+	})
+
+	It("polls until the expected commit is served", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeAuthClient.DoAuthenticatedGetCallCount()).To(Equal(2))
+		url, _ := fakeAuthClient.DoAuthenticatedGetArgsForCall(1)
+		Expect(url).To(Equal("service-uri/app/default"))
+		Expect(progress.String()).To(ContainSubstring("Still serving commit old"))
+		Expect(progress.String()).To(ContainSubstring("Serving commit new"))
+	})
+
+	Context("when several mirrors were refreshed", func() {
+		BeforeEach(func() {
+			commitIds = []string{"other", "new"}
+		})
+
+		It("fails without polling, since only one repository's commit can be checked", func() {
+			Expect(err).To(MatchError("cannot wait for the commits of 2 mirrors, since only the commit of the repository serving app/default/(default label) on config-server can be checked: select one mirror with --repository"))
+			Expect(fakeAuthClient.DoAuthenticatedGetCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when the commit is not served before the timeout", func() {
+		BeforeEach(func() {
+			timeout = 0
+		})
+
+		It("fails with the commit being served", func() {
+			Expect(err).To(MatchError("timed out after 0s waiting for app/default/(default label) on config-server to serve commit new: still serving old"))
+		})
+	})
+
+	Context("when the environment is not backed by Git", func() {
+		BeforeEach(func() {
+			fakeAuthClient.DoAuthenticatedGetReturnsOnCall(0, environmentWithVersion(""), http.StatusOK, nil)
+		})
+
+		It("fails", func() {
+			Expect(err).To(MatchError("app/default/(default label) on config-server is not served from a Git repository, so its commit cannot be checked"))
+		})
+	})
+
+	Context("when there are no commits to wait for", func() {
+		BeforeEach(func() {
+			commitIds = []string{}
+		})
+
+		It("fails without polling", func() {
+			Expect(err).To(HaveOccurred())
+			Expect(fakeAuthClient.DoAuthenticatedGetCallCount()).To(Equal(0))
+		})
+	})
+})
@@ -0,0 +1,248 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// EnvironmentComparison is the outcome of comparing the environment of an application, profile and label served by
+// two config servers.
+type EnvironmentComparison struct {
+	Environment  EnvironmentCoordinates
+	Differences  []PropertyDifference
+	ErrorMessage string
+}
+
+func (c EnvironmentComparison) Failed() bool {
+	return c.ErrorMessage != ""
+}
+
+// ParseEnvironmentSpecs parses environments given as APP_NAME[/PROFILE[/LABEL]], either directly or one per line of a
+// file. Blank lines and lines starting with "#" in the file are ignored. The label may contain "/".
+func ParseEnvironmentSpecs(specs []string, fileName string) ([]EnvironmentCoordinates, error) {
+	if fileName != "" {
+		contents, err := ReadFileContents(fileName)
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(strings.NewReader(contents))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			specs = append(specs, line)
+		}
+	}
+
+	environments := []EnvironmentCoordinates{}
+	for _, spec := range specs {
+		parts := strings.SplitN(spec, "/", 3)
+		if parts[0] == "" {
+			return nil, fmt.Errorf("Invalid environment '%s': use APP_NAME[/PROFILE[/LABEL]]", spec)
+		}
+		environment := EnvironmentCoordinates{Application: parts[0]}
+		if len(parts) > 1 {
+			environment.Profile = parts[1]
+		}
+		if len(parts) > 2 {
+			environment.Label = parts[2]
+		}
+		environments = append(environments, environment)
+	}
+	if len(environments) == 0 {
+		return nil, errors.New("No environments to compare: use --environment or --environments-file")
+	}
+	return environments, nil
+}
+
+// CompareConfigServers fetches each environment from both config servers and compares their effective properties. An
+// environment which cannot be fetched from either config server is reported as failed without stopping the others.
+func CompareConfigServers(fetcher EnvironmentFetcher, fromConfigServer string, toConfigServer string, environments []EnvironmentCoordinates) []EnvironmentComparison {
+	comparisons := []EnvironmentComparison{}
+	for _, environment := range environments {
+		comparison := EnvironmentComparison{Environment: environment}
+
+		from := environment
+		from.ConfigServerInstanceName = fromConfigServer
+		to := environment
+		to.ConfigServerInstanceName = toConfigServer
+
+		fromEnvironment, err := FetchEnvironment(fetcher, from)
+		if err == nil {
+			var toEnvironment *Environment
+			toEnvironment, err = FetchEnvironment(fetcher, to)
+			if err == nil {
+				comparison.Differences = DiffEnvironments(fromEnvironment, toEnvironment)
+			}
+		}
+		if err != nil {
+			comparison.ErrorMessage = err.Error()
+		}
+		comparisons = append(comparisons, comparison)
+	}
+	return comparisons
+}
+
+// SecretsCopy is the outcome of copying the CredHub secrets of an application, profile and label from one config
+// server to another.
+type SecretsCopy struct {
+	Environment  EnvironmentCoordinates
+	Imports      []SecretImport
+	ErrorMessage string
+}
+
+func (c SecretsCopy) Failed() bool {
+	if c.ErrorMessage != "" {
+		return true
+	}
+	for _, secretImport := range c.Imports {
+		if secretImport.Status == SecretFailed {
+			return true
+		}
+	}
+	return false
+}
+
+// CopySecrets copies the properties served from the CredHub source of exactly the application, profile and label of
+// each environment from one config server to the other, adding each property as a secret the way Import does. Other
+// properties, such as those served from Git, cannot be copied through a config server. An environment needs a label,
+// since the CredHub source depends on it, and a single profile. An environment which cannot be copied is reported as
+// failed without stopping the others. No secrets are added if dryRun is set.
+func CopySecrets(fetcher EnvironmentFetcher, credHubSecret CredHubSecret, fromConfigServer string, toConfigServer string, environments []EnvironmentCoordinates, dryRun bool) []SecretsCopy {
+	copies := []SecretsCopy{}
+	for _, environment := range environments {
+		secretsCopy := SecretsCopy{Environment: environment}
+		profile := profileOrDefault(environment.Profile)
+
+		switch {
+		case environment.Label == "":
+			secretsCopy.ErrorMessage = "give the label of the environment to copy its secrets"
+		case strings.Contains(profile, ","):
+			secretsCopy.ErrorMessage = "copy the secrets of one profile at a time"
+		default:
+			from := environment
+			from.ConfigServerInstanceName = fromConfigServer
+			fromEnvironment, err := FetchEnvironment(fetcher, from)
+			if err != nil {
+				secretsCopy.ErrorMessage = err.Error()
+				break
+			}
+
+			secrets := map[string]interface{}{}
+			sourceName := credHubSourceName(environment.Application, profile, environment.Label)
+			for _, propertySource := range fromEnvironment.PropertySources {
+				if propertySource.Name == sourceName {
+					for key, value := range propertySource.Source {
+						secrets[key] = value
+					}
+				}
+			}
+			if len(secrets) == 0 {
+				secretsCopy.Imports = []SecretImport{}
+				break
+			}
+
+			imports, err := credHubSecret.Import(toConfigServer, environment.Application, profile, environment.Label, secrets, dryRun)
+			if imports == nil && err != nil {
+				secretsCopy.ErrorMessage = err.Error()
+			}
+			secretsCopy.Imports = imports
+		}
+		copies = append(copies, secretsCopy)
+	}
+	return copies
+}
+
+// RenderSecretsCopies summarises the secrets copied for each environment. Secret values are never shown. It returns an
+// error, holding the rendered summary, if the secrets of any environment could not all be copied.
+func RenderSecretsCopies(fromConfigServer string, toConfigServer string, copies []SecretsCopy, dryRun bool) (string, error) {
+	var buffer bytes.Buffer
+	if dryRun {
+		buffer.WriteString(fmt.Sprintf("Dry run, secrets which would be copied from %s to %s:\n", fromConfigServer, toConfigServer))
+	} else {
+		buffer.WriteString(fmt.Sprintf("Secrets copied from %s to %s:\n", fromConfigServer, toConfigServer))
+	}
+
+	failed := 0
+	for _, secretsCopy := range copies {
+		environment := secretsCopy.Environment
+		name := fmt.Sprintf("%s/%s/%s", environment.Application, profileOrDefault(environment.Profile), labelOrDefault(environment.Label))
+		if secretsCopy.Failed() {
+			failed++
+		}
+		switch {
+		case secretsCopy.ErrorMessage != "":
+			buffer.WriteString(fmt.Sprintf("\n%s: %s\n", name, secretsCopy.ErrorMessage))
+		case len(secretsCopy.Imports) == 0:
+			buffer.WriteString(fmt.Sprintf("\n%s: no CredHub secrets\n", name))
+		default:
+			buffer.WriteString(fmt.Sprintf("\n%s: %s\n", name, strings.TrimRight(RenderSecretImports(secretsCopy.Imports, dryRun), "\n")))
+		}
+	}
+
+	if failed > 0 {
+		return "", fmt.Errorf("failed to copy the secrets of %d of %d environments\n\n%s", failed, len(copies), buffer.String())
+	}
+	return buffer.String(), nil
+}
+
+// RenderConfigServerComparison renders the differences of each environment and, unless parameterDifferences is nil,
+// of the configuration parameters. It returns an error, holding the rendered comparison, if any environment could not
+// be compared.
+func RenderConfigServerComparison(fromConfigServer string, toConfigServer string, comparisons []EnvironmentComparison, parameterDifferences []PropertyDifference, reveal bool) (string, error) {
+	var buffer bytes.Buffer
+	buffer.WriteString(fmt.Sprintf("from: %s\nto:   %s\n", fromConfigServer, toConfigServer))
+
+	differing, failed := 0, 0
+	for _, comparison := range comparisons {
+		environment := comparison.Environment
+		name := fmt.Sprintf("%s/%s/%s", environment.Application, profileOrDefault(environment.Profile), labelOrDefault(environment.Label))
+		switch {
+		case comparison.Failed():
+			failed++
+			buffer.WriteString(fmt.Sprintf("\n%s: %s\n", name, comparison.ErrorMessage))
+		case len(comparison.Differences) == 0:
+			buffer.WriteString(fmt.Sprintf("\n%s: no differences\n", name))
+		default:
+			differing++
+			buffer.WriteString(fmt.Sprintf("\n%s:\n", name))
+			buffer.WriteString(RenderPropertyDifferences(comparison.Differences, reveal))
+		}
+	}
+
+	if parameterDifferences != nil {
+		if len(parameterDifferences) == 0 {
+			buffer.WriteString("\nconfiguration parameters: no differences\n")
+		} else {
+			buffer.WriteString("\nconfiguration parameters:\n")
+			buffer.WriteString(RenderPropertyDifferences(parameterDifferences, reveal))
+		}
+	}
+
+	buffer.WriteString(fmt.Sprintf("\n%d environments compared: %d differ, %d failed", len(comparisons), differing, failed))
+
+	if failed > 0 {
+		return "", fmt.Errorf("failed to compare %d of %d environments\n\n%s", failed, len(comparisons), buffer.String())
+	}
+	return buffer.String(), nil
+}
@@ -0,0 +1,260 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package config_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/config"
+)
+
+type stubEnvironmentFetcher struct {
+	environments map[config.EnvironmentCoordinates]*config.Environment
+}
+
+func (f *stubEnvironmentFetcher) Fetch(configServerInstanceName string, application string, profile string, label string) (*config.Environment, error) {
+	environment, ok := f.environments[config.EnvironmentCoordinates{ConfigServerInstanceName: configServerInstanceName, Application: application, Profile: profile, Label: label}]
+	if !ok {
+		return nil, errors.New("Configuration not found")
+	}
+	return environment, nil
+}
+
+func (f *stubEnvironmentFetcher) FetchFormatted(configServerInstanceName string, application string, profile string, label string, outputFormat string) (string, error) {
+	return "", errors.New("not implemented")
+}
+
+func environmentOf(source map[string]interface{}) *config.Environment {
+	return &config.Environment{PropertySources: []config.PropertySource{{Name: "https://github.com/org/repo.git/app.yml", Source: source}}}
+}
+
+type stubCredHubSecret struct {
+	config.CredHubSecret
+	imports []config.SecretImport
+	err     error
+	calls   []string
+	secrets []map[string]interface{}
+}
+
+func (s *stubCredHubSecret) Import(configServerInstanceName string, application string, profile string, label string, secrets map[string]interface{}, dryRun bool) ([]config.SecretImport, error) {
+	s.calls = append(s.calls, fmt.Sprintf("%s %s/%s/%s dry run %t", configServerInstanceName, application, profile, label, dryRun))
+	s.secrets = append(s.secrets, secrets)
+	return s.imports, s.err
+}
+
+var _ = Describe("Compare", func() {
+
+	Describe("ParseEnvironmentSpecs", func() {
+		It("parses environments given directly and in a file", func() {
+			testDir, err := ioutil.TempDir("", "scs-cli-")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(testDir)
+			fileName := filepath.Join(testDir, "environments.txt")
+			Expect(ioutil.WriteFile(fileName, []byte("# environments\n\napp/cloud\n other/default/feature/x \n"), 0600)).To(Succeed())
+
+			environments, err := config.ParseEnvironmentSpecs([]string{"app"}, fileName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(environments).To(Equal([]config.EnvironmentCoordinates{
+				{Application: "app"},
+				{Application: "app", Profile: "cloud"},
+				{Application: "other", Profile: "default", Label: "feature/x"},
+			}))
+		})
+
+		It("rejects an environment without an application", func() {
+			_, err := config.ParseEnvironmentSpecs([]string{"/cloud"}, "")
+			Expect(err).To(MatchError("Invalid environment '/cloud': use APP_NAME[/PROFILE[/LABEL]]"))
+		})
+
+		It("requires at least one environment", func() {
+			_, err := config.ParseEnvironmentSpecs(nil, "")
+			Expect(err).To(MatchError("No environments to compare: use --environment or --environments-file"))
+		})
+	})
+
+	Describe("CompareConfigServers", func() {
+		var (
+			fetcher     *stubEnvironmentFetcher
+			comparisons []config.EnvironmentComparison
+		)
+
+		BeforeEach(func() {
+			color.NoColor = true
+			fetcher = &stubEnvironmentFetcher{environments: map[config.EnvironmentCoordinates]*config.Environment{
+				{ConfigServerInstanceName: "old-config", Application: "app"}:                       environmentOf(map[string]interface{}{"server.port": "8080", "db.password": "s3cret"}),
+				{ConfigServerInstanceName: "new-config", Application: "app"}:                       environmentOf(map[string]interface{}{"server.port": "8080", "db.password": "s3cret"}),
+				{ConfigServerInstanceName: "old-config", Application: "app", Profile: "cloud"}:     environmentOf(map[string]interface{}{"server.port": "8080", "db.password": "old"}),
+				{ConfigServerInstanceName: "new-config", Application: "app", Profile: "cloud"}:     environmentOf(map[string]interface{}{"server.port": "9090", "db.password": "new"}),
+				{ConfigServerInstanceName: "old-config", Application: "other", Label: "feature/x"}: environmentOf(map[string]interface{}{}),
+			}}
+
+			comparisons = config.CompareConfigServers(fetcher, "old-config", "new-config", []config.EnvironmentCoordinates{
+				{Application: "app"},
+				{Application: "app", Profile: "cloud"},
+				{Application: "other", Label: "feature/x"},
+			})
+		})
+
+		It("compares each environment", func() {
+			Expect(comparisons).To(HaveLen(3))
+			Expect(comparisons[0].Differences).To(BeEmpty())
+			Expect(comparisons[1].Differences).To(HaveLen(2))
+			Expect(comparisons[2].Failed()).To(BeTrue())
+			Expect(comparisons[2].ErrorMessage).To(Equal("Error fetching other/default/feature/x on new-config: Configuration not found"))
+		})
+
+		It("renders the differences, masking secrets, and fails if an environment could not be compared", func() {
+			_, err := config.RenderConfigServerComparison("old-config", "new-config", comparisons, nil, false)
+			Expect(err).To(HaveOccurred())
+			Expect(trimLines(err.Error())).To(Equal(trimLines(`failed to compare 1 of 3 environments
+
+from: old-config
+to:   new-config
+
+app/default/(default label): no differences
+
+app/cloud/(default label):
+property    change  from   to
+db.password changed ****** ******
+server.port changed 8080   9090
+
+other/default/feature/x: Error fetching other/default/feature/x on new-config: Configuration not found
+
+3 environments compared: 1 differ, 1 failed`)))
+		})
+
+		It("renders the differences of the configuration parameters", func() {
+			parameterDifferences := []config.PropertyDifference{
+				{Key: "git.password", Change: config.Changed, From: &config.Property{Key: "git.password", Value: "old"}, To: &config.Property{Key: "git.password", Value: "new"}},
+				{Key: "git.searchPaths", Change: config.Added, To: &config.Property{Key: "git.searchPaths", Value: "app*"}},
+				{Key: "git.uri", Change: config.Changed, From: &config.Property{Key: "git.uri", Value: "https://github.com/org/old.git"}, To: &config.Property{Key: "git.uri", Value: "https://github.com/org/new.git"}},
+			}
+
+			output, err := config.RenderConfigServerComparison("old-config", "new-config", comparisons[:2], parameterDifferences, true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(trimLines(output)).To(Equal(trimLines(`from: old-config
+to:   new-config
+
+app/default/(default label): no differences
+
+app/cloud/(default label):
+property    change  from to
+db.password changed old  new
+server.port changed 8080 9090
+
+configuration parameters:
+property        change  from                           to
+git.password    changed old                            new
+git.searchPaths added                                  app*
+git.uri         changed https://github.com/org/old.git https://github.com/org/new.git
+
+2 environments compared: 1 differ, 0 failed`)))
+		})
+	})
+
+	Describe("CopySecrets", func() {
+		var (
+			fetcher       *stubEnvironmentFetcher
+			credHubSecret *stubCredHubSecret
+			environments  []config.EnvironmentCoordinates
+			copies        []config.SecretsCopy
+		)
+
+		BeforeEach(func() {
+			fetcher = &stubEnvironmentFetcher{environments: map[config.EnvironmentCoordinates]*config.Environment{
+				{ConfigServerInstanceName: "old-config", Application: "app", Label: "main"}: {PropertySources: []config.PropertySource{
+					{Name: "credhub-app-default-main", Source: map[string]interface{}{"db.password": "s3cret"}},
+					{Name: "credhub-application-default-main", Source: map[string]interface{}{"shared.token": "t0ken"}},
+					{Name: "https://github.com/org/repo.git/app.yml", Source: map[string]interface{}{"server.port": "8080"}},
+				}},
+				{ConfigServerInstanceName: "old-config", Application: "other", Profile: "cloud", Label: "main"}: environmentOf(map[string]interface{}{"server.port": "8080"}),
+			}}
+			credHubSecret = &stubCredHubSecret{imports: []config.SecretImport{{Key: "db.password", Path: "app/default/main/db.password", Status: config.SecretCreated}}}
+			environments = []config.EnvironmentCoordinates{
+				{Application: "app", Label: "main"},
+				{Application: "other", Profile: "cloud", Label: "main"},
+				{Application: "app", Profile: "cloud"},
+				{Application: "app", Profile: "cloud,dev", Label: "main"},
+				{Application: "missing", Label: "main"},
+			}
+		})
+
+		JustBeforeEach(func() {
+			copies = config.CopySecrets(fetcher, credHubSecret, "old-config", "new-config", environments, true)
+		})
+
+		It("copies only the secrets of the CredHub source of each environment", func() {
+			Expect(credHubSecret.calls).To(Equal([]string{"new-config app/default/main dry run true"}))
+			Expect(credHubSecret.secrets).To(Equal([]map[string]interface{}{{"db.password": "s3cret"}}))
+			Expect(copies).To(HaveLen(5))
+			Expect(copies[0].Failed()).To(BeFalse())
+			Expect(copies[0].Imports).To(Equal(credHubSecret.imports))
+			Expect(copies[1].Failed()).To(BeFalse())
+			Expect(copies[1].Imports).To(BeEmpty())
+		})
+
+		It("reports environments which cannot be copied without stopping the others", func() {
+			Expect(copies[2].ErrorMessage).To(Equal("give the label of the environment to copy its secrets"))
+			Expect(copies[3].ErrorMessage).To(Equal("copy the secrets of one profile at a time"))
+			Expect(copies[4].ErrorMessage).To(Equal("Error fetching missing/default/main on old-config: Configuration not found"))
+
+			_, err := config.RenderSecretsCopies("old-config", "new-config", copies, true)
+			Expect(err).To(HaveOccurred())
+			Expect(trimLines(err.Error())).To(Equal(trimLines(`failed to copy the secrets of 3 of 5 environments
+
+Dry run, secrets which would be copied from old-config to new-config:
+
+app/default/main: Dry run, no secrets were imported: 1 would be created, 0 would be updated, 0 would fail
+
+property    path                         status  error
+db.password app/default/main/db.password created
+
+other/cloud/main: no CredHub secrets
+
+app/cloud/(default label): give the label of the environment to copy its secrets
+
+app/cloud,dev/main: copy the secrets of one profile at a time
+
+missing/default/main: Error fetching missing/default/main on old-config: Configuration not found
+`)))
+		})
+
+		Context("when some secrets cannot be added", func() {
+			BeforeEach(func() {
+				credHubSecret.imports = []config.SecretImport{{Key: "db.password", Path: "app/default/main/db.password", Status: config.SecretFailed, ErrorMessage: "forbidden"}}
+				credHubSecret.err = errors.New("failed to import 1 of 1 secrets")
+				environments = environments[:2]
+			})
+
+			It("reports the failed secrets", func() {
+				Expect(copies[0].Failed()).To(BeTrue())
+				Expect(copies[0].ErrorMessage).To(BeEmpty())
+
+				_, err := config.RenderSecretsCopies("old-config", "new-config", copies, false)
+				Expect(err).To(MatchError(ContainSubstring("failed to copy the secrets of 1 of 2 environments")))
+				Expect(err).To(MatchError(ContainSubstring("db.password app/default/main/db.password failed forbidden")))
+			})
+		})
+	})
+})
@@ -0,0 +1,177 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package config
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// PassphraseEnvironmentVariable names the environment variable holding the passphrase of a CredHub backup when no
+// passphrase file is given.
+const PassphraseEnvironmentVariable = "SCS_BACKUP_PASSPHRASE"
+
+const (
+	backupVersion    = 1
+	backupKdf        = "pbkdf2-sha256"
+	backupIterations = 600000
+	backupSaltSize   = 16
+	backupKeySize    = 32
+)
+
+// CredHubBackup holds the secrets of one application, profile and label.
+type CredHubBackup struct {
+	Application string                 `json:"application"`
+	Profile     string                 `json:"profile"`
+	Label       string                 `json:"label"`
+	Secrets     map[string]interface{} `json:"secrets"`
+}
+
+func (b *CredHubBackup) String() string {
+	return fmt.Sprintf("%s/%s/%s", b.Application, b.Profile, b.Label)
+}
+
+// encryptedBackup is the file format of a CredHub backup. The backup is encrypted with AES-256-GCM using a key derived
+// from a passphrase, so it can be restored into a config server with a different encryption key.
+type encryptedBackup struct {
+	Version    int    `json:"version"`
+	Kdf        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// ReadPassphrase reads the passphrase of a CredHub backup from the given file or, if no file is given, from the
+// environment.
+func ReadPassphrase(passphraseFile string) (string, error) {
+	passphrase := os.Getenv(PassphraseEnvironmentVariable)
+	if passphraseFile != "" {
+		contents, err := ReadFileContents(passphraseFile)
+		if err != nil {
+			return "", err
+		}
+		passphrase = strings.TrimRight(contents, "\r\n")
+	}
+	if passphrase == "" {
+		return "", fmt.Errorf("No passphrase provided: use --passphrase-file or set %s", PassphraseEnvironmentVariable)
+	}
+	return passphrase, nil
+}
+
+func WriteCredHubBackup(fileName string, backup *CredHubBackup, passphrase string) error {
+	contents, err := EncryptCredHubBackup(backup, passphrase)
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(fileName, contents, 0600)
+	if err != nil {
+		return fmt.Errorf("Error writing file at path %s : %s", fileName, err)
+	}
+	return nil
+}
+
+func ReadCredHubBackup(fileName string, passphrase string) (*CredHubBackup, error) {
+	contents, err := ReadFileContents(fileName)
+	if err != nil {
+		return nil, err
+	}
+	return DecryptCredHubBackup([]byte(contents), passphrase)
+}
+
+func EncryptCredHubBackup(backup *CredHubBackup, passphrase string) ([]byte, error) {
+	plaintext, err := json.Marshal(backup)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, backupSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	aead, err := backupCipher(passphrase, salt, backupIterations)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(encryptedBackup{
+		Version:    backupVersion,
+		Kdf:        backupKdf,
+		Iterations: backupIterations,
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, plaintext, nil),
+	}, "", "  ")
+}
+
+func DecryptCredHubBackup(contents []byte, passphrase string) (*CredHubBackup, error) {
+	var encrypted encryptedBackup
+	err := json.Unmarshal(contents, &encrypted)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid backup file: %s", err)
+	}
+	if encrypted.Version != backupVersion || encrypted.Kdf != backupKdf {
+		return nil, fmt.Errorf("Unsupported backup file: version %d, key derivation %s", encrypted.Version, encrypted.Kdf)
+	}
+
+	aead, err := backupCipher(passphrase, encrypted.Salt, encrypted.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	if len(encrypted.Nonce) != aead.NonceSize() {
+		return nil, errors.New("Invalid backup file: bad nonce")
+	}
+	plaintext, err := aead.Open(nil, encrypted.Nonce, encrypted.Ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("Cannot decrypt backup file: wrong passphrase or corrupted file")
+	}
+
+	backup := &CredHubBackup{}
+	decoder := json.NewDecoder(bytes.NewReader(plaintext))
+	decoder.UseNumber()
+	err = decoder.Decode(backup)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid backup file: %s", err)
+	}
+	return backup, nil
+}
+
+func backupCipher(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, backupKeySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
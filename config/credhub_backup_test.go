@@ -0,0 +1,141 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package config_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/config"
+)
+
+var _ = Describe("CredHubBackup", func() {
+
+	var backup *config.CredHubBackup
+
+	BeforeEach(func() {
+		backup = &config.CredHubBackup{
+			Application: "app",
+			Profile:     "cloud",
+			Label:       "master",
+			Secrets:     map[string]interface{}{"db.password": "s3cret", "db.port": json.Number("5432")},
+		}
+	})
+
+	Describe("EncryptCredHubBackup", func() {
+		var (
+			contents []byte
+			err      error
+		)
+
+		BeforeEach(func() {
+			contents, err = config.EncryptCredHubBackup(backup, "passphrase")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("does not contain the secrets in clear text", func() {
+			Expect(string(contents)).NotTo(ContainSubstring("s3cret"))
+			Expect(string(contents)).NotTo(ContainSubstring("db.password"))
+		})
+
+		It("can be decrypted with the passphrase", func() {
+			decrypted, err := config.DecryptCredHubBackup(contents, "passphrase")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decrypted).To(Equal(backup))
+		})
+
+		It("cannot be decrypted with another passphrase", func() {
+			_, err := config.DecryptCredHubBackup(contents, "wrong")
+			Expect(err).To(MatchError("Cannot decrypt backup file: wrong passphrase or corrupted file"))
+		})
+	})
+
+	Describe("DecryptCredHubBackup", func() {
+		It("rejects a file which is not a backup", func() {
+			_, err := config.DecryptCredHubBackup([]byte("db.password=s3cret"), "passphrase")
+			Expect(err).To(MatchError(HavePrefix("Invalid backup file: ")))
+		})
+
+		It("rejects an unsupported backup version", func() {
+			_, err := config.DecryptCredHubBackup([]byte(`{"version": 2, "kdf": "pbkdf2-sha256"}`), "passphrase")
+			Expect(err).To(MatchError("Unsupported backup file: version 2, key derivation pbkdf2-sha256"))
+		})
+	})
+
+	Describe("WriteCredHubBackup and ReadCredHubBackup", func() {
+		var testDir string
+
+		BeforeEach(func() {
+			var err error
+			testDir, err = ioutil.TempDir("", "scs-cli-")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(testDir)
+		})
+
+		It("writes a backup readable only by its owner and reads it back", func() {
+			fileName := filepath.Join(testDir, "backup.json")
+			Expect(config.WriteCredHubBackup(fileName, backup, "passphrase")).To(Succeed())
+
+			info, err := os.Stat(fileName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+			read, err := config.ReadCredHubBackup(fileName, "passphrase")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(read).To(Equal(backup))
+		})
+	})
+
+	Describe("ReadPassphrase", func() {
+		var testDir string
+
+		BeforeEach(func() {
+			var err error
+			testDir, err = ioutil.TempDir("", "scs-cli-")
+			Expect(err).NotTo(HaveOccurred())
+			os.Setenv(config.PassphraseEnvironmentVariable, "from-environment")
+		})
+
+		AfterEach(func() {
+			os.Unsetenv(config.PassphraseEnvironmentVariable)
+			os.RemoveAll(testDir)
+		})
+
+		It("reads the passphrase from the file without its trailing newline", func() {
+			fileName := filepath.Join(testDir, "passphrase")
+			Expect(ioutil.WriteFile(fileName, []byte("from-file\n"), 0600)).To(Succeed())
+			Expect(config.ReadPassphrase(fileName)).To(Equal("from-file"))
+		})
+
+		It("reads the passphrase from the environment when no file is given", func() {
+			Expect(config.ReadPassphrase("")).To(Equal("from-environment"))
+		})
+
+		It("fails when no passphrase is provided", func() {
+			os.Unsetenv(config.PassphraseEnvironmentVariable)
+			_, err := config.ReadPassphrase("")
+			Expect(err).To(MatchError("No passphrase provided: use --passphrase-file or set SCS_BACKUP_PASSPHRASE"))
+		})
+	})
+})
@@ -2,17 +2,107 @@ package config
 
 import (
 	"code.cloudfoundry.org/cli/plugin"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/cfutil"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/format"
 	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/httpclient"
 	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/serviceutil"
+	"net/http"
+	"net/url"
 	"regexp"
+	"sort"
+	"strings"
 )
 
+// The application name whose configuration is shared by all applications.
+const sharedApplicationName = "application"
+
 type CredHubSecret interface {
-	Add(configServerInstanceName string, credHubPath string, credHubSecret string) error
-	Remove(configServerInstanceName string, credHubPath string) error
+	Add(configServerInstanceName string, credHubPath CredHubPath, credHubSecret string) error
+	Remove(configServerInstanceName string, credHubPath CredHubPath) error
+	// List returns the properties served from CredHub for {appName}[/{profile}[/{label}]].
+	List(configServerInstanceName string, environmentPath string) ([]Property, error)
+	// Get returns the properties served from CredHub for the secret at {appName}/{profile}/{label}/{propertyName}. The
+	// config server serves the keys of every secret stored for {appName}/{profile}/{label} from a single property
+	// source, so those are all returned.
+	Get(configServerInstanceName string, credHubPath string) ([]Property, error)
+	// Rotate replaces the secret at the given path with one holding just the given key and value. The key, which
+	// defaults to the property name of the path, must already be served for the path's application, profile and label.
+	// Other keys are not written back since the config server serves the secrets of an application, profile and label
+	// together, so the keys of this secret cannot be told apart from those of its siblings.
+	Rotate(configServerInstanceName string, credHubPath CredHubPath, key string, value string) error
+	// Import adds each of the given properties as a secret at {appName}/{profile}/{label}/{propertyName} and returns the
+	// outcome for each property, sorted by property name. No secrets are added if dryRun is set.
+	Import(configServerInstanceName string, application string, profile string, label string, secrets map[string]interface{}, dryRun bool) ([]SecretImport, error)
+	// Export returns the secrets stored for exactly {appName}/{profile}/{label}, excluding secrets the config server
+	// serves for the same environment from other CredHub paths such as those of the "application" application.
+	Export(configServerInstanceName string, application string, profile string, label string) (*CredHubBackup, error)
+}
+
+const (
+	SecretCreated = "created"
+	SecretUpdated = "updated"
+	SecretFailed  = "failed"
+)
+
+// SecretImport is the outcome of importing one property as a CredHub secret.
+type SecretImport struct {
+	Key          string
+	Path         string
+	Status       string
+	ErrorMessage string
+}
+
+// CredHubPath identifies a secret by the application, profile and label it is served for and its property name.
+type CredHubPath struct {
+	Application string
+	Profile     string
+	Label       string
+	Property    string
+}
+
+// ParseCredHubPath parses a path of the form {appName}/{profile}/{label}/{propertyName}. A "/" in a label may be given
+// as "(_)".
+func ParseCredHubPath(credHubPath string) (CredHubPath, error) {
+	err := validateCredHubPath(credHubPath)
+	if err != nil {
+		return CredHubPath{}, err
+	}
+	segments := strings.Split(credHubPath, "/")
+	return CredHubPath{
+		Application: segments[0],
+		Profile:     segments[1],
+		Label:       strings.Replace(segments[2], "(_)", "/", -1),
+		Property:    segments[3],
+	}, nil
+}
+
+func (p CredHubPath) String() string {
+	return fmt.Sprintf("%s/%s/%s/%s", p.Application, p.Profile, p.Label, p.Property)
+}
+
+func (p CredHubPath) validate() error {
+	missing := []string{}
+	for _, field := range []struct{ name, value string }{
+		{"application", p.Application},
+		{"profile", p.Profile},
+		{"label", p.Label},
+		{"property", p.Property},
+	} {
+		if field.value == "" {
+			missing = append(missing, field.name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("CredHub path %s is missing the %s", p, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func (p CredHubPath) escaped() string {
+	return fmt.Sprintf("%s/%s/%s/%s", url.PathEscape(p.Application), url.PathEscape(p.Profile), escapeLabel(p.Label), url.PathEscape(p.Property))
 }
 
 type credHubSecret struct {
@@ -29,13 +119,18 @@ func NewCredHubSecret(connection plugin.CliConnection, client httpclient.Authent
 	}
 }
 
-func (r *credHubSecret) Add(configServerInstanceName string, credHubPath string, credHubSecret string) error {
-	accessToken, err := cfutil.GetToken(r.cliConnection)
+func (r *credHubSecret) Add(configServerInstanceName string, credHubPath CredHubPath, credHubSecret string) error {
+	err := credHubPath.validate()
 	if err != nil {
 		return err
 	}
 
-	err = validateCredHubPath(credHubPath)
+	err = validateCredHubSecret(credHubSecret)
+	if err != nil {
+		return err
+	}
+
+	accessToken, err := cfutil.GetToken(r.cliConnection)
 	if err != nil {
 		return err
 	}
@@ -45,50 +140,362 @@ func (r *credHubSecret) Add(configServerInstanceName string, credHubPath string,
 		return fmt.Errorf("error obtaining config server URL: %s", err)
 	}
 
-	path := fmt.Sprintf("%ssecrets/%s", serviceInstanceUrl, credHubPath)
+	path := fmt.Sprintf("%ssecrets/%s", serviceInstanceUrl, credHubPath.escaped())
 	status, e := r.authenticatedClient.DoAuthenticatedPut(path, "application/json", credHubSecret, accessToken)
 
-	if status != 200 {
-		return errors.New("failed to add secret or is not supported by this config server")
+	return secretRequestError("add", status, e)
+}
+
+func (r *credHubSecret) Remove(configServerInstanceName string, credHubPath CredHubPath) error {
+	err := credHubPath.validate()
+	if err != nil {
+		return err
 	}
 
-	if e != nil {
-		return e
+	accessToken, err := cfutil.GetToken(r.cliConnection)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	serviceInstanceUrl, err := r.serviceInstanceUrlResolver.GetServiceInstanceUrl(configServerInstanceName, accessToken)
+	if err != nil {
+		return fmt.Errorf("error obtaining config server URL: %s", err)
+	}
+
+	path := fmt.Sprintf("%ssecrets/%s", serviceInstanceUrl, credHubPath.escaped())
+	status, e := r.authenticatedClient.DoAuthenticatedDelete(path, accessToken)
+
+	return secretRequestError("remove", status, e)
 }
 
-func (r *credHubSecret) Remove(configServerInstanceName string, credHubPath string) error {
-	accessToken, err := cfutil.GetToken(r.cliConnection)
+func (r *credHubSecret) List(configServerInstanceName string, environmentPath string) ([]Property, error) {
+	coordinates := EnvironmentCoordinates{ConfigServerInstanceName: configServerInstanceName, Application: sharedApplicationName}
+	segments := strings.SplitN(environmentPath, "/", 3)
+	if segments[0] != "" {
+		coordinates.Application = segments[0]
+	}
+	if len(segments) > 1 {
+		coordinates.Profile = segments[1]
+	}
+	if len(segments) > 2 {
+		coordinates.Label = segments[2]
+	}
+
+	return r.credHubProperties(coordinates)
+}
+
+func (r *credHubSecret) Get(configServerInstanceName string, credHubPath string) ([]Property, error) {
+	path, err := ParseCredHubPath(credHubPath)
+	if err != nil {
+		return nil, err
+	}
+
+	propertySource, err := r.secretSource(configServerInstanceName, path)
+	if err != nil {
+		return nil, err
+	}
+
+	properties := []Property{}
+	for key, value := range propertySource.Source {
+		properties = append(properties, Property{Key: key, Value: formatValue(value), Source: propertySource.Name})
+	}
+	sort.Slice(properties, func(i, j int) bool {
+		return properties[i].Key < properties[j].Key
+	})
+	return properties, nil
+}
+
+func (r *credHubSecret) Rotate(configServerInstanceName string, credHubPath CredHubPath, key string, value string) error {
+	err := credHubPath.validate()
 	if err != nil {
 		return err
 	}
 
-	err = validateCredHubPath(credHubPath)
+	propertySource, err := r.secretSource(configServerInstanceName, credHubPath)
 	if err != nil {
 		return err
 	}
 
-	serviceInstanceUrl, err := r.serviceInstanceUrlResolver.GetServiceInstanceUrl(configServerInstanceName, accessToken)
+	if key == "" {
+		key = credHubPath.Property
+	}
+	if _, ok := propertySource.Source[key]; !ok {
+		keys := []string{}
+		for k := range propertySource.Source {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return fmt.Errorf("the secret at %s has no key %s: use one of %s", credHubPath, key, strings.Join(keys, ", "))
+	}
+
+	body, err := json.Marshal(map[string]string{key: value})
 	if err != nil {
-		return fmt.Errorf("error obtaining config server URL: %s", err)
+		return err
 	}
+	return r.Add(configServerInstanceName, credHubPath, string(body))
+}
 
-	path := fmt.Sprintf("%ssecrets/%s", serviceInstanceUrl, credHubPath)
-	status, e := r.authenticatedClient.DoAuthenticatedDelete(path, accessToken)
+// secretSource returns the property source the config server serves the secret at the given path from.
+func (r *credHubSecret) secretSource(configServerInstanceName string, credHubPath CredHubPath) (PropertySource, error) {
+	propertySource, err := r.credHubSource(EnvironmentCoordinates{
+		ConfigServerInstanceName: configServerInstanceName,
+		Application:              credHubPath.Application,
+		Profile:                  credHubPath.Profile,
+		Label:                    credHubPath.Label,
+	})
+	if err != nil {
+		return PropertySource{}, err
+	}
+	if propertySource == nil {
+		return PropertySource{}, fmt.Errorf("no secret found at %s", credHubPath)
+	}
+	return *propertySource, nil
+}
 
-	if status != 200 {
-		return errors.New("failed to remove secret or is not supported by this config server")
+// credHubSource returns the CredHub source of exactly the application, profile and label of the given coordinates, or
+// nil if the config server serves no secrets from it. The CredHub sources of other paths served for the same
+// environment, such as those of the "application" application, are ignored.
+func (r *credHubSecret) credHubSource(coordinates EnvironmentCoordinates) (*PropertySource, error) {
+	environment, err := FetchEnvironment(NewEnvironmentFetcher(r.cliConnection, r.authenticatedClient, r.serviceInstanceUrlResolver), coordinates)
+	if err != nil {
+		return nil, err
 	}
 
-	if e != nil {
-		return e
+	sourceName := credHubSourceName(coordinates.Application, coordinates.Profile, coordinates.Label)
+	for i, propertySource := range environment.PropertySources {
+		if propertySource.Name == sourceName && len(propertySource.Source) > 0 {
+			return &environment.PropertySources[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// credHubSourceName is the name of the property source the config server serves the secrets stored for an
+// application, profile and label from.
+func credHubSourceName(application string, profile string, label string) string {
+	return fmt.Sprintf("credhub-%s-%s-%s", application, profile, label)
+}
+
+func (r *credHubSecret) Import(configServerInstanceName string, application string, profile string, label string, secrets map[string]interface{}, dryRun bool) ([]SecretImport, error) {
+	existing, err := r.credHubProperties(EnvironmentCoordinates{
+		ConfigServerInstanceName: configServerInstanceName,
+		Application:              application,
+		Profile:                  profile,
+		Label:                    label,
+	})
+	if err != nil {
+		return nil, err
+	}
+	existingKeys := map[string]bool{}
+	for _, property := range existing {
+		existingKeys[property.Key] = true
+	}
+
+	keys := []string{}
+	for key := range secrets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	imports := []SecretImport{}
+	for _, key := range keys {
+		path := CredHubPath{Application: application, Profile: profile, Label: label, Property: key}
+		secretImport := SecretImport{
+			Key:    key,
+			Path:   path.String(),
+			Status: SecretCreated,
+		}
+		if existingKeys[key] {
+			secretImport.Status = SecretUpdated
+		}
+
+		err := path.validate()
+		if err == nil && !dryRun {
+			var secret []byte
+			secret, err = json.Marshal(map[string]interface{}{key: secrets[key]})
+			if err == nil {
+				err = r.Add(configServerInstanceName, path, string(secret))
+			}
+		}
+		if err != nil {
+			secretImport.Status = SecretFailed
+			secretImport.ErrorMessage = err.Error()
+		}
+		imports = append(imports, secretImport)
 	}
 
+	return imports, checkSecretImports(imports)
+}
+
+func (r *credHubSecret) Export(configServerInstanceName string, application string, profile string, label string) (*CredHubBackup, error) {
+	coordinates := EnvironmentCoordinates{
+		ConfigServerInstanceName: configServerInstanceName,
+		Application:              application,
+		Profile:                  profile,
+		Label:                    label,
+	}
+	propertySource, err := r.credHubSource(coordinates)
+	if err != nil {
+		return nil, err
+	}
+	if propertySource == nil {
+		return nil, fmt.Errorf("no CredHub secrets found for %s", coordinates)
+	}
+
+	backup := &CredHubBackup{Application: application, Profile: profile, Label: label, Secrets: map[string]interface{}{}}
+	for key, value := range propertySource.Source {
+		backup.Secrets[key] = value
+	}
+	return backup, nil
+}
+
+func checkSecretImports(imports []SecretImport) error {
+	failed := []SecretImport{}
+	for _, secretImport := range imports {
+		if secretImport.Status == SecretFailed {
+			failed = append(failed, secretImport)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to import %d of %d secrets\n\n%s", len(failed), len(imports), RenderSecretImports(imports, false))
+	}
 	return nil
 }
 
+// RenderSecretImports summarises the outcome of an import. Secret values are never shown.
+func RenderSecretImports(imports []SecretImport, dryRun bool) string {
+	counts := map[string]int{}
+	tab := &format.Table{}
+	tab.Entitle([]string{"property", "path", "status", "error"})
+	for _, secretImport := range imports {
+		counts[secretImport.Status]++
+		tab.AddRow([]string{secretImport.Key, secretImport.Path, secretImport.Status, secretImport.ErrorMessage})
+	}
+
+	summary := fmt.Sprintf("%d created, %d updated, %d failed", counts[SecretCreated], counts[SecretUpdated], counts[SecretFailed])
+	if dryRun {
+		summary = fmt.Sprintf("Dry run, no secrets were imported: %d would be created, %d would be updated, %d would fail", counts[SecretCreated], counts[SecretUpdated], counts[SecretFailed])
+	}
+	if len(imports) == 0 {
+		return summary
+	}
+	return summary + "\n\n" + tab.String()
+}
+
+func (r *credHubSecret) credHubProperties(coordinates EnvironmentCoordinates) ([]Property, error) {
+	environment, err := FetchEnvironment(NewEnvironmentFetcher(r.cliConnection, r.authenticatedClient, r.serviceInstanceUrlResolver), coordinates)
+	if err != nil {
+		return nil, err
+	}
+
+	properties := []Property{}
+	for _, propertySource := range environment.PropertySources {
+		if SourceType(propertySource.Name) != CredHubSource {
+			continue
+		}
+		for key, value := range propertySource.Source {
+			properties = append(properties, Property{Key: key, Value: formatValue(value), Source: propertySource.Name})
+		}
+	}
+	sort.Slice(properties, func(i, j int) bool {
+		if properties[i].Source != properties[j].Source {
+			return properties[i].Source < properties[j].Source
+		}
+		return properties[i].Key < properties[j].Key
+	})
+	return properties, nil
+}
+
+func RenderCredHubSecrets(properties []Property, reveal bool) string {
+	if len(properties) == 0 {
+		return "No CredHub secrets found"
+	}
+
+	tab := &format.Table{}
+	tab.Entitle([]string{"property", "value", "source"})
+	for _, property := range properties {
+		value := property.Value
+		if !reveal {
+			value = MaskedValue
+		}
+		tab.AddRow([]string{property.Key, value, property.Source})
+	}
+	return tab.String()
+}
+
+// secretRequestError explains why a request to the secrets endpoint failed. A transport error, which has no status, is
+// returned as is.
+func secretRequestError(action string, status int, e error) error {
+	if status == http.StatusOK && e == nil {
+		return nil
+	}
+	if status == 0 && e != nil {
+		return e
+	}
+
+	detail := fmt.Sprintf("status %d", status)
+	if e != nil {
+		detail = e.Error()
+	}
+	message := fmt.Sprintf("failed to %s secret: %s", action, detail)
+
+	var hint string
+	switch {
+	case status == http.StatusUnauthorized:
+		hint = "your session may have expired, run 'cf login' and try again"
+	case status == http.StatusForbidden:
+		hint = "check that you are a space developer in the space of the config server and that the config server supports CredHub secrets"
+	case status == http.StatusNotFound:
+		hint = "check the config server instance name; config servers older than Spring Cloud Services 3.1 do not support CredHub secrets"
+	case status == http.StatusConflict:
+		hint = "the secret conflicts with an existing secret at this path, for example one of a different type; remove it and try again"
+	case status >= http.StatusInternalServerError:
+		hint = "the config server or CredHub could not process the request; try again later or check the config server's logs"
+	}
+	if hint != "" {
+		message = fmt.Sprintf("%s\nHint: %s", message, hint)
+	}
+	return errors.New(message)
+}
+
+// validateCredHubSecret checks that a secret is a JSON object, as the secrets endpoint requires.
+func validateCredHubSecret(credHubSecret string) error {
+	var secret map[string]interface{}
+	err := json.Unmarshal([]byte(credHubSecret), &secret)
+	if err == nil {
+		if secret == nil {
+			return errors.New("Invalid JSON secret: expected a JSON object such as {\"key\": \"value\"}")
+		}
+		return nil
+	}
+
+	var offset int64
+	switch jsonErr := err.(type) {
+	case *json.SyntaxError:
+		offset = jsonErr.Offset
+	case *json.UnmarshalTypeError:
+		return errors.New("Invalid JSON secret: expected a JSON object such as {\"key\": \"value\"}")
+	default:
+		return fmt.Errorf("Invalid JSON secret: %s", err)
+	}
+	line, column := lineAndColumn(credHubSecret, offset)
+	return fmt.Errorf("Invalid JSON secret at line %d, column %d: %s", line, column, err)
+}
+
+func lineAndColumn(text string, offset int64) (int, int) {
+	if offset > int64(len(text)) {
+		offset = int64(len(text))
+	}
+	// The offset is just after the offending character.
+	preceding := text[:offset]
+	line := strings.Count(preceding, "\n") + 1
+	column := len(preceding) - strings.LastIndex(preceding, "\n") - 1
+	if column < 1 {
+		column = 1
+	}
+	return line, column
+}
+
 func validateCredHubPath(credHubPath string) error {
 	matched, _ := regexp.MatchString(`^[\w-.:()[\]]+/[\w-.:()[\]]+/[\w-.:()[\]]+/[\w-.:()[\]]+$`, credHubPath)
 	if !matched {
@@ -2,12 +2,17 @@ package config_test
 
 import (
 	"code.cloudfoundry.org/cli/plugin/pluginfakes"
+	"encoding/json"
 	"errors"
+	"github.com/fatih/color"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/config"
 	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/httpclient/httpclientfakes"
 	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/serviceutil/serviceutilfakes"
+	"io/ioutil"
+	"net/http"
+	"strings"
 )
 
 var _ = Describe("CredhubSecret", func() {
@@ -44,7 +49,7 @@ var _ = Describe("CredhubSecret", func() {
 	Describe("CredHub Add Secret", func() {
 
 		JustBeforeEach(func() {
-			secretsError = credhubSecret.Add(configServerName, "application(1)/cloud:profile/master.branch/[one-two]", "{\"key\":\"secret\"}")
+			secretsError = credhubSecret.Add(configServerName, config.CredHubPath{Application: "application(1)", Profile: "cloud:profile", Label: "feature/x", Property: "[one two]"}, "{\"key\":\"secret\"}")
 		})
 
 		BeforeEach(func() {
@@ -56,7 +61,7 @@ var _ = Describe("CredhubSecret", func() {
 			Expect(fakeAuthClient.DoAuthenticatedPutCallCount()).Should(Equal(1))
 
 			url, bodyType, body, token := fakeAuthClient.DoAuthenticatedPutArgsForCall(0)
-			Expect(url).To(Equal(secretsURI + "/application(1)/cloud:profile/master.branch/[one-two]"))
+			Expect(url).To(Equal(secretsURI + "/application%281%29/cloud:profile/feature(_)x/%5Bone%20two%5D"))
 			Expect(token).To(Equal(accessToken))
 			Expect(bodyType).To(Equal("application/json"))
 			Expect(body).To(Equal("{\"key\":\"secret\"}"))
@@ -64,76 +69,115 @@ var _ = Describe("CredhubSecret", func() {
 
 		Context("when add fails", func() {
 
-			var e = errors.New("failed to add secret or is not supported by this config server")
+			var e = errors.New("Authenticated put of 'service-uri/secrets/application%281%29/cloud:profile/feature(_)x/%5Bone%20two%5D' failed: 500 Internal Server Error: CredHub unavailable")
 
 			BeforeEach(func() {
 				fakeAuthClient.DoAuthenticatedPutReturns(500, e)
-				secretsError = credhubSecret.Add("fake-service-name", "application/cloud/master/one", "{\"key\":\"secret\"}")
 			})
 
-			It("should return error message", func() {
-				Expect(secretsError).To(Equal(e))
+			It("should return the response and a hint", func() {
+				Expect(secretsError).To(MatchError("failed to add secret: " + e.Error() + "\nHint: the config server or CredHub could not process the request; try again later or check the config server's logs"))
 			})
 		})
 
 		Context("when add calls an old version of SCS", func() {
 
-			var e = errors.New("failed to add secret or is not supported by this config server")
-
 			BeforeEach(func() {
-				fakeAuthClient.DoAuthenticatedPutReturns(403, e)
-				secretsError = credhubSecret.Add("old-service-name", "application/cloud/master/one", "{\"key\":\"secret\"}")
+				fakeAuthClient.DoAuthenticatedPutReturns(404, nil)
 			})
 
 			It("should return error message", func() {
-				Expect(secretsError).To(Equal(e))
+				Expect(secretsError).To(MatchError("failed to add secret: status 404\nHint: check the config server instance name; config servers older than Spring Cloud Services 3.1 do not support CredHub secrets"))
 			})
 		})
 
-		Context("when add calls with invalid path with less than three elements", func() {
+		DescribeTable("when add is rejected",
+			func(status int, hint string) {
+				fakeAuthClient.DoAuthenticatedPutReturns(status, errors.New("rejected"))
+				secretsError = credhubSecret.Add(configServerName, config.CredHubPath{Application: "application", Profile: "cloud", Label: "master", Property: "one"}, "{\"key\":\"secret\"}")
+				Expect(secretsError).To(MatchError("failed to add secret: rejected\nHint: " + hint))
+			},
+			Entry("unauthorized", http.StatusUnauthorized, "your session may have expired, run 'cf login' and try again"),
+			Entry("forbidden", http.StatusForbidden, "check that you are a space developer in the space of the config server and that the config server supports CredHub secrets"),
+			Entry("conflict", http.StatusConflict, "the secret conflicts with an existing secret at this path, for example one of a different type; remove it and try again"),
+		)
 
-			var e = errors.New("CredHub path should just include the required fields: {appName}/{profile}/{label}/{propertyName}")
+		Context("when the request cannot be sent", func() {
 
-			JustBeforeEach(func() {
-				secretsError = credhubSecret.Add("old-service-name", "application/default/one", "{\"key\":\"secret\"}")
-			})
+			var e = errors.New("Authenticated put of 'service-uri/secrets/one' failed: connection refused")
 
 			BeforeEach(func() {
-				fakeAuthClient.DoAuthenticatedPutReturns(200, nil)
+				fakeAuthClient.DoAuthenticatedPutReturns(0, e)
 			})
 
-			It("should return error message", func() {
+			It("should return the transport error", func() {
 				Expect(secretsError).To(Equal(e))
 			})
 		})
 
-		Context("when add calls with invalid path without elements", func() {
+		Context("when the secret is not valid JSON", func() {
 
-			var e = errors.New("CredHub path should just include the required fields: {appName}/{profile}/{label}/{propertyName}")
+			JustBeforeEach(func() {
+				secretsError = credhubSecret.Add(configServerName, config.CredHubPath{Application: "application", Profile: "cloud", Label: "master", Property: "one"}, "{\n  \"key\": \"secret\",\n  \"other\" 1\n}")
+			})
+
+			It("should report where the JSON is invalid", func() {
+				Expect(secretsError).To(MatchError("Invalid JSON secret at line 3, column 11: invalid character '1' after object key"))
+			})
+		})
+
+		Context("when the path is incomplete", func() {
 
 			JustBeforeEach(func() {
-				secretsError = credhubSecret.Add("old-service-name", "///", "{\"key\":\"secret\"}")
+				secretsError = credhubSecret.Add(configServerName, config.CredHubPath{Application: "app", Profile: "cloud", Property: "one"}, "{\"key\":\"secret\"}")
 			})
 
-			BeforeEach(func() {
-				fakeAuthClient.DoAuthenticatedPutReturns(200, nil)
+			It("should return error message", func() {
+				Expect(secretsError).To(MatchError("CredHub path app/cloud//one is missing the label"))
+			})
+		})
+
+		Context("when the secret is not a JSON object", func() {
+
+			JustBeforeEach(func() {
+				secretsError = credhubSecret.Add(configServerName, config.CredHubPath{Application: "application", Profile: "cloud", Label: "master", Property: "one"}, "\"secret\"")
 			})
 
 			It("should return error message", func() {
-				Expect(secretsError).To(Equal(e))
+				Expect(secretsError).To(MatchError("Invalid JSON secret: expected a JSON object such as {\"key\": \"value\"}"))
 			})
 		})
 
-		Context("when add calls with invalid path with more than three elements", func() {
+	})
+
+	Describe("ParseCredHubPath", func() {
+
+		It("parses the fields of the path", func() {
+			path, err := config.ParseCredHubPath("app/cloud/feature(_)x/db.password")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(path).To(Equal(config.CredHubPath{Application: "app", Profile: "cloud", Label: "feature/x", Property: "db.password"}))
+			Expect(path.String()).To(Equal("app/cloud/feature/x/db.password"))
+		})
+
+		Context("when the path is invalid with less than three elements", func() {
 
 			var e = errors.New("CredHub path should just include the required fields: {appName}/{profile}/{label}/{propertyName}")
 
 			JustBeforeEach(func() {
-				secretsError = credhubSecret.Add("old-service-name", "a-a/a.a/ab/aaa/foooas/aaa", "{\"key\":\"secret\"}")
+				_, secretsError = config.ParseCredHubPath("application/default/one")
 			})
 
-			BeforeEach(func() {
-				fakeAuthClient.DoAuthenticatedPutReturns(200, nil)
+			It("should return error message", func() {
+				Expect(secretsError).To(Equal(e))
+			})
+		})
+
+		Context("when the path is invalid without elements", func() {
+
+			var e = errors.New("CredHub path should just include the required fields: {appName}/{profile}/{label}/{propertyName}")
+
+			JustBeforeEach(func() {
+				_, secretsError = config.ParseCredHubPath("///")
 			})
 
 			It("should return error message", func() {
@@ -141,16 +185,25 @@ var _ = Describe("CredhubSecret", func() {
 			})
 		})
 
-		Context("when add calls with invalid path with non valid characters", func() {
+		Context("when the path is invalid with more than three elements", func() {
 
 			var e = errors.New("CredHub path should just include the required fields: {appName}/{profile}/{label}/{propertyName}")
 
 			JustBeforeEach(func() {
-				secretsError = credhubSecret.Add("old-service-name", "a#a/a.a/ab/", "{\"key\":\"secret\"}")
+				_, secretsError = config.ParseCredHubPath("a-a/a.a/ab/aaa/foooas/aaa")
 			})
 
-			BeforeEach(func() {
-				fakeAuthClient.DoAuthenticatedPutReturns(200, nil)
+			It("should return error message", func() {
+				Expect(secretsError).To(Equal(e))
+			})
+		})
+
+		Context("when the path is invalid with non valid characters", func() {
+
+			var e = errors.New("CredHub path should just include the required fields: {appName}/{profile}/{label}/{propertyName}")
+
+			JustBeforeEach(func() {
+				_, secretsError = config.ParseCredHubPath("a#a/a.a/ab/")
 			})
 
 			It("should return error message", func() {
@@ -162,7 +215,7 @@ var _ = Describe("CredhubSecret", func() {
 	Describe("CredHub Remove Secret", func() {
 
 		JustBeforeEach(func() {
-			secretsError = credhubSecret.Remove(configServerName, "application/cloud/master/one")
+			secretsError = credhubSecret.Remove(configServerName, config.CredHubPath{Application: "application", Profile: "cloud", Label: "master", Property: "one"})
 		})
 
 		BeforeEach(func() {
@@ -180,48 +233,388 @@ var _ = Describe("CredhubSecret", func() {
 
 		Context("when remove fails", func() {
 
-			var e = errors.New("failed to remove secret or is not supported by this config server")
+			var e = errors.New("Authenticated delete of 'service-uri/secrets/application/cloud/master/one' failed: 500 Internal Server Error")
 
 			BeforeEach(func() {
 				fakeAuthClient.DoAuthenticatedDeleteReturns(500, e)
-				secretsError = credhubSecret.Remove("fake-service-name", "application/cloud/master/one")
 			})
 
-			It("should return error message", func() {
-				Expect(secretsError).To(Equal(e))
+			It("should return the response and a hint", func() {
+				Expect(secretsError).To(MatchError("failed to remove secret: " + e.Error() + "\nHint: the config server or CredHub could not process the request; try again later or check the config server's logs"))
 			})
 		})
 
 		Context("when remove calls an old version of SCS", func() {
 
-			var e = errors.New("failed to remove secret or is not supported by this config server")
-
 			BeforeEach(func() {
-				fakeAuthClient.DoAuthenticatedDeleteReturns(403, e)
-				secretsError = credhubSecret.Remove("old-service-name", "application/cloud/master/one")
+				fakeAuthClient.DoAuthenticatedDeleteReturns(404, nil)
 			})
 
 			It("should return error message", func() {
-				Expect(secretsError).To(Equal(e))
+				Expect(secretsError).To(MatchError("failed to remove secret: status 404\nHint: check the config server instance name; config servers older than Spring Cloud Services 3.1 do not support CredHub secrets"))
 			})
 		})
 
-		Context("when remove calls with invalid path", func() {
+		Context("when the path is incomplete", func() {
 
-			var e = errors.New("CredHub path should just include the required fields: {appName}/{profile}/{label}/{propertyName}")
+			JustBeforeEach(func() {
+				secretsError = credhubSecret.Remove(configServerName, config.CredHubPath{Label: "feature/x"})
+			})
+
+			It("should return error message", func() {
+				Expect(secretsError).To(MatchError("CredHub path //feature/x/ is missing the application, profile, property"))
+			})
+		})
+	})
+
+	Describe("CredHub List and Get Secrets", func() {
+
+		const environmentBody = `{
+			"name": "app",
+			"profiles": ["cloud"],
+			"label": "master",
+			"propertySources": [
+				{"name": "credhub-app-cloud-master", "source": {"db.password": "s3cret", "db.username": "admin", "api.token": "t0ken"}},
+				{"name": "https://github.com/org/repo.git/app.yml", "source": {"db.url": "jdbc:x"}}
+			]
+		}`
+
+		var properties []config.Property
+
+		BeforeEach(func() {
+			fakeAuthClient.DoAuthenticatedGetReturns(ioutil.NopCloser(strings.NewReader(environmentBody)), http.StatusOK, nil)
+		})
+
+		Describe("List", func() {
+			var environmentPath string
+
+			BeforeEach(func() {
+				environmentPath = "app/cloud/feature/x"
+			})
 
 			JustBeforeEach(func() {
-				secretsError = credhubSecret.Remove("old-service-name", "application/default/one")
+				properties, secretsError = credhubSecret.List(configServerName, environmentPath)
+			})
+
+			It("fetches the environment, allowing a slash in the label", func() {
+				Expect(secretsError).NotTo(HaveOccurred())
+				url, token := fakeAuthClient.DoAuthenticatedGetArgsForCall(0)
+				Expect(url).To(Equal(serviceURI + "app/cloud/feature(_)x"))
+				Expect(token).To(Equal(accessToken))
 			})
 
+			It("returns only the properties served from CredHub", func() {
+				Expect(properties).To(Equal([]config.Property{
+					{Key: "api.token", Value: "t0ken", Source: "credhub-app-cloud-master"},
+					{Key: "db.password", Value: "s3cret", Source: "credhub-app-cloud-master"},
+					{Key: "db.username", Value: "admin", Source: "credhub-app-cloud-master"},
+				}))
+			})
+
+			Context("when no application is given", func() {
+				BeforeEach(func() {
+					environmentPath = ""
+				})
+
+				It("lists the secrets shared by all applications", func() {
+					url, _ := fakeAuthClient.DoAuthenticatedGetArgsForCall(0)
+					Expect(url).To(Equal(serviceURI + "application/default"))
+				})
+			})
+		})
+
+		Describe("Get", func() {
+			var credHubPath string
+
+			BeforeEach(func() {
+				credHubPath = "app/cloud/master/db"
+			})
+
+			JustBeforeEach(func() {
+				properties, secretsError = credhubSecret.Get(configServerName, credHubPath)
+			})
+
+			It("returns the keys served from the CredHub source of the application, profile and label", func() {
+				Expect(secretsError).NotTo(HaveOccurred())
+				url, _ := fakeAuthClient.DoAuthenticatedGetArgsForCall(0)
+				Expect(url).To(Equal(serviceURI + "app/cloud/master"))
+				Expect(properties).To(Equal([]config.Property{
+					{Key: "api.token", Value: "t0ken", Source: "credhub-app-cloud-master"},
+					{Key: "db.password", Value: "s3cret", Source: "credhub-app-cloud-master"},
+					{Key: "db.username", Value: "admin", Source: "credhub-app-cloud-master"},
+				}))
+			})
+
+			Context("when the secret does not exist", func() {
+				BeforeEach(func() {
+					credHubPath = "app/cloud/release/db"
+				})
+
+				It("should return error message", func() {
+					Expect(secretsError).To(MatchError("no secret found at app/cloud/release/db"))
+				})
+			})
+
+			Context("when the path is invalid", func() {
+				BeforeEach(func() {
+					credHubPath = "app/cloud"
+				})
+
+				It("should return error message without calling the config server", func() {
+					Expect(secretsError).To(MatchError("CredHub path should just include the required fields: {appName}/{profile}/{label}/{propertyName}"))
+					Expect(fakeAuthClient.DoAuthenticatedGetCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		Describe("RenderCredHubSecrets", func() {
 			BeforeEach(func() {
-				fakeAuthClient.DoAuthenticatedDeleteReturns(200, nil)
+				color.NoColor = true
+				properties = []config.Property{{Key: "db.password", Value: "s3cret", Source: "credhub-app-cloud-master"}}
+			})
+
+			It("masks values", func() {
+				Expect(config.RenderCredHubSecrets(properties, false)).To(ContainSubstring("db.password ******"))
+			})
+
+			It("reveals values on request", func() {
+				Expect(config.RenderCredHubSecrets(properties, true)).To(ContainSubstring("db.password s3cret"))
+			})
+
+			It("reports when there are no secrets", func() {
+				Expect(config.RenderCredHubSecrets(nil, false)).To(Equal("No CredHub secrets found"))
+			})
+		})
+	})
+
+	Describe("CredHub Import Secrets", func() {
+
+		const environmentBody = `{
+			"name": "app",
+			"profiles": ["cloud"],
+			"label": "master",
+			"propertySources": [
+				{"name": "credhub-app-cloud-master", "source": {"db.password": "old"}}
+			]
+		}`
+
+		var (
+			secrets map[string]interface{}
+			dryRun  bool
+			imports []config.SecretImport
+		)
+
+		BeforeEach(func() {
+			secrets = map[string]interface{}{"db.password": "s3cret", "api.token": "t0ken", "db.port": 5432}
+			dryRun = false
+			fakeAuthClient.DoAuthenticatedGetReturns(ioutil.NopCloser(strings.NewReader(environmentBody)), http.StatusOK, nil)
+			fakeAuthClient.DoAuthenticatedPutReturns(http.StatusOK, nil)
+		})
+
+		JustBeforeEach(func() {
+			imports, secretsError = credhubSecret.Import(configServerName, "app", "cloud", "master", secrets, dryRun)
+		})
+
+		It("adds each property as a secret", func() {
+			Expect(secretsError).NotTo(HaveOccurred())
+			Expect(fakeAuthClient.DoAuthenticatedPutCallCount()).To(Equal(3))
+
+			url, bodyType, body, token := fakeAuthClient.DoAuthenticatedPutArgsForCall(0)
+			Expect(url).To(Equal(secretsURI + "/app/cloud/master/api.token"))
+			Expect(bodyType).To(Equal("application/json"))
+			Expect(body).To(Equal(`{"api.token":"t0ken"}`))
+			Expect(token).To(Equal(accessToken))
+
+			_, _, body, _ = fakeAuthClient.DoAuthenticatedPutArgsForCall(2)
+			Expect(body).To(Equal(`{"db.port":5432}`))
+		})
+
+		It("reports which secrets were created and updated", func() {
+			Expect(imports).To(Equal([]config.SecretImport{
+				{Key: "api.token", Path: "app/cloud/master/api.token", Status: config.SecretCreated},
+				{Key: "db.password", Path: "app/cloud/master/db.password", Status: config.SecretUpdated},
+				{Key: "db.port", Path: "app/cloud/master/db.port", Status: config.SecretCreated},
+			}))
+		})
+
+		Context("when it is a dry run", func() {
+			BeforeEach(func() {
+				dryRun = true
+			})
+
+			It("does not add any secrets", func() {
+				Expect(secretsError).NotTo(HaveOccurred())
+				Expect(imports).To(HaveLen(3))
+				Expect(fakeAuthClient.DoAuthenticatedPutCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when some secrets cannot be added", func() {
+			BeforeEach(func() {
+				secrets[""] = "value"
+				fakeAuthClient.DoAuthenticatedPutReturnsOnCall(1, http.StatusForbidden, nil)
+			})
+
+			It("adds the remaining secrets and reports the failures", func() {
+				Expect(fakeAuthClient.DoAuthenticatedPutCallCount()).To(Equal(3))
+				Expect(imports[0].Key).To(Equal(""))
+				Expect(imports[0].Status).To(Equal(config.SecretFailed))
+				Expect(imports[0].ErrorMessage).To(Equal("CredHub path app/cloud/master/ is missing the property"))
+				Expect(imports[2].Key).To(Equal("db.password"))
+				Expect(imports[2].Status).To(Equal(config.SecretFailed))
+				Expect(imports[2].ErrorMessage).To(HavePrefix("failed to add secret: status 403\nHint: "))
+				Expect(secretsError).To(MatchError(HavePrefix("failed to import 2 of 4 secrets")))
+			})
+		})
+
+		Context("when the existing secrets cannot be fetched", func() {
+			BeforeEach(func() {
+				fakeAuthClient.DoAuthenticatedGetReturns(nil, http.StatusInternalServerError, errors.New("boom"))
+			})
+
+			It("does not add any secrets", func() {
+				Expect(secretsError).To(HaveOccurred())
+				Expect(fakeAuthClient.DoAuthenticatedPutCallCount()).To(Equal(0))
+			})
+		})
+
+		Describe("RenderSecretImports", func() {
+			It("summarises the import", func() {
+				Expect(config.RenderSecretImports(imports, false)).To(HavePrefix("2 created, 1 updated, 0 failed\n\n"))
+			})
+
+			It("summarises a dry run", func() {
+				Expect(config.RenderSecretImports(imports, true)).To(HavePrefix("Dry run, no secrets were imported: 2 would be created, 1 would be updated, 0 would fail"))
+			})
+		})
+	})
+
+	Describe("CredHub Export Secrets", func() {
+
+		const environmentBody = `{
+			"name": "app",
+			"profiles": ["cloud"],
+			"label": "master",
+			"propertySources": [
+				{"name": "credhub-app-cloud-master", "source": {"db.password": "s3cret", "db.port": 5432}},
+				{"name": "credhub-app-default-master", "source": {"default.token": "t0ken"}},
+				{"name": "credhub-application-cloud-master", "source": {"shared.token": "t0ken"}}
+			]
+		}`
+
+		var backup *config.CredHubBackup
+
+		BeforeEach(func() {
+			fakeAuthClient.DoAuthenticatedGetReturns(ioutil.NopCloser(strings.NewReader(environmentBody)), http.StatusOK, nil)
+		})
+
+		JustBeforeEach(func() {
+			backup, secretsError = credhubSecret.Export(configServerName, "app", "cloud", "master")
+		})
+
+		It("returns only the secrets of the CredHub source of the application, profile and label", func() {
+			Expect(secretsError).NotTo(HaveOccurred())
+			Expect(backup).To(Equal(&config.CredHubBackup{
+				Application: "app",
+				Profile:     "cloud",
+				Label:       "master",
+				Secrets:     map[string]interface{}{"db.password": "s3cret", "db.port": json.Number("5432")},
+			}))
+		})
+
+		Context("when there are no secrets", func() {
+			BeforeEach(func() {
+				fakeAuthClient.DoAuthenticatedGetReturns(ioutil.NopCloser(strings.NewReader(`{"propertySources": []}`)), http.StatusOK, nil)
 			})
 
 			It("should return error message", func() {
-				Expect(secretsError).To(Equal(e))
+				Expect(secretsError).To(MatchError("no CredHub secrets found for app/cloud/master on fake-config-server-name"))
 			})
 		})
 
+		Context("when the environment cannot be fetched", func() {
+			BeforeEach(func() {
+				fakeAuthClient.DoAuthenticatedGetReturns(nil, http.StatusNotFound, errors.New("not found"))
+			})
+
+			It("should return the error", func() {
+				Expect(secretsError).To(MatchError(ContainSubstring("not found")))
+				Expect(secretsError).NotTo(MatchError(HavePrefix("no CredHub secrets found")))
+			})
+		})
+	})
+
+	Describe("CredHub Rotate Secret", func() {
+
+		const environmentBody = `{
+			"name": "app",
+			"profiles": ["cloud"],
+			"label": "feature/x",
+			"propertySources": [
+				{"name": "credhub-app-cloud-feature/x", "source": {"password": "old", "username": "admin", "port": 5432, "pool": {"size": 5, "validate": true}}},
+				{"name": "credhub-application-cloud-feature/x", "source": {"token": "t0ken"}}
+			]
+		}`
+
+		var (
+			credHubPath config.CredHubPath
+			key         string
+		)
+
+		BeforeEach(func() {
+			credHubPath = config.CredHubPath{Application: "app", Profile: "cloud", Label: "feature/x", Property: "db"}
+			key = "password"
+			fakeAuthClient.DoAuthenticatedGetReturns(ioutil.NopCloser(strings.NewReader(environmentBody)), http.StatusOK, nil)
+			fakeAuthClient.DoAuthenticatedPutReturns(http.StatusOK, nil)
+		})
+
+		JustBeforeEach(func() {
+			secretsError = credhubSecret.Rotate(configServerName, credHubPath, key, "new")
+		})
+
+		It("writes only the rotated key, leaving the secrets served alongside it alone", func() {
+			Expect(secretsError).NotTo(HaveOccurred())
+			url, _ := fakeAuthClient.DoAuthenticatedGetArgsForCall(0)
+			Expect(url).To(Equal(serviceURI + "app/cloud/feature(_)x"))
+
+			Expect(fakeAuthClient.DoAuthenticatedPutCallCount()).To(Equal(1))
+			url, _, body, _ := fakeAuthClient.DoAuthenticatedPutArgsForCall(0)
+			Expect(url).To(Equal(secretsURI + "/app/cloud/feature(_)x/db"))
+			Expect(body).To(MatchJSON(`{"password": "new"}`))
+		})
+
+		Context("when the key is not part of the secret", func() {
+			BeforeEach(func() {
+				key = "url"
+			})
+
+			It("should return error message", func() {
+				Expect(secretsError).To(MatchError("the secret at app/cloud/feature/x/db has no key url: use one of password, pool, port, username"))
+				Expect(fakeAuthClient.DoAuthenticatedPutCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when no key is given", func() {
+			BeforeEach(func() {
+				credHubPath.Property = "password"
+				key = ""
+			})
+
+			It("replaces the value of the property of the path", func() {
+				_, _, body, _ := fakeAuthClient.DoAuthenticatedPutArgsForCall(0)
+				Expect(body).To(MatchJSON(`{"password": "new"}`))
+			})
+		})
+
+		Context("when the secret does not exist", func() {
+			BeforeEach(func() {
+				credHubPath.Label = "main"
+			})
+
+			It("should return error message", func() {
+				Expect(secretsError).To(MatchError("no secret found at app/cloud/main/db"))
+				Expect(fakeAuthClient.DoAuthenticatedPutCallCount()).To(Equal(0))
+			})
+		})
 	})
 })
@@ -0,0 +1,127 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package config
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/format"
+)
+
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// PropertyDifference describes how the effective value of a key differs between two environments. From is nil for an
+// added key and To is nil for a removed key.
+type PropertyDifference struct {
+	Key    string
+	Change string
+	From   *Property
+	To     *Property
+}
+
+// EnvironmentCoordinates identify an environment served by a config server.
+type EnvironmentCoordinates struct {
+	ConfigServerInstanceName string
+	Application              string
+	Profile                  string
+	Label                    string
+}
+
+func (c EnvironmentCoordinates) String() string {
+	return fmt.Sprintf("%s/%s/%s on %s", c.Application, profileOrDefault(c.Profile), labelOrDefault(c.Label), c.ConfigServerInstanceName)
+}
+
+func FetchEnvironment(fetcher EnvironmentFetcher, coordinates EnvironmentCoordinates) (*Environment, error) {
+	environment, err := fetcher.Fetch(coordinates.ConfigServerInstanceName, coordinates.Application, coordinates.Profile, coordinates.Label)
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching %s: %s", coordinates, err)
+	}
+	return environment, nil
+}
+
+// DiffEnvironments compares the effective properties of two environments and returns the differences sorted by key.
+func DiffEnvironments(from *Environment, to *Environment) []PropertyDifference {
+	fromProperties := propertiesByKey(from.EffectiveProperties())
+	toProperties := propertiesByKey(to.EffectiveProperties())
+
+	differences := []PropertyDifference{}
+	for key, fromProperty := range fromProperties {
+		toProperty, ok := toProperties[key]
+		if !ok {
+			differences = append(differences, PropertyDifference{Key: key, Change: Removed, From: fromProperty})
+		} else if fromProperty.Value != toProperty.Value {
+			differences = append(differences, PropertyDifference{Key: key, Change: Changed, From: fromProperty, To: toProperty})
+		}
+	}
+	for key, toProperty := range toProperties {
+		if _, ok := fromProperties[key]; !ok {
+			differences = append(differences, PropertyDifference{Key: key, Change: Added, To: toProperty})
+		}
+	}
+
+	sort.Slice(differences, func(i, j int) bool {
+		return differences[i].Key < differences[j].Key
+	})
+	return differences
+}
+
+func RenderEnvironmentDiff(from EnvironmentCoordinates, to EnvironmentCoordinates, differences []PropertyDifference, reveal bool) string {
+	header := fmt.Sprintf("from: %s\nto:   %s\n\n", from, to)
+	if len(differences) == 0 {
+		return header + "No differences found\n"
+	}
+
+	return header + RenderPropertyDifferences(differences, reveal)
+}
+
+// RenderPropertyDifferences renders differences as a table of keys with their old and new values, masking secrets unless
+// reveal is set.
+func RenderPropertyDifferences(differences []PropertyDifference, reveal bool) string {
+	tab := &format.Table{}
+	tab.Entitle([]string{"property", "change", "from", "to"})
+	for _, difference := range differences {
+		tab.AddRow([]string{difference.Key, difference.Change, displayValueOf(difference.From, reveal), displayValueOf(difference.To, reveal)})
+	}
+	return tab.String()
+}
+
+func displayValueOf(property *Property, reveal bool) string {
+	if property == nil {
+		return ""
+	}
+	return DisplayValue(*property, reveal)
+}
+
+func labelOrDefault(label string) string {
+	if label == "" {
+		return "(default label)"
+	}
+	return label
+}
+
+func propertiesByKey(properties []Property) map[string]*Property {
+	byKey := make(map[string]*Property, len(properties))
+	for i := range properties {
+		byKey[properties[i].Key] = &properties[i]
+	}
+	return byKey
+}
@@ -0,0 +1,132 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package config_test
+
+import (
+	"github.com/fatih/color"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/config"
+)
+
+var _ = Describe("Diff", func() {
+
+	var (
+		from        *config.Environment
+		to          *config.Environment
+		differences []config.PropertyDifference
+	)
+
+	BeforeEach(func() {
+		from = &config.Environment{
+			PropertySources: []config.PropertySource{
+				{Name: "https://github.com/org/repo.git/app-staging.yml", Source: map[string]interface{}{
+					"server.port":    "8080",
+					"feature.flag":   "true",
+					"db.password":    "staging-secret",
+					"staging.only":   "x",
+					"unchanged.flag": "same",
+				}},
+			},
+		}
+		to = &config.Environment{
+			PropertySources: []config.PropertySource{
+				{Name: "https://github.com/org/repo.git/app-prod.yml", Source: map[string]interface{}{
+					"server.port":    "8081",
+					"db.password":    "prod-secret",
+					"prod.only":      "y",
+					"unchanged.flag": "same",
+				}},
+				{Name: "https://github.com/org/repo.git/application.yml", Source: map[string]interface{}{
+					"feature.flag": "true",
+				}},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		differences = config.DiffEnvironments(from, to)
+	})
+
+	It("reports added, removed and changed keys in key order", func() {
+		Expect(differences).To(HaveLen(4))
+		Expect(differences[0].Key).To(Equal("db.password"))
+		Expect(differences[0].Change).To(Equal(config.Changed))
+		Expect(differences[1].Key).To(Equal("prod.only"))
+		Expect(differences[1].Change).To(Equal(config.Added))
+		Expect(differences[1].From).To(BeNil())
+		Expect(differences[2].Key).To(Equal("server.port"))
+		Expect(differences[2].Change).To(Equal(config.Changed))
+		Expect(differences[2].From.Value).To(Equal("8080"))
+		Expect(differences[2].To.Value).To(Equal("8081"))
+		Expect(differences[3].Key).To(Equal("staging.only"))
+		Expect(differences[3].Change).To(Equal(config.Removed))
+		Expect(differences[3].To).To(BeNil())
+	})
+
+	Describe("RenderEnvironmentDiff", func() {
+		var (
+			fromCoordinates config.EnvironmentCoordinates
+			toCoordinates   config.EnvironmentCoordinates
+			reveal          bool
+			output          string
+		)
+
+		BeforeEach(func() {
+			color.NoColor = true
+			reveal = false
+			fromCoordinates = config.EnvironmentCoordinates{ConfigServerInstanceName: "config-server", Application: "app", Profile: "staging"}
+			toCoordinates = config.EnvironmentCoordinates{ConfigServerInstanceName: "config-server", Application: "app", Profile: "prod", Label: "main"}
+		})
+
+		JustBeforeEach(func() {
+			output = trimLines(config.RenderEnvironmentDiff(fromCoordinates, toCoordinates, differences, reveal))
+		})
+
+		It("masks credential-like values", func() {
+			Expect(output).To(Equal(`from: app/staging/(default label) on config-server
+to:   app/prod/main on config-server
+
+property     change  from   to
+db.password  changed ****** ******
+prod.only    added          y
+server.port  changed 8080   8081
+staging.only removed x
+`))
+		})
+
+		Context("when values are revealed", func() {
+			BeforeEach(func() {
+				reveal = true
+			})
+
+			It("shows the secret values", func() {
+				Expect(output).To(ContainSubstring("staging-secret prod-secret"))
+			})
+		})
+
+		Context("when there are no differences", func() {
+			BeforeEach(func() {
+				to = from
+			})
+
+			It("says so", func() {
+				Expect(output).To(HaveSuffix("No differences found\n"))
+			})
+		})
+	})
+})
@@ -70,7 +70,7 @@ func (e *encrypter) EncryptString(configServerInstanceName string, textToEncrypt
 		if len(body) > 0 {
 			errorDetails = fmt.Sprintf(": %s", string(body))
 		}
-		return "", fmt.Errorf("Encryption failed or is not supported by this config server%s", errorDetails)
+		return "", fmt.Errorf("Encryption failed or is not supported by this config server%s\nHint: run 'cf config-server-key-info %s' to check whether it has an encryption key", errorDetails, configServerInstanceName)
 	}
 
 	return string(body), nil
@@ -112,7 +112,7 @@ var _ = Describe("Encrypter", func() {
 			})
 
 			It("reports that encryption failed or is not supported", func() {
-				Expect(err).To(MatchError("Encryption failed or is not supported by this config server"))
+				Expect(err).To(MatchError("Encryption failed or is not supported by this config server\nHint: run 'cf config-server-key-info " + serviceRegistryInstance + "' to check whether it has an encryption key"))
 			})
 		})
 
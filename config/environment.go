@@ -0,0 +1,240 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"code.cloudfoundry.org/cli/plugin"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/cfutil"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/format"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/httpclient"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/serviceutil"
+)
+
+const DefaultProfile = "default"
+
+// Environment is the response of the config server's /{application}/{profile}/{label} endpoint.
+// Property sources are listed in precedence order, highest precedence first.
+type Environment struct {
+	Name            string
+	Profiles        []string
+	Label           string
+	Version         string
+	State           string
+	PropertySources []PropertySource `json:"propertySources"`
+}
+
+type PropertySource struct {
+	Name   string
+	Source map[string]interface{}
+}
+
+// Property is the effective value of a key together with the name of the property source which supplied it.
+type Property struct {
+	Key    string
+	Value  string
+	Source string
+}
+
+type EnvironmentFetcher interface {
+	Fetch(configServerInstanceName string, application string, profile string, label string) (*Environment, error)
+	FetchFormatted(configServerInstanceName string, application string, profile string, label string, outputFormat string) (string, error)
+}
+
+type environmentFetcher struct {
+	cliConnection              plugin.CliConnection
+	authenticatedClient        httpclient.AuthenticatedClient
+	serviceInstanceUrlResolver serviceutil.ServiceInstanceResolver
+}
+
+func NewEnvironmentFetcher(cliConnection plugin.CliConnection, authenticatedClient httpclient.AuthenticatedClient, serviceInstanceUrlResolver serviceutil.ServiceInstanceResolver) EnvironmentFetcher {
+	return &environmentFetcher{
+		cliConnection:              cliConnection,
+		authenticatedClient:        authenticatedClient,
+		serviceInstanceUrlResolver: serviceInstanceUrlResolver,
+	}
+}
+
+func (e *environmentFetcher) Fetch(configServerInstanceName string, application string, profile string, label string) (*Environment, error) {
+	path := fmt.Sprintf("%s/%s", url.PathEscape(application), url.PathEscape(profileOrDefault(profile)))
+	if label != "" {
+		path = fmt.Sprintf("%s/%s", path, escapeLabel(label))
+	}
+
+	body, err := e.get(configServerInstanceName, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var environment Environment
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	err = decoder.Decode(&environment)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid config server environment response JSON: %s, response body: '%s'", err, string(body))
+	}
+
+	return &environment, nil
+}
+
+func (e *environmentFetcher) FetchFormatted(configServerInstanceName string, application string, profile string, label string, outputFormat string) (string, error) {
+	extension, err := formatExtension(outputFormat)
+	if err != nil {
+		return "", err
+	}
+
+	path := fmt.Sprintf("%s-%s.%s", url.PathEscape(application), url.PathEscape(profileOrDefault(profile)), extension)
+	if label != "" {
+		path = fmt.Sprintf("%s/%s", escapeLabel(label), path)
+	}
+
+	body, err := e.get(configServerInstanceName, path)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(body), "\n"), nil
+}
+
+func (e *environmentFetcher) get(configServerInstanceName string, path string) ([]byte, error) {
+	accessToken, err := cfutil.GetToken(e.cliConnection)
+	if err != nil {
+		return nil, err
+	}
+
+	configServerUrl, err := e.serviceInstanceUrlResolver.GetServiceInstanceUrl(configServerInstanceName, accessToken)
+	if err != nil {
+		return nil, fmt.Errorf("Error obtaining config server URL: %s", err)
+	}
+
+	bodyReader, statusCode, err := e.authenticatedClient.DoAuthenticatedGet(configServerUrl+path, accessToken)
+	if statusCode == http.StatusNotFound {
+		return nil, errors.New("Configuration not found. Check the application name, profile and label, and that the config server version supports this command")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if bodyReader == nil {
+		return nil, errors.New("Config server environment response body missing")
+	}
+	defer bodyReader.Close()
+
+	body, err := ioutil.ReadAll(bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read config server environment response body: %s", err)
+	}
+
+	return body, nil
+}
+
+// EffectiveProperties returns the winning value of each key, sorted by key. A key defined in several
+// property sources takes its value from the first, that is highest precedence, source.
+func (env *Environment) EffectiveProperties() []Property {
+	seen := make(map[string]struct{})
+	properties := []Property{}
+	for _, propertySource := range env.PropertySources {
+		for key, value := range propertySource.Source {
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			properties = append(properties, Property{
+				Key:    key,
+				Value:  formatValue(value),
+				Source: propertySource.Name,
+			})
+		}
+	}
+
+	sort.Slice(properties, func(i, j int) bool {
+		return properties[i].Key < properties[j].Key
+	})
+	return properties
+}
+
+func RenderEnvironment(env *Environment) string {
+	var buffer bytes.Buffer
+	buffer.WriteString(fmt.Sprintf(`application: %s
+profiles:    %s
+label:       %s
+version:     %s
+`,
+		env.Name,
+		strings.Join(env.Profiles, ","),
+		env.Label,
+		env.Version))
+
+	if len(env.PropertySources) == 0 {
+		buffer.WriteString("\nNo property sources found\n")
+		return buffer.String()
+	}
+
+	buffer.WriteString("\nproperty sources (highest precedence first):\n")
+	for i, propertySource := range env.PropertySources {
+		buffer.WriteString(fmt.Sprintf("%d. %s\n", i+1, propertySource.Name))
+	}
+
+	tab := &format.Table{}
+	tab.Entitle([]string{"property", "value", "source"})
+	for _, property := range env.EffectiveProperties() {
+		tab.AddRow([]string{property.Key, property.Value, property.Source})
+	}
+	buffer.WriteString("\n")
+	buffer.WriteString(tab.String())
+
+	return buffer.String()
+}
+
+func formatValue(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+func formatExtension(outputFormat string) (string, error) {
+	switch outputFormat {
+	case "properties":
+		return "properties", nil
+	case "yaml", "yml":
+		return "yml", nil
+	case "json":
+		return "json", nil
+	}
+	return "", fmt.Errorf("Unsupported format '%s': use one of properties, yaml or json", outputFormat)
+}
+
+func profileOrDefault(profile string) string {
+	if profile == "" {
+		return DefaultProfile
+	}
+	return profile
+}
+
+// The config server expects a "/" in a label to be passed as "(_)".
+func escapeLabel(label string) string {
+	return strings.Replace(url.PathEscape(label), "%2F", "(_)", -1)
+}
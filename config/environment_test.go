@@ -0,0 +1,233 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package config_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"code.cloudfoundry.org/cli/plugin/pluginfakes"
+	"github.com/fatih/color"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/config"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/httpclient/httpclientfakes"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/serviceutil/serviceutilfakes"
+)
+
+var _ = Describe("EnvironmentFetcher", func() {
+
+	const (
+		accessToken       = "fake-access-token"
+		bearerAccessToken = "bearer fake-access-token"
+		serviceURI        = "service-uri/"
+		configServerName  = "fake-config-server-name"
+		environmentBody   = `{
+			"name": "app",
+			"profiles": ["dev"],
+			"label": "main",
+			"version": "abc123",
+			"propertySources": [
+				{"name": "credhub-app-dev-main", "source": {"db.password": "s3cret"}},
+				{"name": "https://github.com/org/repo.git/app-dev.yml", "source": {"db.password": "overridden", "server.port": 8081}},
+				{"name": "https://github.com/org/repo.git/application.yml", "source": {"server.port": 8080, "logging.level.root": "INFO"}}
+			]
+		}`
+	)
+
+	var (
+		fakeCliConnection *pluginfakes.FakeCliConnection
+		fakeAuthClient    *httpclientfakes.FakeAuthenticatedClient
+		fakeResolver      *serviceutilfakes.FakeServiceInstanceResolver
+		fetcher           config.EnvironmentFetcher
+		label             string
+		err               error
+	)
+
+	BeforeEach(func() {
+		fakeCliConnection = &pluginfakes.FakeCliConnection{}
+		fakeAuthClient = &httpclientfakes.FakeAuthenticatedClient{}
+		fakeResolver = &serviceutilfakes.FakeServiceInstanceResolver{}
+		label = ""
+
+		fakeCliConnection.AccessTokenReturns(bearerAccessToken, nil)
+		fakeResolver.GetServiceInstanceUrlReturns(serviceURI, nil)
+		fakeAuthClient.DoAuthenticatedGetReturns(ioutil.NopCloser(strings.NewReader(environmentBody)), http.StatusOK, nil)
+	})
+
+	JustBeforeEach(func() {
+		fetcher = config.NewEnvironmentFetcher(fakeCliConnection, fakeAuthClient, fakeResolver)
+	})
+
+	Describe("Fetch", func() {
+		var environment *config.Environment
+
+		JustBeforeEach(func() {
+			environment, err = fetcher.Fetch(configServerName, "app", "dev", label)
+		})
+
+		It("calls the environment endpoint without a label", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeAuthClient.DoAuthenticatedGetCallCount()).To(Equal(1))
+			url, token := fakeAuthClient.DoAuthenticatedGetArgsForCall(0)
+			Expect(url).To(Equal("service-uri/app/dev"))
+			Expect(token).To(Equal(accessToken))
+		})
+
+		It("parses the property sources in precedence order", func() {
+			Expect(environment.Name).To(Equal("app"))
+			Expect(environment.Version).To(Equal("abc123"))
+			Expect(environment.PropertySources).To(HaveLen(3))
+			Expect(environment.PropertySources[0].Name).To(Equal("credhub-app-dev-main"))
+		})
+
+		It("resolves the winning value of each key", func() {
+			Expect(environment.EffectiveProperties()).To(Equal([]config.Property{
+				{Key: "db.password", Value: "s3cret", Source: "credhub-app-dev-main"},
+				{Key: "logging.level.root", Value: "INFO", Source: "https://github.com/org/repo.git/application.yml"},
+				{Key: "server.port", Value: "8081", Source: "https://github.com/org/repo.git/app-dev.yml"},
+			}))
+		})
+
+		Context("when a label containing a slash is given", func() {
+			BeforeEach(func() {
+				label = "feature/x"
+			})
+
+			It("passes the slash using the config server's (_) escape", func() {
+				url, _ := fakeAuthClient.DoAuthenticatedGetArgsForCall(0)
+				Expect(url).To(Equal("service-uri/app/dev/feature(_)x"))
+			})
+		})
+
+		Context("when the config server URL cannot be resolved", func() {
+			BeforeEach(func() {
+				fakeResolver.GetServiceInstanceUrlReturns("", errors.New("not found"))
+			})
+
+			It("should return a suitable error", func() {
+				Expect(err).To(MatchError("Error obtaining config server URL: not found"))
+			})
+		})
+
+		Context("when the environment is not found", func() {
+			BeforeEach(func() {
+				fakeAuthClient.DoAuthenticatedGetReturns(nil, http.StatusNotFound, errors.New("Authenticated get failed: 404"))
+			})
+
+			It("should return a suitable error", func() {
+				Expect(err.Error()).To(HavePrefix("Configuration not found."))
+			})
+		})
+
+		Context("when the response is not valid JSON", func() {
+			BeforeEach(func() {
+				fakeAuthClient.DoAuthenticatedGetReturns(ioutil.NopCloser(strings.NewReader("{")), http.StatusOK, nil)
+			})
+
+			It("should return a suitable error", func() {
+				Expect(err).To(MatchError("Invalid config server environment response JSON: unexpected EOF, response body: '{'"))
+			})
+		})
+	})
+
+	Describe("FetchFormatted", func() {
+		var (
+			outputFormat string
+			output       string
+		)
+
+		BeforeEach(func() {
+			outputFormat = "yaml"
+			fakeAuthClient.DoAuthenticatedGetReturns(ioutil.NopCloser(strings.NewReader("server:\n  port: 8081\n")), http.StatusOK, nil)
+		})
+
+		JustBeforeEach(func() {
+			output, err = fetcher.FetchFormatted(configServerName, "app", "", label, outputFormat)
+		})
+
+		It("calls the flattened YAML endpoint for the default profile", func() {
+			Expect(err).NotTo(HaveOccurred())
+			url, _ := fakeAuthClient.DoAuthenticatedGetArgsForCall(0)
+			Expect(url).To(Equal("service-uri/app-default.yml"))
+			Expect(output).To(Equal("server:\n  port: 8081"))
+		})
+
+		Context("when a label is given", func() {
+			BeforeEach(func() {
+				label = "main"
+				outputFormat = "properties"
+			})
+
+			It("prefixes the path with the label", func() {
+				url, _ := fakeAuthClient.DoAuthenticatedGetArgsForCall(0)
+				Expect(url).To(Equal("service-uri/main/app-default.properties"))
+			})
+		})
+
+		Context("when the format is not supported", func() {
+			BeforeEach(func() {
+				outputFormat = "xml"
+			})
+
+			It("fails without calling the config server", func() {
+				Expect(err).To(MatchError("Unsupported format 'xml': use one of properties, yaml or json"))
+				Expect(fakeAuthClient.DoAuthenticatedGetCallCount()).To(Equal(0))
+			})
+		})
+	})
+
+	Describe("RenderEnvironment", func() {
+		var environment *config.Environment
+
+		BeforeEach(func() {
+			color.NoColor = true
+		})
+
+		JustBeforeEach(func() {
+			environment, err = fetcher.Fetch(configServerName, "app", "dev", label)
+		})
+
+		It("lists the property sources and the winning values", func() {
+			Expect(trimLines(config.RenderEnvironment(environment))).To(Equal(`application: app
+profiles:    dev
+label:       main
+version:     abc123
+
+property sources (highest precedence first):
+1. credhub-app-dev-main
+2. https://github.com/org/repo.git/app-dev.yml
+3. https://github.com/org/repo.git/application.yml
+
+property           value  source
+db.password        s3cret credhub-app-dev-main
+logging.level.root INFO   https://github.com/org/repo.git/application.yml
+server.port        8081   https://github.com/org/repo.git/app-dev.yml
+`))
+		})
+	})
+})
+
+func trimLines(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
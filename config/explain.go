@@ -0,0 +1,103 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/format"
+)
+
+// The config server replaces a property it cannot decrypt with a property of this prefix.
+const invalidPropertyPrefix = "invalid."
+
+// PropertyOrigin is a definition of a key in one property source.
+type PropertyOrigin struct {
+	Property
+	Precedence       int
+	Winner           bool
+	DecryptionFailed bool
+}
+
+// Origins returns each definition of the given key in precedence order, highest precedence first. A property source
+// which only failed to decrypt the key is included, but its value cannot win.
+func (env *Environment) Origins(key string) []PropertyOrigin {
+	origins := []PropertyOrigin{}
+	winnerFound := false
+	for i, propertySource := range env.PropertySources {
+		value, defined := propertySource.Source[key]
+		_, decryptionFailed := propertySource.Source[invalidPropertyPrefix+key]
+		if !defined && !decryptionFailed {
+			continue
+		}
+		origins = append(origins, PropertyOrigin{
+			Property: Property{
+				Key:    key,
+				Value:  formatValue(value),
+				Source: propertySource.Name,
+			},
+			Precedence:       i + 1,
+			Winner:           defined && !winnerFound,
+			DecryptionFailed: decryptionFailed,
+		})
+		winnerFound = winnerFound || defined
+	}
+	return origins
+}
+
+func RenderPropertyOrigins(env *Environment, key string, reveal bool) (string, error) {
+	origins := env.Origins(key)
+	if len(origins) == 0 {
+		return "", fmt.Errorf("Property %s is not defined in any of the %d property sources of %s/%s/%s", key, len(env.PropertySources), env.Name, strings.Join(env.Profiles, ","), env.Label)
+	}
+
+	tab := &format.Table{}
+	tab.Entitle([]string{"precedence", "source", "type", "value", "encryption", "status"})
+	for _, origin := range origins {
+		status := "overridden"
+		if origin.Winner {
+			status = "wins"
+		} else if origin.DecryptionFailed {
+			status = "failed"
+		}
+		tab.AddRow([]string{
+			strconv.Itoa(origin.Precedence),
+			origin.Source,
+			SourceType(origin.Source),
+			DisplayValue(origin.Property, reveal),
+			encryptionStatus(origin),
+			status,
+		})
+	}
+
+	return fmt.Sprintf("property:    %s\napplication: %s\nprofiles:    %s\nlabel:       %s\n\n%s",
+		key, env.Name, strings.Join(env.Profiles, ","), env.Label, tab.String()), nil
+}
+
+// The config server decrypts values before serving them, so only values it left encrypted or failed to decrypt can be
+// detected.
+func encryptionStatus(origin PropertyOrigin) string {
+	if origin.DecryptionFailed {
+		return "decryption failed"
+	}
+	if strings.HasPrefix(origin.Value, cipherPrefix) {
+		return "not decrypted"
+	}
+	return ""
+}
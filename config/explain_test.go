@@ -0,0 +1,115 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package config_test
+
+import (
+	"github.com/fatih/color"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/config"
+)
+
+var _ = Describe("Explain", func() {
+
+	var environment *config.Environment
+
+	BeforeEach(func() {
+		color.NoColor = true
+		environment = &config.Environment{
+			Name:     "app",
+			Profiles: []string{"dev"},
+			Label:    "main",
+			PropertySources: []config.PropertySource{
+				{Name: "credhub-app-dev-main", Source: map[string]interface{}{"db.password": "from-credhub"}},
+				{Name: "vault:app/dev", Source: map[string]interface{}{"invalid.db.password": "<n/a>"}},
+				{Name: "https://github.com/org/repo.git/app-dev.yml", Source: map[string]interface{}{"db.password": "{cipher}abc", "server.port": "8081"}},
+				{Name: "https://github.com/org/repo.git/application.yml", Source: map[string]interface{}{"server.port": "8080"}},
+			},
+		}
+	})
+
+	Describe("Origins", func() {
+		It("lists every definition of a key in precedence order", func() {
+			origins := environment.Origins("server.port")
+			Expect(origins).To(HaveLen(2))
+			Expect(origins[0].Precedence).To(Equal(3))
+			Expect(origins[0].Value).To(Equal("8081"))
+			Expect(origins[0].Winner).To(BeTrue())
+			Expect(origins[1].Precedence).To(Equal(4))
+			Expect(origins[1].Winner).To(BeFalse())
+		})
+
+		It("includes property sources which failed to decrypt the key", func() {
+			origins := environment.Origins("db.password")
+			Expect(origins).To(HaveLen(3))
+			Expect(origins[1].Source).To(Equal("vault:app/dev"))
+			Expect(origins[1].DecryptionFailed).To(BeTrue())
+		})
+
+		It("does not let a property source which failed to decrypt the key win", func() {
+			environment.PropertySources = environment.PropertySources[1:]
+			origins := environment.Origins("db.password")
+			Expect(origins).To(HaveLen(2))
+			Expect(origins[0].Source).To(Equal("vault:app/dev"))
+			Expect(origins[0].DecryptionFailed).To(BeTrue())
+			Expect(origins[0].Winner).To(BeFalse())
+			Expect(origins[1].Value).To(Equal("{cipher}abc"))
+			Expect(origins[1].Winner).To(BeTrue())
+		})
+
+		It("returns nothing for an undefined key", func() {
+			Expect(environment.Origins("no.such.key")).To(BeEmpty())
+		})
+	})
+
+	Describe("RenderPropertyOrigins", func() {
+		It("shows the winner, source types and encryption status with secrets masked", func() {
+			output, err := config.RenderPropertyOrigins(environment, "db.password", false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(trimLines(output)).To(Equal(`property:    db.password
+application: app
+profiles:    dev
+label:       main
+
+precedence source                                      type    value  encryption        status
+1          credhub-app-dev-main                        credhub ******                   wins
+2          vault:app/dev                               vault   ****** decryption failed failed
+3          https://github.com/org/repo.git/app-dev.yml git     ****** not decrypted     overridden
+`))
+		})
+
+		It("shows a property source which failed to decrypt the key as failed", func() {
+			environment.PropertySources = environment.PropertySources[1:]
+			output, err := config.RenderPropertyOrigins(environment, "db.password", false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(trimLines(output)).To(HaveSuffix(`precedence source                                      type  value  encryption        status
+1          vault:app/dev                               vault ****** decryption failed failed
+2          https://github.com/org/repo.git/app-dev.yml git   ****** not decrypted     wins
+`))
+		})
+
+		It("reveals values on request", func() {
+			output, _ := config.RenderPropertyOrigins(environment, "db.password", true)
+			Expect(output).To(ContainSubstring("from-credhub"))
+		})
+
+		It("fails for an undefined key", func() {
+			_, err := config.RenderPropertyOrigins(environment, "no.such.key", false)
+			Expect(err).To(MatchError("Property no.such.key is not defined in any of the 4 property sources of app/dev/main"))
+		})
+	})
+})
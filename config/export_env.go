@@ -0,0 +1,109 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+const (
+	DotEnvFormat                = "dotenv"
+	SpringApplicationJsonFormat = "json"
+	YamlFormat                  = "yaml"
+)
+
+// ExportEnvironment renders the effective properties of an environment for running the application locally: as a .env
+// file, as the value of SPRING_APPLICATION_JSON, or as a YAML file such as application-local.yml. Secrets are left
+// out if excludeSecrets is set.
+func ExportEnvironment(env *Environment, outputFormat string, excludeSecrets bool) (string, error) {
+	properties := []Property{}
+	for _, property := range env.EffectiveProperties() {
+		if excludeSecrets && IsSensitive(property) {
+			continue
+		}
+		properties = append(properties, property)
+	}
+
+	switch outputFormat {
+	case DotEnvFormat:
+		return exportDotEnv(properties), nil
+	case SpringApplicationJsonFormat:
+		return exportSpringApplicationJson(properties)
+	case YamlFormat, "yml":
+		return exportYaml(properties)
+	}
+	return "", fmt.Errorf("Unsupported format '%s': use one of dotenv, json or yaml", outputFormat)
+}
+
+// WriteEnvironmentFile writes an exported environment to a file which only the current user can read, since it may
+// contain secrets.
+func WriteEnvironmentFile(fileName string, contents string) error {
+	err := ioutil.WriteFile(fileName, []byte(contents+"\n"), 0600)
+	if err != nil {
+		return fmt.Errorf("Error writing file at path %s : %s", fileName, err)
+	}
+	return nil
+}
+
+func exportDotEnv(properties []Property) string {
+	var buffer bytes.Buffer
+	for _, property := range properties {
+		buffer.WriteString(fmt.Sprintf("%s=%s\n", EnvironmentVariableName(property.Key), quoteDotEnvValue(property.Value)))
+	}
+	return strings.TrimRight(buffer.String(), "\n")
+}
+
+// EnvironmentVariableName converts a property key to the environment variable which Spring Boot binds to it, e.g.
+// "spring.datasource.url" to "SPRING_DATASOURCE_URL" and "servers[0].host-name" to "SERVERS_0_HOSTNAME".
+func EnvironmentVariableName(key string) string {
+	name := strings.NewReplacer(".", "_", "[", "_", "]", "", "-", "").Replace(key)
+	return strings.ToUpper(name)
+}
+
+func quoteDotEnvValue(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
+
+func exportSpringApplicationJson(properties []Property) (string, error) {
+	contents, err := json.MarshalIndent(propertyMap(properties), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(contents), nil
+}
+
+func exportYaml(properties []Property) (string, error) {
+	contents, err := yaml.Marshal(propertyMap(properties))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(contents), "\n"), nil
+}
+
+func propertyMap(properties []Property) map[string]string {
+	values := make(map[string]string, len(properties))
+	for _, property := range properties {
+		values[property.Key] = property.Value
+	}
+	return values
+}
@@ -0,0 +1,160 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package config_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/config"
+)
+
+var _ = Describe("ExportEnvironment", func() {
+
+	var (
+		environment    *config.Environment
+		outputFormat   string
+		excludeSecrets bool
+		output         string
+		err            error
+	)
+
+	BeforeEach(func() {
+		environment = &config.Environment{
+			Name: "app",
+			PropertySources: []config.PropertySource{
+				{Name: "credhub-app-default-master", Source: map[string]interface{}{"api.key": "k3y"}},
+				{Name: "https://github.com/config-repo/app.yml", Source: map[string]interface{}{
+					"spring.datasource.url":      "jdbc:postgresql://localhost/db",
+					"spring.datasource.password": "s3cret",
+					"servers[0].host-name":       "one",
+					"greeting":                   "say \"hi\"\nand go",
+					"timeout":                    json.Number("30"),
+				}},
+				{Name: "https://github.com/config-repo/application.yml", Source: map[string]interface{}{"timeout": json.Number("10")}},
+			},
+		}
+		excludeSecrets = false
+	})
+
+	JustBeforeEach(func() {
+		output, err = config.ExportEnvironment(environment, outputFormat, excludeSecrets)
+	})
+
+	Context("when the format is dotenv", func() {
+		BeforeEach(func() {
+			outputFormat = "dotenv"
+		})
+
+		It("writes the effective properties as environment variables", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output).To(Equal(`API_KEY="k3y"
+GREETING="say \"hi\"\nand go"
+SERVERS_0_HOSTNAME="one"
+SPRING_DATASOURCE_PASSWORD="s3cret"
+SPRING_DATASOURCE_URL="jdbc:postgresql://localhost/db"
+TIMEOUT="30"`))
+		})
+
+		Context("when secrets are excluded", func() {
+			BeforeEach(func() {
+				excludeSecrets = true
+			})
+
+			It("leaves out the secrets", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(output).To(Equal(`GREETING="say \"hi\"\nand go"
+SERVERS_0_HOSTNAME="one"
+SPRING_DATASOURCE_URL="jdbc:postgresql://localhost/db"
+TIMEOUT="30"`))
+			})
+		})
+	})
+
+	Context("when the format is json", func() {
+		BeforeEach(func() {
+			outputFormat = "json"
+			excludeSecrets = true
+		})
+
+		It("writes the effective properties as a JSON object", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output).To(MatchJSON(`{
+				"greeting": "say \"hi\"\nand go",
+				"servers[0].host-name": "one",
+				"spring.datasource.url": "jdbc:postgresql://localhost/db",
+				"timeout": "30"
+			}`))
+		})
+	})
+
+	Context("when the format is yaml", func() {
+		BeforeEach(func() {
+			outputFormat = "yaml"
+			excludeSecrets = true
+		})
+
+		It("writes the effective properties as YAML", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output).To(MatchYAML(`
+greeting: "say \"hi\"\nand go"
+servers[0].host-name: one
+spring.datasource.url: jdbc:postgresql://localhost/db
+timeout: "30"
+`))
+		})
+	})
+
+	Context("when the format is not supported", func() {
+		BeforeEach(func() {
+			outputFormat = "xml"
+		})
+
+		It("returns a suitable error", func() {
+			Expect(err).To(MatchError("Unsupported format 'xml': use one of dotenv, json or yaml"))
+		})
+	})
+})
+
+var _ = Describe("EnvironmentVariableName", func() {
+	It("converts property keys the way Spring Boot binds environment variables", func() {
+		Expect(config.EnvironmentVariableName("spring.datasource.url")).To(Equal("SPRING_DATASOURCE_URL"))
+		Expect(config.EnvironmentVariableName("my.service[0].other-name")).To(Equal("MY_SERVICE_0_OTHERNAME"))
+	})
+})
+
+var _ = Describe("WriteEnvironmentFile", func() {
+	It("writes a file readable only by the current user", func() {
+		testDir, err := ioutil.TempDir("", "scs-cli-")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(testDir)
+
+		path := filepath.Join(testDir, ".env")
+		Expect(config.WriteEnvironmentFile(path, "A=\"b\"")).To(Succeed())
+
+		contents, err := ioutil.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(Equal("A=\"b\"\n"))
+		info, err := os.Stat(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+	})
+})
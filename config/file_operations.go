@@ -2,9 +2,13 @@ package config
 
 import (
 	"fmt"
+	"io"
 	"io/ioutil"
 )
 
+// StdinFileName is the file name which denotes standard input.
+const StdinFileName = "-"
+
 func ReadFileContents(fileToEncrypt string) (string, error) {
 	var dat, err = ioutil.ReadFile(fileToEncrypt)
 	if err != nil {
@@ -12,3 +16,16 @@ func ReadFileContents(fileToEncrypt string) (string, error) {
 	}
 	return string(dat), nil
 }
+
+// ReadSecretContents reads a secret from the given file or, if the file name is StdinFileName, from stdin, so that the
+// secret need not appear on the command line.
+func ReadSecretContents(secretFile string, stdin io.Reader) (string, error) {
+	if secretFile != StdinFileName {
+		return ReadFileContents(secretFile)
+	}
+	dat, err := ioutil.ReadAll(stdin)
+	if err != nil {
+		return "", fmt.Errorf("Error reading standard input : %s", err)
+	}
+	return string(dat), nil
+}
@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -49,6 +50,23 @@ var _ = Describe("Encrypt file", func() {
 
 })
 
+var _ = Describe("Read secret", func() {
+
+	It("should read the secret from a file", func() {
+		testFile := CreateFile(CreateTempDir(), "secret.json")
+		contents, err := ReadSecretContents(testFile, strings.NewReader("unused"))
+		Expect(contents).To(Equal("Hello\nWorld\n"))
+		Expect(err).To(BeNil())
+	})
+
+	It("should read the secret from stdin", func() {
+		contents, err := ReadSecretContents("-", strings.NewReader(`{"key":"secret"}`))
+		Expect(contents).To(Equal(`{"key":"secret"}`))
+		Expect(err).To(BeNil())
+	})
+
+})
+
 func check(err error) {
 	if err != nil {
 		panic(err)
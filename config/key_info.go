@@ -0,0 +1,233 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package config
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"code.cloudfoundry.org/cli/plugin"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/cfutil"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/httpclient"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/serviceutil"
+)
+
+const (
+	EncryptionAvailable = "OK"
+	EncryptionNoKey     = "NO_KEY"
+)
+
+const sshRsaKeyType = "ssh-rsa"
+
+// KeyInfo describes the encryption key of a config server. PublicKey is nil unless the key is an RSA key, since the
+// config server does not publish symmetric keys.
+type KeyInfo struct {
+	Status    string
+	PublicKey *rsa.PublicKey
+}
+
+type KeyInfoFetcher interface {
+	// Fetch reports the encryption status of a config server and its public key, if any. If an application and
+	// profile are given, the key used to encrypt their values is fetched instead of the default key.
+	Fetch(configServerInstanceName string, application string, profile string) (*KeyInfo, error)
+}
+
+type keyInfoFetcher struct {
+	cliConnection              plugin.CliConnection
+	authenticatedClient        httpclient.AuthenticatedClient
+	serviceInstanceUrlResolver serviceutil.ServiceInstanceResolver
+}
+
+func NewKeyInfoFetcher(cliConnection plugin.CliConnection, authenticatedClient httpclient.AuthenticatedClient, serviceInstanceUrlResolver serviceutil.ServiceInstanceResolver) KeyInfoFetcher {
+	return &keyInfoFetcher{
+		cliConnection:              cliConnection,
+		authenticatedClient:        authenticatedClient,
+		serviceInstanceUrlResolver: serviceInstanceUrlResolver,
+	}
+}
+
+func (k *keyInfoFetcher) Fetch(configServerInstanceName string, application string, profile string) (*KeyInfo, error) {
+	accessToken, err := cfutil.GetToken(k.cliConnection)
+	if err != nil {
+		return nil, err
+	}
+
+	configServerUrl, err := k.serviceInstanceUrlResolver.GetServiceInstanceUrl(configServerInstanceName, accessToken)
+	if err != nil {
+		return nil, fmt.Errorf("Error obtaining config server URL: %s", err)
+	}
+
+	// The config server responds with 404 Not Found when no encryption key is installed.
+	body, statusCode, err := k.get(configServerUrl+"encrypt/status", accessToken)
+	if statusCode == http.StatusNotFound {
+		return &KeyInfo{Status: EncryptionNoKey}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var status struct {
+		Status string
+	}
+	err = json.Unmarshal(body, &status)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid encryption status response JSON: %s, response body: '%s'", err, string(body))
+	}
+
+	keyInfo := &KeyInfo{Status: status.Status}
+	if keyInfo.Status != EncryptionAvailable {
+		return keyInfo, nil
+	}
+
+	keyPath := "key"
+	if application != "" {
+		keyPath = fmt.Sprintf("key/%s/%s", url.PathEscape(application), url.PathEscape(profileOrDefault(profile)))
+	}
+	// The config server responds with 404 Not Found when its key is not an RSA key.
+	body, statusCode, err = k.get(configServerUrl+keyPath, accessToken)
+	if statusCode == http.StatusNotFound {
+		return keyInfo, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	keyInfo.PublicKey, err = ParseRsaPublicKey(string(body))
+	if err != nil {
+		return nil, err
+	}
+	return keyInfo, nil
+}
+
+func (k *keyInfoFetcher) get(url string, accessToken string) ([]byte, int, error) {
+	bodyReader, statusCode, err := k.authenticatedClient.DoAuthenticatedGet(url, accessToken)
+	if err != nil {
+		return nil, statusCode, err
+	}
+	if bodyReader == nil {
+		return nil, statusCode, errors.New("Config server response body missing")
+	}
+	defer bodyReader.Close()
+
+	body, err := ioutil.ReadAll(bodyReader)
+	if err != nil {
+		return nil, statusCode, fmt.Errorf("Cannot read config server response body: %s", err)
+	}
+	return body, statusCode, nil
+}
+
+// ParseRsaPublicKey parses an RSA public key in the OpenSSH format served by the config server's /key endpoint, e.g.
+// "ssh-rsa AAAA... application", or in PEM format.
+func ParseRsaPublicKey(text string) (*rsa.PublicKey, error) {
+	text = strings.TrimSpace(text)
+	if block, _ := pem.Decode([]byte(text)); block != nil {
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid RSA public key: %s", err)
+		}
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, errors.New("Invalid RSA public key: not an RSA key")
+		}
+		return rsaKey, nil
+	}
+
+	fields := strings.Fields(text)
+	if len(fields) < 2 || fields[0] != sshRsaKeyType {
+		return nil, errors.New("Invalid RSA public key: expected an ssh-rsa or PEM public key")
+	}
+	data, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return nil, fmt.Errorf("Invalid RSA public key: %s", err)
+	}
+
+	var parts [3][]byte
+	for i := range parts {
+		if len(data) < 4 {
+			return nil, errors.New("Invalid RSA public key: truncated key")
+		}
+		length := binary.BigEndian.Uint32(data)
+		if uint32(len(data)-4) < length {
+			return nil, errors.New("Invalid RSA public key: truncated key")
+		}
+		parts[i], data = data[4:4+length], data[4+length:]
+	}
+	if string(parts[0]) != sshRsaKeyType {
+		return nil, errors.New("Invalid RSA public key: expected an ssh-rsa or PEM public key")
+	}
+	exponent := new(big.Int).SetBytes(parts[1])
+	if !exponent.IsInt64() || exponent.Int64() > int64(^uint32(0)>>1) {
+		return nil, errors.New("Invalid RSA public key: unsupported exponent")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(parts[2]), E: int(exponent.Int64())}, nil
+}
+
+// PublicKeyFingerprint returns the SHA-256 fingerprint of the DER encoding of a public key, which identifies the key
+// a config server is using.
+func PublicKeyFingerprint(key *rsa.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:]), nil
+}
+
+func RenderKeyInfo(keyInfo *KeyInfo) (string, error) {
+	var buffer bytes.Buffer
+	switch keyInfo.Status {
+	case EncryptionAvailable:
+		buffer.WriteString("encryption:  available\n")
+	case EncryptionNoKey:
+		buffer.WriteString("encryption:  not available: no encryption key is installed\n")
+		return strings.TrimRight(buffer.String(), "\n"), nil
+	default:
+		buffer.WriteString(fmt.Sprintf("encryption:  not available: status %s\n", keyInfo.Status))
+		return strings.TrimRight(buffer.String(), "\n"), nil
+	}
+
+	if keyInfo.PublicKey == nil {
+		buffer.WriteString("key type:    symmetric (no public key)")
+		return buffer.String(), nil
+	}
+
+	fingerprint, err := PublicKeyFingerprint(keyInfo.PublicKey)
+	if err != nil {
+		return "", err
+	}
+	der, err := x509.MarshalPKIXPublicKey(keyInfo.PublicKey)
+	if err != nil {
+		return "", err
+	}
+	buffer.WriteString(fmt.Sprintf("key type:    RSA %d bits\n", keyInfo.PublicKey.N.BitLen()))
+	buffer.WriteString(fmt.Sprintf("fingerprint: %s\n", fingerprint))
+	// The config server does not expose the alias of its key, so the fingerprint is all that identifies it.
+	buffer.WriteString("key alias:   cannot be determined: the fingerprint is the only identifier of the key\n\n")
+	buffer.Write(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	return strings.TrimRight(buffer.String(), "\n"), nil
+}
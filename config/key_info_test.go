@@ -0,0 +1,225 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package config_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"code.cloudfoundry.org/cli/plugin/pluginfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/config"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/httpclient/httpclientfakes"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/serviceutil/serviceutilfakes"
+)
+
+// sshRsaPublicKey encodes a public key the way the config server's /key endpoint does.
+func sshRsaPublicKey(key *rsa.PublicKey) string {
+	var buffer bytes.Buffer
+	for _, part := range [][]byte{[]byte("ssh-rsa"), big.NewInt(int64(key.E)).Bytes(), append([]byte{0}, key.N.Bytes()...)} {
+		binary.Write(&buffer, binary.BigEndian, uint32(len(part)))
+		buffer.Write(part)
+	}
+	return "ssh-rsa " + base64.StdEncoding.EncodeToString(buffer.Bytes()) + " application"
+}
+
+var _ = Describe("KeyInfo", func() {
+
+	const (
+		accessToken = "access-token"
+		serviceURI  = "service-uri/"
+	)
+
+	var (
+		privateKey *rsa.PrivateKey
+		responses  map[string]string
+		statuses   map[string]int
+	)
+
+	BeforeEach(func() {
+		var err error
+		privateKey, err = rsa.GenerateKey(rand.Reader, 1024)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Describe("Fetch", func() {
+		var (
+			fakeCliConnection *pluginfakes.FakeCliConnection
+			fakeAuthClient    *httpclientfakes.FakeAuthenticatedClient
+			fakeResolver      *serviceutilfakes.FakeServiceInstanceResolver
+			application       string
+			keyInfo           *config.KeyInfo
+			err               error
+		)
+
+		BeforeEach(func() {
+			fakeCliConnection = &pluginfakes.FakeCliConnection{}
+			fakeAuthClient = &httpclientfakes.FakeAuthenticatedClient{}
+			fakeResolver = &serviceutilfakes.FakeServiceInstanceResolver{}
+			fakeCliConnection.AccessTokenReturns("bearer "+accessToken, nil)
+			fakeResolver.GetServiceInstanceUrlReturns(serviceURI, nil)
+			application = ""
+
+			responses = map[string]string{
+				serviceURI + "encrypt/status": `{"status":"OK"}`,
+				serviceURI + "key":            sshRsaPublicKey(&privateKey.PublicKey),
+			}
+			statuses = map[string]int{}
+			fakeAuthClient.DoAuthenticatedGetStub = func(url string, token string) (io.ReadCloser, int, error) {
+				if status, ok := statuses[url]; ok {
+					return nil, status, errors.New("request failed")
+				}
+				return ioutil.NopCloser(strings.NewReader(responses[url])), http.StatusOK, nil
+			}
+		})
+
+		JustBeforeEach(func() {
+			keyInfo, err = config.NewKeyInfoFetcher(fakeCliConnection, fakeAuthClient, fakeResolver).Fetch("config-server", application, "")
+		})
+
+		It("reports that encryption is available and returns the public key", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(keyInfo.Status).To(Equal(config.EncryptionAvailable))
+			Expect(keyInfo.PublicKey).To(Equal(&privateKey.PublicKey))
+
+			serviceInstanceName, token := fakeResolver.GetServiceInstanceUrlArgsForCall(0)
+			Expect(serviceInstanceName).To(Equal("config-server"))
+			Expect(token).To(Equal(accessToken))
+		})
+
+		Context("when an application is given", func() {
+			BeforeEach(func() {
+				application = "app"
+				responses[serviceURI+"key/app/default"] = responses[serviceURI+"key"]
+				statuses[serviceURI+"key"] = http.StatusInternalServerError
+			})
+
+			It("fetches the key of the application and default profile", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(keyInfo.PublicKey).To(Equal(&privateKey.PublicKey))
+			})
+		})
+
+		Context("when no key is installed", func() {
+			BeforeEach(func() {
+				statuses[serviceURI+"encrypt/status"] = http.StatusNotFound
+			})
+
+			It("reports that encryption is not available", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(keyInfo).To(Equal(&config.KeyInfo{Status: config.EncryptionNoKey}))
+				Expect(fakeAuthClient.DoAuthenticatedGetCallCount()).To(Equal(1))
+			})
+		})
+
+		Context("when the key is symmetric", func() {
+			BeforeEach(func() {
+				statuses[serviceURI+"key"] = http.StatusNotFound
+			})
+
+			It("reports that encryption is available without a public key", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(keyInfo).To(Equal(&config.KeyInfo{Status: config.EncryptionAvailable}))
+			})
+		})
+
+		Context("when the encryption status cannot be fetched", func() {
+			BeforeEach(func() {
+				statuses[serviceURI+"encrypt/status"] = http.StatusInternalServerError
+			})
+
+			It("returns the error", func() {
+				Expect(err).To(MatchError("request failed"))
+			})
+		})
+
+		Context("when the encryption status is invalid", func() {
+			BeforeEach(func() {
+				responses[serviceURI+"encrypt/status"] = "not json"
+			})
+
+			It("returns a suitable error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(HavePrefix("Invalid encryption status response JSON: "))
+			})
+		})
+	})
+
+	Describe("ParseRsaPublicKey", func() {
+		It("parses an OpenSSH public key", func() {
+			key, err := config.ParseRsaPublicKey(sshRsaPublicKey(&privateKey.PublicKey) + "\n")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(key).To(Equal(&privateKey.PublicKey))
+		})
+
+		It("parses a PEM public key", func() {
+			der, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+			Expect(err).NotTo(HaveOccurred())
+			key, err := config.ParseRsaPublicKey(string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(key).To(Equal(&privateKey.PublicKey))
+		})
+
+		It("rejects other keys", func() {
+			_, err := config.ParseRsaPublicKey("ssh-ed25519 AAAAC3NzaC1lZDI1NTE5 application")
+			Expect(err).To(MatchError("Invalid RSA public key: expected an ssh-rsa or PEM public key"))
+		})
+
+		It("rejects a truncated key", func() {
+			_, err := config.ParseRsaPublicKey("ssh-rsa AAAAB3NzaC1yc2EAAAAD")
+			Expect(err).To(MatchError("Invalid RSA public key: truncated key"))
+		})
+	})
+
+	Describe("RenderKeyInfo", func() {
+		It("renders the public key of an RSA key with its fingerprint", func() {
+			output, err := config.RenderKeyInfo(&config.KeyInfo{Status: config.EncryptionAvailable, PublicKey: &privateKey.PublicKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			fingerprint, err := config.PublicKeyFingerprint(&privateKey.PublicKey)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fingerprint).To(HavePrefix("SHA256:"))
+			Expect(output).To(HavePrefix("encryption:  available\nkey type:    RSA 1024 bits\nfingerprint: " + fingerprint + "\n" +
+				"key alias:   cannot be determined: the fingerprint is the only identifier of the key\n\n-----BEGIN PUBLIC KEY-----\n"))
+			Expect(output).To(HaveSuffix("-----END PUBLIC KEY-----"))
+		})
+
+		It("renders a symmetric key", func() {
+			output, err := config.RenderKeyInfo(&config.KeyInfo{Status: config.EncryptionAvailable})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output).To(Equal("encryption:  available\nkey type:    symmetric (no public key)"))
+		})
+
+		It("renders a missing key", func() {
+			output, err := config.RenderKeyInfo(&config.KeyInfo{Status: config.EncryptionNoKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output).To(Equal("encryption:  not available: no encryption key is installed"))
+		})
+	})
+})
@@ -0,0 +1,397 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/format"
+	"go.yaml.in/yaml/v3"
+)
+
+const (
+	SensitiveKeyReason     = "sensitive key"
+	HighEntropyValueReason = "high-entropy value"
+)
+
+const (
+	highEntropyMinLength = 20
+	highEntropyMinBits   = 4.0
+)
+
+var (
+	credHubReferencePattern = regexp.MustCompile(`^\(\(.+\)\)$|credhub-ref`)
+	placeholderPattern      = regexp.MustCompile(`^\$\{.*\}$`)
+)
+
+// LintFinding is a property of a configuration file whose value looks like a secret but is neither encrypted nor a
+// CredHub reference.
+type LintFinding struct {
+	File         string
+	Line         int
+	Key          string
+	Reason       string
+	Fixed        bool
+	ErrorMessage string
+
+	value string
+	// The value occupies bytes [start, end) of its line. quote is the quote character to use for the fixed value.
+	start int
+	end   int
+	quote string
+}
+
+// LintPath scans a properties or YAML file, or all such files below a directory, for unencrypted secrets.
+func LintPath(path string) ([]LintFinding, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error opening path %s : %s", path, err)
+	}
+
+	files := []string{}
+	if !info.IsDir() {
+		files = append(files, path)
+	} else {
+		err = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() && file != path && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if !info.IsDir() && isConfigurationFile(file) {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Error scanning path %s : %s", path, err)
+		}
+	}
+
+	findings := []LintFinding{}
+	for _, file := range files {
+		fileFindings, err := lintFile(file)
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, fileFindings...)
+	}
+	return findings, nil
+}
+
+func isConfigurationFile(file string) bool {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".properties", ".yml", ".yaml":
+		return true
+	}
+	return false
+}
+
+func lintFile(file string) ([]LintFinding, error) {
+	contents, err := ReadFileContents(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []LintFinding
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".properties":
+		findings = lintProperties(contents)
+	case ".yml", ".yaml":
+		findings, err = lintYaml(contents)
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing file at path %s : %s", file, err)
+		}
+	default:
+		return nil, fmt.Errorf("Unsupported file %s: use a .properties, .yml or .yaml file", file)
+	}
+
+	for i := range findings {
+		findings[i].File = file
+	}
+	return findings, nil
+}
+
+func lintProperties(contents string) []LintFinding {
+	findings := []LintFinding{}
+	scanner := bufio.NewScanner(strings.NewReader(contents))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "!") {
+			continue
+		}
+		separator := strings.IndexAny(line, "=:")
+		if separator < 0 {
+			continue
+		}
+		key := strings.TrimSpace(line[:separator])
+		end := len(strings.TrimRight(line, " \t"))
+		start := separator + 1
+		for start < end && (line[start] == ' ' || line[start] == '\t') {
+			start++
+		}
+		value := line[start:end]
+		if reason := lintReason(key, value); reason != "" {
+			findings = append(findings, LintFinding{Line: lineNumber, Key: key, Reason: reason, value: value, start: start, end: end})
+		}
+	}
+	return findings
+}
+
+// lintYaml scans every document of a YAML file, such as the documents of the profiles of a multi-profile file.
+func lintYaml(contents string) ([]LintFinding, error) {
+	lines := strings.Split(contents, "\n")
+	findings := []LintFinding{}
+	var walk func(prefix string, node *yaml.Node)
+	walk = func(prefix string, node *yaml.Node) {
+		switch node.Kind {
+		case yaml.DocumentNode:
+			for _, child := range node.Content {
+				walk(prefix, child)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key := node.Content[i].Value
+				if prefix != "" {
+					key = prefix + "." + key
+				}
+				walk(key, node.Content[i+1])
+			}
+		case yaml.SequenceNode:
+			for i, child := range node.Content {
+				walk(fmt.Sprintf("%s[%d]", prefix, i), child)
+			}
+		case yaml.ScalarNode:
+			reason := ""
+			switch node.Tag {
+			case "!!str":
+				reason = lintReason(prefix, node.Value)
+			case "!!int", "!!float", "!!bool":
+				// A numeric or boolean value, such as a PIN, is only a secret under a sensitive key.
+				if sensitiveKeyPattern.MatchString(prefix) {
+					reason = SensitiveKeyReason
+				}
+			}
+			if reason != "" {
+				finding := LintFinding{Line: node.Line, Key: prefix, Reason: reason, value: node.Value, quote: "'"}
+				finding.start, finding.end = yamlScalarExtent(lines[node.Line-1], node)
+				findings = append(findings, finding)
+			}
+		}
+	}
+
+	decoder := yaml.NewDecoder(strings.NewReader(contents))
+	for {
+		var document yaml.Node
+		err := decoder.Decode(&document)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		walk("", &document)
+	}
+	return findings, nil
+}
+
+// yamlScalarExtent returns the extent of a scalar on its line, or -1 if the scalar cannot be replaced in place.
+func yamlScalarExtent(line string, node *yaml.Node) (int, int) {
+	start := node.Column - 1
+	if start < 0 || start >= len(line) {
+		return -1, -1
+	}
+	rest := line[start:]
+	switch node.Style {
+	case 0:
+		if strings.HasPrefix(rest, node.Value) {
+			return start, start + len(node.Value)
+		}
+	case yaml.SingleQuotedStyle:
+		for i := 1; i < len(rest); i++ {
+			if rest[i] == '\'' {
+				if i+1 < len(rest) && rest[i+1] == '\'' {
+					i++
+					continue
+				}
+				return start, start + i + 1
+			}
+		}
+	case yaml.DoubleQuotedStyle:
+		for i := 1; i < len(rest); i++ {
+			if rest[i] == '\\' {
+				i++
+				continue
+			}
+			if rest[i] == '"' {
+				return start, start + i + 1
+			}
+		}
+	}
+	return -1, -1
+}
+
+func lintReason(key string, value string) string {
+	if value == "" || strings.HasPrefix(value, cipherPrefix) || credHubReferencePattern.MatchString(value) || placeholderPattern.MatchString(value) {
+		return ""
+	}
+	if sensitiveKeyPattern.MatchString(key) {
+		return SensitiveKeyReason
+	}
+	if isHighEntropy(value) {
+		return HighEntropyValueReason
+	}
+	return ""
+}
+
+// isHighEntropy reports whether a value looks like a generated key or token rather than a word, URL or sentence.
+func isHighEntropy(value string) bool {
+	if len(value) < highEntropyMinLength || strings.ContainsAny(value, " \t") || strings.Contains(value, "://") {
+		return false
+	}
+	if !strings.ContainsAny(value, "0123456789") || !strings.ContainsAny(value, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ") {
+		return false
+	}
+	return shannonEntropy(value) >= highEntropyMinBits
+}
+
+func shannonEntropy(value string) float64 {
+	counts := map[rune]int{}
+	for _, c := range value {
+		counts[c]++
+	}
+	entropy := 0.0
+	length := float64(len([]rune(value)))
+	for _, count := range counts {
+		p := float64(count) / length
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// FixLintFindings encrypts the values of the findings through the config server and replaces them in their files with
+// "{cipher}" values. Each finding records whether it was fixed.
+func FixLintFindings(findings []LintFinding, encrypter Encrypter, configServerInstanceName string) ([]LintFinding, error) {
+	byFile := map[string][]int{}
+	files := []string{}
+	for i := range findings {
+		if _, ok := byFile[findings[i].File]; !ok {
+			files = append(files, findings[i].File)
+		}
+		byFile[findings[i].File] = append(byFile[findings[i].File], i)
+	}
+
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return findings, fmt.Errorf("Error opening file at path %s : %s", file, err)
+		}
+		contents, err := ReadFileContents(file)
+		if err != nil {
+			return findings, err
+		}
+		lines := strings.Split(contents, "\n")
+
+		// Replace from the end of each line so that earlier extents remain valid.
+		indexes := byFile[file]
+		sort.Slice(indexes, func(i, j int) bool {
+			a, b := findings[indexes[i]], findings[indexes[j]]
+			if a.Line != b.Line {
+				return a.Line < b.Line
+			}
+			return a.start > b.start
+		})
+		for _, i := range indexes {
+			finding := &findings[i]
+			if finding.start < 0 {
+				finding.ErrorMessage = "cannot be fixed automatically"
+				continue
+			}
+			cipher, err := encrypter.EncryptString(configServerInstanceName, finding.value)
+			if err != nil {
+				finding.ErrorMessage = err.Error()
+				continue
+			}
+			line := lines[finding.Line-1]
+			replacement := cipherPrefix + cipher
+			if finding.quote != "" {
+				replacement = finding.quote + strings.ReplaceAll(replacement, finding.quote, finding.quote+finding.quote) + finding.quote
+			}
+			lines[finding.Line-1] = line[:finding.start] + replacement + line[finding.end:]
+			finding.Fixed = true
+		}
+
+		err = ioutil.WriteFile(file, []byte(strings.Join(lines, "\n")), info.Mode().Perm())
+		if err != nil {
+			return findings, fmt.Errorf("Error writing file at path %s : %s", file, err)
+		}
+	}
+	return findings, nil
+}
+
+func RenderLintFindings(findings []LintFinding, fix bool) string {
+	if len(findings) == 0 {
+		return "No unencrypted secrets found"
+	}
+
+	tab := &format.Table{}
+	headings := []string{"location", "property", "reason"}
+	if fix {
+		headings = append(headings, "status")
+	}
+	tab.Entitle(headings)
+	for _, finding := range findings {
+		row := []string{finding.File + ":" + strconv.Itoa(finding.Line), finding.Key, finding.Reason}
+		if fix {
+			status := "encrypted"
+			if !finding.Fixed {
+				status = "not fixed: " + finding.ErrorMessage
+			}
+			row = append(row, status)
+		}
+		tab.AddRow(row)
+	}
+	return tab.String()
+}
+
+// CheckLintFindings returns an error listing the findings which remain unencrypted, if any.
+func CheckLintFindings(findings []LintFinding, fix bool) error {
+	unfixed := 0
+	for _, finding := range findings {
+		if !finding.Fixed {
+			unfixed++
+		}
+	}
+	if unfixed > 0 {
+		return fmt.Errorf("found %d unencrypted secrets\n\n%s", unfixed, RenderLintFindings(findings, fix))
+	}
+	return nil
+}
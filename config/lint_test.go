@@ -0,0 +1,332 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package config_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/config"
+)
+
+type stubEncrypter struct {
+	configServerInstanceNames []string
+	err                       error
+}
+
+func (e *stubEncrypter) EncryptString(configServerInstanceName string, plainText string) (string, error) {
+	e.configServerInstanceNames = append(e.configServerInstanceNames, configServerInstanceName)
+	if e.err != nil {
+		return "", e.err
+	}
+	return "encrypted-" + plainText, nil
+}
+
+func (e *stubEncrypter) EncryptFile(configServerInstanceName string, fileToEncrypt string) (string, error) {
+	return "", errors.New("not implemented")
+}
+
+var _ = Describe("Lint", func() {
+
+	const token = "x7Kq2LmP9vR4tZ8wB3nY6cJ1"
+
+	var (
+		testDir  string
+		findings []config.LintFinding
+		err      error
+	)
+
+	writeFile := func(name string, contents string) string {
+		path := filepath.Join(testDir, name)
+		Expect(os.MkdirAll(filepath.Dir(path), 0700)).To(Succeed())
+		Expect(ioutil.WriteFile(path, []byte(contents), 0640)).To(Succeed())
+		return path
+	}
+
+	readFile := func(path string) string {
+		contents, err := ioutil.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		return string(contents)
+	}
+
+	BeforeEach(func() {
+		testDir, err = ioutil.TempDir("", "scs-cli-")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(testDir)
+	})
+
+	Describe("LintPath", func() {
+		Context("when a properties file contains secrets", func() {
+			var path string
+
+			BeforeEach(func() {
+				path = writeFile("application.properties", "# db.password=commented\n"+
+					"db.url=jdbc:postgresql://localhost/db\n"+
+					"db.password = s3cret\n"+
+					"api.token: {cipher}AQBvbjB\n"+
+					"mail.password=${MAIL_PASSWORD}\n"+
+					"client.key="+token+"\n"+
+					"empty.secret=\n")
+				findings, err = config.LintPath(path)
+			})
+
+			It("reports the unencrypted secrets", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(findings).To(HaveLen(2))
+				Expect(findings[0].File).To(Equal(path))
+				Expect(findings[0].Line).To(Equal(3))
+				Expect(findings[0].Key).To(Equal("db.password"))
+				Expect(findings[0].Reason).To(Equal(config.SensitiveKeyReason))
+				Expect(findings[1].Line).To(Equal(6))
+				Expect(findings[1].Key).To(Equal("client.key"))
+				Expect(findings[1].Reason).To(Equal(config.HighEntropyValueReason))
+			})
+		})
+
+		Context("when a YAML file contains secrets", func() {
+			BeforeEach(func() {
+				path := writeFile("application.yml", "spring:\n"+
+					"  datasource:\n"+
+					"    url: jdbc:postgresql://localhost/db\n"+
+					"    password: s3cret\n"+
+					"    timeout: 30\n"+
+					"github:\n"+
+					"  token: ((github-token))\n"+
+					"  secret: credhub-ref:/github\n"+
+					"servers:\n"+
+					"  - \""+token+"\"\n")
+				findings, err = config.LintPath(path)
+			})
+
+			It("reports the unencrypted secrets with their flattened keys", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(findings).To(HaveLen(2))
+				Expect(findings[0].Line).To(Equal(4))
+				Expect(findings[0].Key).To(Equal("spring.datasource.password"))
+				Expect(findings[1].Line).To(Equal(10))
+				Expect(findings[1].Key).To(Equal("servers[0]"))
+				Expect(findings[1].Reason).To(Equal(config.HighEntropyValueReason))
+			})
+		})
+
+		Context("when a YAML file has numeric or boolean secrets", func() {
+			BeforeEach(func() {
+				path := writeFile("application.yml", "db:\n"+
+					"  password: 123456\n"+
+					"  pool-size: 10\n"+
+					"  ssl: true\n"+
+					"api-key: 0x1F\n"+
+					"secret: false\n"+
+					"token: 1.5\n")
+				findings, err = config.LintPath(path)
+			})
+
+			It("reports the values of sensitive keys only", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(findings).To(HaveLen(4))
+				Expect(findings[0].Key).To(Equal("db.password"))
+				Expect(findings[0].Reason).To(Equal(config.SensitiveKeyReason))
+				Expect(findings[1].Key).To(Equal("api-key"))
+				Expect(findings[2].Key).To(Equal("secret"))
+				Expect(findings[3].Key).To(Equal("token"))
+			})
+		})
+
+		Context("when a YAML file has several documents", func() {
+			BeforeEach(func() {
+				path := writeFile("application.yml", "db:\n"+
+					"  password: s3cret\n"+
+					"---\n"+
+					"spring.config.activate.on-profile: cloud\n"+
+					"db:\n"+
+					"  password: cl0ud\n")
+				findings, err = config.LintPath(path)
+			})
+
+			It("reports the unencrypted secrets of every document", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(findings).To(HaveLen(2))
+				Expect(findings[0].Line).To(Equal(2))
+				Expect(findings[1].Line).To(Equal(6))
+				Expect(findings[1].Key).To(Equal("db.password"))
+			})
+		})
+
+		Context("when the path is a directory", func() {
+			BeforeEach(func() {
+				writeFile("app/application.properties", "db.password=s3cret\n")
+				writeFile("app/application.yaml", "db:\n  password: s3cret\n")
+				writeFile("README.md", "password=s3cret\n")
+				writeFile(".git/config.properties", "password=s3cret\n")
+				findings, err = config.LintPath(testDir)
+			})
+
+			It("scans the configuration files below it, skipping hidden directories", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(findings).To(HaveLen(2))
+				Expect(findings[0].File).To(Equal(filepath.Join(testDir, "app", "application.properties")))
+				Expect(findings[1].File).To(Equal(filepath.Join(testDir, "app", "application.yaml")))
+			})
+		})
+
+		Context("when a YAML file is invalid", func() {
+			var path string
+
+			BeforeEach(func() {
+				path = writeFile("application.yml", "db: [\n")
+				findings, err = config.LintPath(path)
+			})
+
+			It("returns a suitable error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(HavePrefix("Error parsing file at path " + path + " : "))
+			})
+		})
+
+		Context("when the path does not exist", func() {
+			BeforeEach(func() {
+				findings, err = config.LintPath(filepath.Join(testDir, "missing"))
+			})
+
+			It("returns a suitable error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(HavePrefix("Error opening path " + filepath.Join(testDir, "missing")))
+			})
+		})
+	})
+
+	Describe("FixLintFindings", func() {
+		var encrypter *stubEncrypter
+
+		BeforeEach(func() {
+			encrypter = &stubEncrypter{}
+		})
+
+		It("encrypts the secrets of a properties file in place", func() {
+			path := writeFile("application.properties", "db.url=jdbc:h2:mem\ndb.password = s3cret  \n")
+			findings, err = config.LintPath(path)
+			Expect(err).NotTo(HaveOccurred())
+
+			findings, err = config.FixLintFindings(findings, encrypter, "config-server")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(findings[0].Fixed).To(BeTrue())
+			Expect(encrypter.configServerInstanceNames).To(Equal([]string{"config-server"}))
+			Expect(readFile(path)).To(Equal("db.url=jdbc:h2:mem\ndb.password = {cipher}encrypted-s3cret  \n"))
+
+			info, err := os.Stat(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0640)))
+		})
+
+		It("encrypts the secrets of a YAML file in place, quoting the cipher", func() {
+			path := writeFile("application.yml", "db:\n  password: s3cret # keep\n  secret: 'it''s'\n  other: { token: \"a\\\"b\" }\n")
+			findings, err = config.LintPath(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(findings).To(HaveLen(3))
+
+			findings, err = config.FixLintFindings(findings, encrypter, "config-server")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(readFile(path)).To(Equal("db:\n  password: '{cipher}encrypted-s3cret' # keep\n  secret: '{cipher}encrypted-it''s'\n  other: { token: '{cipher}encrypted-a\"b' }\n"))
+		})
+
+		It("quotes the cipher of a numeric or boolean secret so that it is read as a string", func() {
+			path := writeFile("application.yml", "db:\n  password: 123456\n  secret: true # keep\n")
+			findings, err = config.LintPath(path)
+			Expect(err).NotTo(HaveOccurred())
+
+			findings, err = config.FixLintFindings(findings, encrypter, "config-server")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(readFile(path)).To(Equal("db:\n  password: '{cipher}encrypted-123456'\n  secret: '{cipher}encrypted-true' # keep\n"))
+		})
+
+		It("encrypts the secrets of every document of a YAML file", func() {
+			path := writeFile("application.yml", "db:\n  password: s3cret\n---\ndb:\n  password: cl0ud\n")
+			findings, err = config.LintPath(path)
+			Expect(err).NotTo(HaveOccurred())
+
+			findings, err = config.FixLintFindings(findings, encrypter, "config-server")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(readFile(path)).To(Equal("db:\n  password: '{cipher}encrypted-s3cret'\n---\ndb:\n  password: '{cipher}encrypted-cl0ud'\n"))
+		})
+
+		It("does not fix multi-line values", func() {
+			contents := "db:\n  password: |\n    s3cret\n"
+			path := writeFile("application.yml", contents)
+			findings, err = config.LintPath(path)
+			Expect(err).NotTo(HaveOccurred())
+
+			findings, err = config.FixLintFindings(findings, encrypter, "config-server")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(findings[0].Fixed).To(BeFalse())
+			Expect(findings[0].ErrorMessage).To(Equal("cannot be fixed automatically"))
+			Expect(readFile(path)).To(Equal(contents))
+		})
+
+		It("records encryption failures", func() {
+			encrypter.err = errors.New("encryption failed")
+			contents := "db.password=s3cret\n"
+			path := writeFile("application.properties", contents)
+			findings, err = config.LintPath(path)
+			Expect(err).NotTo(HaveOccurred())
+
+			findings, err = config.FixLintFindings(findings, encrypter, "config-server")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(findings[0].Fixed).To(BeFalse())
+			Expect(findings[0].ErrorMessage).To(Equal("encryption failed"))
+			Expect(readFile(path)).To(Equal(contents))
+		})
+	})
+
+	Describe("CheckLintFindings", func() {
+		BeforeEach(func() {
+			color.NoColor = true
+		})
+
+		It("succeeds when there are no findings", func() {
+			Expect(config.CheckLintFindings([]config.LintFinding{}, false)).To(Succeed())
+			Expect(config.RenderLintFindings([]config.LintFinding{}, false)).To(Equal("No unencrypted secrets found"))
+		})
+
+		It("succeeds when all findings were fixed", func() {
+			findings := []config.LintFinding{{File: "a.yml", Line: 2, Key: "db.password", Reason: config.SensitiveKeyReason, Fixed: true}}
+			Expect(config.CheckLintFindings(findings, true)).To(Succeed())
+		})
+
+		It("lists the findings without their values", func() {
+			findings := []config.LintFinding{
+				{File: "a.yml", Line: 2, Key: "db.password", Reason: config.SensitiveKeyReason, Fixed: true},
+				{File: "b.properties", Line: 10, Key: "client.key", Reason: config.HighEntropyValueReason, ErrorMessage: "encryption failed"},
+			}
+			err := config.CheckLintFindings(findings, true)
+			Expect(err).To(HaveOccurred())
+			Expect(trimLines(err.Error())).To(Equal(trimLines(`found 1 unencrypted secrets
+
+location        property    reason             status
+a.yml:2         db.password sensitive key      encrypted
+b.properties:10 client.key  high-entropy value not fixed: encryption failed
+`)))
+		})
+	})
+})
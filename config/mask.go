@@ -0,0 +1,66 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package config
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	MaskedValue  = "******"
+	cipherPrefix = "{cipher}"
+)
+
+var sensitiveKeyPattern = regexp.MustCompile(`(?i)(password|passwd|pwd|secret|token|credential|passphrase|private[-_.]?key|api[-_.]?key|access[-_.]?key)`)
+
+// IsSensitive reports whether a property should be masked when displayed: its key looks like a credential, its value
+// is still encrypted, or it was served from CredHub.
+func IsSensitive(property Property) bool {
+	return sensitiveKeyPattern.MatchString(property.Key) ||
+		strings.HasPrefix(property.Value, cipherPrefix) ||
+		SourceType(property.Source) == CredHubSource
+}
+
+// DisplayValue returns the value of a property, masked if it is sensitive unless reveal is set.
+func DisplayValue(property Property, reveal bool) string {
+	if !reveal && IsSensitive(property) {
+		return MaskedValue
+	}
+	return property.Value
+}
+
+const (
+	CredHubSource = "credhub"
+	VaultSource   = "vault"
+	GitSource     = "git"
+	OtherSource   = "other"
+)
+
+// SourceType classifies a property source by the naming conventions of the config server's environment repositories.
+func SourceType(propertySourceName string) string {
+	switch {
+	case strings.HasPrefix(propertySourceName, "credhub-"):
+		return CredHubSource
+	case strings.HasPrefix(propertySourceName, "vault:"):
+		return VaultSource
+	case strings.Contains(propertySourceName, "://") || strings.HasPrefix(propertySourceName, "git@") ||
+		strings.HasPrefix(propertySourceName, "file:") || strings.HasPrefix(propertySourceName, "Config resource "):
+		return GitSource
+	}
+	return OtherSource
+}
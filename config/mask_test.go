@@ -0,0 +1,49 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package config_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/config"
+)
+
+var _ = Describe("Mask", func() {
+
+	DescribeTable("SourceType",
+		func(propertySourceName string, expected string) {
+			Expect(config.SourceType(propertySourceName)).To(Equal(expected))
+		},
+		Entry("CredHub", "credhub-app-default-master", config.CredHubSource),
+		Entry("Vault", "vault:app/default", config.VaultSource),
+		Entry("Git URI", "https://github.com/org/repo.git/app.yml", config.GitSource),
+		Entry("Git clone", "Config resource 'file [/tmp/config-repo-1/app.yml]' via location 'file:/tmp/config-repo-1/'", config.GitSource),
+		Entry("other", "configClient", config.OtherSource),
+	)
+
+	DescribeTable("DisplayValue",
+		func(property config.Property, reveal bool, expected string) {
+			Expect(config.DisplayValue(property, reveal)).To(Equal(expected))
+		},
+		Entry("plain value", config.Property{Key: "server.port", Value: "8080"}, false, "8080"),
+		Entry("credential-like key", config.Property{Key: "spring.datasource.password", Value: "x"}, false, config.MaskedValue),
+		Entry("api key", config.Property{Key: "stripe.api-key", Value: "x"}, false, config.MaskedValue),
+		Entry("encrypted value", config.Property{Key: "a.b", Value: "{cipher}abc"}, false, config.MaskedValue),
+		Entry("CredHub value", config.Property{Key: "a.b", Value: "x", Source: "credhub-app-default-master"}, false, config.MaskedValue),
+		Entry("revealed value", config.Property{Key: "spring.datasource.password", Value: "x"}, true, "x"),
+	)
+})
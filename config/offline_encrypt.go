@@ -0,0 +1,131 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package config
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// The defaults of the config server's RsaSecretEncryptor: PKCS #1 v1.5 padding of a random secret, which is stretched
+// with PBKDF2 and this salt into an AES-256-CBC key.
+const (
+	rsaSecretSize      = 16
+	rsaDefaultSalt     = "deadbeef"
+	rsaAesIterations   = 1024
+	rsaAesKeySize      = 32
+	rsaMaxSecretLength = 0xffff
+)
+
+type offlineEncrypter struct {
+	publicKey *rsa.PublicKey
+}
+
+// NewOfflineEncrypter returns an Encrypter which encrypts values locally with the RSA public key of a config server, in
+// the format the config server produces with its default RSA settings. The configuration server instance name passed
+// to it is ignored.
+func NewOfflineEncrypter(publicKey *rsa.PublicKey) Encrypter {
+	return &offlineEncrypter{publicKey: publicKey}
+}
+
+// ReadPublicKeyFile reads an RSA public key, such as one printed by config-server-key-info, from a file.
+func ReadPublicKeyFile(fileName string) (*rsa.PublicKey, error) {
+	contents, err := ReadFileContents(fileName)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ParseRsaPublicKey(contents)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading public key at path %s : %s", fileName, err)
+	}
+	return key, nil
+}
+
+func (e *offlineEncrypter) EncryptFile(configServerInstanceName string, fileToEncrypt string) (string, error) {
+	textToEncrypt, err := ReadFileContents(fileToEncrypt)
+	if err != nil {
+		return "", err
+	}
+
+	return e.EncryptString(configServerInstanceName, textToEncrypt)
+}
+
+// EncryptString produces the two byte length of the RSA encrypted secret, the secret, and the AES encryption of the
+// text with a key derived from the hex encoded secret, all base64 encoded.
+func (e *offlineEncrypter) EncryptString(configServerInstanceName string, textToEncrypt string) (string, error) {
+	secret := make([]byte, rsaSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	encryptedSecret, err := rsa.EncryptPKCS1v15(rand.Reader, e.publicKey, secret)
+	if err != nil {
+		return "", fmt.Errorf("Encryption failed: %s", err)
+	}
+	if len(encryptedSecret) > rsaMaxSecretLength {
+		return "", errors.New("Encryption failed: RSA key too large")
+	}
+
+	encryptedText, err := encryptAesCbc(hex.EncodeToString(secret), []byte(textToEncrypt))
+	if err != nil {
+		return "", fmt.Errorf("Encryption failed: %s", err)
+	}
+
+	var result bytes.Buffer
+	binary.Write(&result, binary.BigEndian, uint16(len(encryptedSecret)))
+	result.Write(encryptedSecret)
+	result.Write(encryptedText)
+	return base64.StdEncoding.EncodeToString(result.Bytes()), nil
+}
+
+// encryptAesCbc encrypts like Spring Security's Encryptors.standard: the random initialization vector followed by the
+// AES-256-CBC encryption, with PKCS #7 padding, of the text.
+func encryptAesCbc(password string, text []byte) ([]byte, error) {
+	salt, err := hex.DecodeString(rsaDefaultSalt)
+	if err != nil {
+		return nil, err
+	}
+	key, err := pbkdf2.Key(sha1.New, password, salt, rsaAesIterations, rsaAesKeySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	padding := aes.BlockSize - len(text)%aes.BlockSize
+	plaintext := append(append([]byte{}, text...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+
+	result := make([]byte, aes.BlockSize+len(plaintext))
+	iv := result[:aes.BlockSize]
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(result[aes.BlockSize:], plaintext)
+	return result, nil
+}
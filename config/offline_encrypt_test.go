@@ -0,0 +1,129 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package config_test
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/config"
+)
+
+// decryptLikeConfigServer decrypts a value the way the config server's RsaSecretEncryptor does with its default
+// settings.
+func decryptLikeConfigServer(privateKey *rsa.PrivateKey, encrypted string) string {
+	data, err := base64.StdEncoding.DecodeString(encrypted)
+	Expect(err).NotTo(HaveOccurred())
+
+	length := int(binary.BigEndian.Uint16(data))
+	secret, err := rsa.DecryptPKCS1v15(nil, privateKey, data[2:2+length])
+	Expect(err).NotTo(HaveOccurred())
+	data = data[2+length:]
+
+	salt, _ := hex.DecodeString("deadbeef")
+	key, err := pbkdf2.Key(sha1.New, hex.EncodeToString(secret), salt, 1024, 32)
+	Expect(err).NotTo(HaveOccurred())
+	block, err := aes.NewCipher(key)
+	Expect(err).NotTo(HaveOccurred())
+
+	plaintext := make([]byte, len(data)-aes.BlockSize)
+	cipher.NewCBCDecrypter(block, data[:aes.BlockSize]).CryptBlocks(plaintext, data[aes.BlockSize:])
+	return string(plaintext[:len(plaintext)-int(plaintext[len(plaintext)-1])])
+}
+
+var _ = Describe("OfflineEncrypter", func() {
+
+	var (
+		privateKey *rsa.PrivateKey
+		encrypter  config.Encrypter
+		testDir    string
+	)
+
+	BeforeEach(func() {
+		var err error
+		privateKey, err = rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+		encrypter = config.NewOfflineEncrypter(&privateKey.PublicKey)
+
+		testDir, err = ioutil.TempDir("", "scs-cli-")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(testDir)
+	})
+
+	It("encrypts a string in the config server's format", func() {
+		for _, plainText := range []string{"", "s3cret", "exactly sixteen!", "a longer value with ünïcödé"} {
+			encrypted, err := encrypter.EncryptString("", plainText)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decryptLikeConfigServer(privateKey, encrypted)).To(Equal(plainText))
+		}
+	})
+
+	It("uses a new secret for each value", func() {
+		first, err := encrypter.EncryptString("", "s3cret")
+		Expect(err).NotTo(HaveOccurred())
+		second, err := encrypter.EncryptString("", "s3cret")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(first).NotTo(Equal(second))
+	})
+
+	It("encrypts the contents of a file", func() {
+		fileName := filepath.Join(testDir, "secret.txt")
+		Expect(ioutil.WriteFile(fileName, []byte("s3cret"), 0600)).To(Succeed())
+
+		encrypted, err := encrypter.EncryptFile("", fileName)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decryptLikeConfigServer(privateKey, encrypted)).To(Equal("s3cret"))
+	})
+
+	Describe("ReadPublicKeyFile", func() {
+		It("reads a PEM public key", func() {
+			der, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+			Expect(err).NotTo(HaveOccurred())
+			fileName := filepath.Join(testDir, "key.pem")
+			Expect(ioutil.WriteFile(fileName, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600)).To(Succeed())
+
+			key, err := config.ReadPublicKeyFile(fileName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(key).To(Equal(&privateKey.PublicKey))
+		})
+
+		It("reports an invalid key", func() {
+			fileName := filepath.Join(testDir, "key.pem")
+			Expect(ioutil.WriteFile(fileName, []byte("not a key"), 0600)).To(Succeed())
+
+			_, err := config.ReadPublicKeyFile(fileName)
+			Expect(err).To(MatchError("Error reading public key at path " + fileName + " : Invalid RSA public key: expected an ssh-rsa or PEM public key"))
+		})
+	})
+})
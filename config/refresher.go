@@ -6,14 +6,34 @@ import (
 	"errors"
 	"fmt"
 	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/cfutil"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/format"
 	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/httpclient"
 	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/serviceutil"
 	"io"
-	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
 )
 
+const mirrorRefreshFailed = "FAILED"
+
+// MirrorRefresh is the outcome of refreshing the Git mirror of one repository.
+type MirrorRefresh struct {
+	Uri          string
+	Status       string
+	CommitId     string
+	Time         string
+	ErrorMessage string
+}
+
+func (m MirrorRefresh) Failed() bool {
+	return m.Status == mirrorRefreshFailed
+}
+
 type Refresher interface {
-	Refresh(string) error
+	// Refresh refreshes the Git mirrors of a config server and returns the outcome for the mirrors of the given
+	// repositories, or for all mirrors if no repositories are given.
+	Refresh(configServerInstanceName string, repositories []string) ([]MirrorRefresh, error)
 }
 
 type refresher struct {
@@ -22,50 +42,155 @@ type refresher struct {
 	serviceInstanceUrlResolver serviceutil.ServiceInstanceResolver
 }
 
-func checkRefreshStatus(reader io.Reader) error {
-	body, err := ioutil.ReadAll(reader)
+func parseRefreshStatus(reader io.Reader) ([]MirrorRefresh, error) {
+	var jsonMap map[string]map[string]interface{}
+	decoder := json.NewDecoder(reader)
+	decoder.UseNumber()
+	err := decoder.Decode(&jsonMap)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	var jsonMap map[string]map[string]string
-	err = json.Unmarshal(body, &jsonMap)
-	if err != nil {
-		return err
+	mirrors := []MirrorRefresh{}
+	for uri, refresh := range jsonMap {
+		mirrors = append(mirrors, MirrorRefresh{
+			Uri:          uri,
+			Status:       firstField(refresh, "status"),
+			CommitId:     firstField(refresh, "commitId"),
+			Time:         firstField(refresh, "time", "commitTime", "timestamp"),
+			ErrorMessage: firstField(refresh, "errorMessage", "error", "message"),
+		})
 	}
+	sort.Slice(mirrors, func(i, j int) bool {
+		return mirrors[i].Uri < mirrors[j].Uri
+	})
+	return mirrors, nil
+}
 
-	for _, refresh := range jsonMap {
-		status := refresh["status"]
-		if status == "FAILED" {
-			return errors.New("failed to refresh mirror")
+func checkRefreshStatus(mirrors []MirrorRefresh) error {
+	failed := []MirrorRefresh{}
+	for _, mirror := range mirrors {
+		if mirror.Failed() {
+			failed = append(failed, mirror)
 		}
 	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to refresh %d of %d mirrors\n\n%s", len(failed), len(mirrors), RenderMirrorRefreshes(failed))
+	}
 	return nil
 }
 
-func (r *refresher) Refresh(configServerInstanceName string) error {
+func (r *refresher) Refresh(configServerInstanceName string, repositories []string) ([]MirrorRefresh, error) {
 	accessToken, err := cfutil.GetToken(r.cliConnection)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	serviceInstanceUrl, err := r.serviceInstanceUrlResolver.GetServiceInstanceUrl(configServerInstanceName, accessToken)
 	if err != nil {
-		return fmt.Errorf("error obtaining config server URL: %s", err)
+		return nil, fmt.Errorf("error obtaining config server URL: %s", err)
 	}
 
 	bodyReader, status, e := r.authenticatedClient.DoAuthenticatedPost(fmt.Sprintf("%sactuator/refreshmirrors", serviceInstanceUrl), "application/json", "", accessToken)
-	defer bodyReader.Close()
+	if bodyReader != nil {
+		defer bodyReader.Close()
+	}
 
 	if e != nil {
-		return e
+		return nil, e
 	}
 
-	if status != 200 {
-		return errors.New("failed to refresh mirror")
+	if status != 200 || bodyReader == nil {
+		return nil, errors.New("failed to refresh mirror")
 	}
 
-	return checkRefreshStatus(bodyReader)
+	mirrors, err := parseRefreshStatus(bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("invalid refresh mirrors response: %s", err)
+	}
+
+	mirrors, err = selectMirrors(mirrors, repositories)
+	if err != nil {
+		return nil, err
+	}
+
+	return mirrors, checkRefreshStatus(mirrors)
+}
+
+// selectMirrors returns the mirrors whose URI matches one of the given repositories. A repository may be a URI, with or
+// without a ".git" suffix, or a pattern in which "*" matches any sequence of characters.
+func selectMirrors(mirrors []MirrorRefresh, repositories []string) ([]MirrorRefresh, error) {
+	if len(repositories) == 0 {
+		return mirrors, nil
+	}
+
+	selected := []MirrorRefresh{}
+	for _, repository := range repositories {
+		matched := false
+		for _, mirror := range mirrors {
+			if matchesRepository(repository, mirror.Uri) {
+				matched = true
+				if !containsMirror(selected, mirror) {
+					selected = append(selected, mirror)
+				}
+			}
+		}
+		if !matched {
+			return nil, fmt.Errorf("no mirror found for repository %s", repository)
+		}
+	}
+
+	sort.Slice(selected, func(i, j int) bool {
+		return selected[i].Uri < selected[j].Uri
+	})
+	return selected, nil
+}
+
+func matchesRepository(repository string, uri string) bool {
+	if strings.TrimSuffix(repository, ".git") == strings.TrimSuffix(uri, ".git") {
+		return true
+	}
+	pattern := "^" + strings.Replace(regexp.QuoteMeta(repository), `\*`, ".*", -1) + "$"
+	matched, _ := regexp.MatchString(pattern, uri)
+	return matched
+}
+
+func containsMirror(mirrors []MirrorRefresh, mirror MirrorRefresh) bool {
+	for _, m := range mirrors {
+		if m.Uri == mirror.Uri {
+			return true
+		}
+	}
+	return false
+}
+
+// CommitIds returns the commits of the successfully refreshed mirrors.
+func CommitIds(mirrors []MirrorRefresh) []string {
+	commitIds := []string{}
+	for _, mirror := range mirrors {
+		if !mirror.Failed() && mirror.CommitId != "" {
+			commitIds = append(commitIds, mirror.CommitId)
+		}
+	}
+	return commitIds
+}
+
+func RenderMirrorRefreshes(mirrors []MirrorRefresh) string {
+	tab := &format.Table{}
+	tab.Entitle([]string{"mirror", "status", "commit", "time", "error"})
+	for _, mirror := range mirrors {
+		tab.AddRow([]string{mirror.Uri, mirror.Status, mirror.CommitId, mirror.Time, mirror.ErrorMessage})
+	}
+	return tab.String()
+}
+
+func firstField(fields map[string]interface{}, names ...string) string {
+	for _, name := range names {
+		if value, ok := fields[name]; ok && value != nil {
+			return fmt.Sprint(value)
+		}
+	}
+	return ""
 }
 
 func NewRefresher(connection plugin.CliConnection, client httpclient.AuthenticatedClient, resolver serviceutil.ServiceInstanceResolver) Refresher {
@@ -3,6 +3,7 @@ package config_test
 import (
 	"code.cloudfoundry.org/cli/plugin/pluginfakes"
 	"errors"
+	"github.com/fatih/color"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/config"
@@ -27,6 +28,8 @@ var _ = Describe("Refresher", func() {
 		refresher         config.Refresher
 		fakeAuthClient    *httpclientfakes.FakeAuthenticatedClient
 		fakeResolver      *serviceutilfakes.FakeServiceInstanceResolver
+		mirrors           []config.MirrorRefresh
+		repositories      []string
 		refreshError      error
 	)
 
@@ -37,6 +40,7 @@ var _ = Describe("Refresher", func() {
 
 		fakeCliConnection.AccessTokenReturns(bearerAccessToken, nil)
 		fakeResolver.GetServiceInstanceUrlReturns(serviceURI, nil)
+		repositories = nil
 	})
 
 	JustBeforeEach(func() {
@@ -46,7 +50,7 @@ var _ = Describe("Refresher", func() {
 	Describe("Refresh", func() {
 
 		JustBeforeEach(func() {
-			refreshError = refresher.Refresh(configServerName)
+			mirrors, refreshError = refresher.Refresh(configServerName, repositories)
 		})
 
 		Context("when refresh endpoint returns success", func() {
@@ -68,6 +72,61 @@ var _ = Describe("Refresher", func() {
 				Expect(token).To(Equal(accessToken))
 				Expect(bodyType).To(Equal("application/json"))
 			})
+
+			It("should return the status of each mirror", func() {
+				Expect(mirrors).To(Equal([]config.MirrorRefresh{
+					{Uri: "rep-1-url", Status: "SUCCESS", CommitId: "11"},
+					{Uri: "rep-2-url", Status: "SUCCESS", CommitId: "21"},
+				}))
+				Expect(config.CommitIds(mirrors)).To(Equal([]string{"11", "21"}))
+			})
+
+			Context("when repositories are selected", func() {
+				BeforeEach(func() {
+					repositories = []string{"rep-2-*"}
+				})
+
+				It("should return only the matching mirrors", func() {
+					Expect(refreshError).NotTo(HaveOccurred())
+					Expect(mirrors).To(Equal([]config.MirrorRefresh{
+						{Uri: "rep-2-url", Status: "SUCCESS", CommitId: "21"},
+					}))
+				})
+			})
+
+			Context("when a selected repository has no mirror", func() {
+				BeforeEach(func() {
+					repositories = []string{"rep-1-url", "rep-9-url"}
+				})
+
+				It("should return a suitable error", func() {
+					Expect(refreshError).To(MatchError("no mirror found for repository rep-9-url"))
+				})
+			})
+		})
+
+		Context("when the refresh endpoint cannot be reached", func() {
+
+			var e = errors.New("connection refused")
+
+			BeforeEach(func() {
+				fakeAuthClient.DoAuthenticatedPostReturns(nil, 0, e)
+			})
+
+			It("should return the error without panicking", func() {
+				Expect(refreshError).To(Equal(e))
+			})
+		})
+
+		Context("when refresh endpoint returns invalid JSON", func() {
+
+			BeforeEach(func() {
+				fakeAuthClient.DoAuthenticatedPostReturns(ioutil.NopCloser(strings.NewReader("{")), 200, nil)
+			})
+
+			It("should return a suitable error", func() {
+				Expect(refreshError).To(MatchError("invalid refresh mirrors response: unexpected EOF"))
+			})
 		})
 
 		Context("when refresh endpoint fails with error", func() {
@@ -76,7 +135,6 @@ var _ = Describe("Refresher", func() {
 
 			BeforeEach(func() {
 				fakeAuthClient.DoAuthenticatedPostReturns(ioutil.NopCloser(strings.NewReader("")), 500, e)
-				refreshError = refresher.Refresh("fake-service-name")
 			})
 
 			It("should return the provided error message", func() {
@@ -88,7 +146,6 @@ var _ = Describe("Refresher", func() {
 
 			BeforeEach(func() {
 				fakeAuthClient.DoAuthenticatedPostReturns(ioutil.NopCloser(strings.NewReader("")), 500, nil)
-				refreshError = refresher.Refresh("fake-service-name")
 			})
 
 			It("should return the default error message", func() {
@@ -99,17 +156,53 @@ var _ = Describe("Refresher", func() {
 		Context("when refresh fails for at least one mirror", func() {
 			body := `{
 				"rep-1-url" : { "commitId" : "11", "status": "SUCCESS"},
-				"rep-2-url" : { "commitId" : "21", "status": "FAILED"},
+				"rep-2-url" : { "commitId" : "21", "status": "FAILED", "time": 1600000000000, "errorMessage": "authentication failed"},
 				"rep-3-url" : { "commitId" : "31", "status": "SUCCESS"}
 			}`
 			BeforeEach(func() {
 				fakeAuthClient.DoAuthenticatedPostReturns(ioutil.NopCloser(strings.NewReader(body)), 200, nil)
-				refreshError = refresher.Refresh("fake-service-name")
 			})
 
-			It("should return the error message", func() {
-				Expect(refreshError).To(Equal(errors.New("failed to refresh mirror")))
+			It("should return an error listing only the failed mirror", func() {
+				Expect(refreshError).To(HaveOccurred())
+				Expect(refreshError.Error()).To(HavePrefix("failed to refresh 1 of 3 mirrors\n\n"))
+				Expect(refreshError.Error()).To(ContainSubstring("rep-2-url"))
+				Expect(refreshError.Error()).To(ContainSubstring("authentication failed"))
+				Expect(refreshError.Error()).NotTo(ContainSubstring("rep-1-url"))
+				Expect(refreshError.Error()).NotTo(ContainSubstring("rep-3-url"))
+			})
+
+			Context("when only succeeding repositories are selected", func() {
+				BeforeEach(func() {
+					repositories = []string{"rep-1-url.git", "rep-3-url"}
+				})
+
+				It("should not fail", func() {
+					Expect(refreshError).NotTo(HaveOccurred())
+					Expect(mirrors).To(HaveLen(2))
+				})
+			})
+
+			It("should return the status of each mirror", func() {
+				Expect(mirrors).To(HaveLen(3))
+				Expect(mirrors[1].Failed()).To(BeTrue())
+				Expect(mirrors[1].ErrorMessage).To(Equal("authentication failed"))
+				Expect(mirrors[1].Time).To(Equal("1600000000000"))
+			})
+		})
+	})
+
+	Describe("RenderMirrorRefreshes", func() {
+		It("should render a table of mirrors", func() {
+			color.NoColor = true
+			output := config.RenderMirrorRefreshes([]config.MirrorRefresh{
+				{Uri: "rep-1-url", Status: "SUCCESS", CommitId: "11"},
+				{Uri: "rep-2-url", Status: "FAILED", ErrorMessage: "authentication failed"},
 			})
+			Expect(trimLines(output)).To(Equal(`mirror    status  commit time error
+rep-1-url SUCCESS 11
+rep-2-url FAILED              authentication failed
+`))
 		})
 	})
 })
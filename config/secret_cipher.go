@@ -0,0 +1,93 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package config
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// EncryptedValuePrefix marks a value encrypted by a SecretCipher, in the same way as the config server marks values
+// encrypted with its key with "{cipher}".
+const EncryptedValuePrefix = "{encrypted}"
+
+// SecretCipher encrypts individual values, such as the credentials in a manifest of service instances, with AES-256-GCM
+// using a key derived from a passphrase. The key is derived once, from a salt shared by every value.
+type SecretCipher struct {
+	Kdf        string
+	Iterations int
+	Salt       []byte
+	aead       cipher.AEAD
+}
+
+// NewSecretCipher returns a cipher with a new random salt.
+func NewSecretCipher(passphrase string) (*SecretCipher, error) {
+	salt := make([]byte, backupSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return OpenSecretCipher(passphrase, backupKdf, salt, backupIterations)
+}
+
+// OpenSecretCipher returns a cipher which decrypts values encrypted by a cipher with the given key derivation, salt and
+// number of iterations.
+func OpenSecretCipher(passphrase string, kdf string, salt []byte, iterations int) (*SecretCipher, error) {
+	if kdf != backupKdf {
+		return nil, fmt.Errorf("Unsupported key derivation %s", kdf)
+	}
+	aead, err := backupCipher(passphrase, salt, iterations)
+	if err != nil {
+		return nil, err
+	}
+	return &SecretCipher{
+		Kdf:        kdf,
+		Iterations: iterations,
+		Salt:       salt,
+		aead:       aead,
+	}, nil
+}
+
+// IsEncryptedValue reports whether a value was encrypted by a SecretCipher.
+func IsEncryptedValue(value string) bool {
+	return strings.HasPrefix(value, EncryptedValuePrefix)
+}
+
+func (c *SecretCipher) Encrypt(value string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(value), nil)
+	return EncryptedValuePrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (c *SecretCipher) Decrypt(value string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, EncryptedValuePrefix))
+	if err != nil || len(sealed) < c.aead.NonceSize() {
+		return "", errors.New("Invalid encrypted value")
+	}
+	nonceSize := c.aead.NonceSize()
+	plaintext, err := c.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return "", errors.New("Cannot decrypt value: wrong passphrase or corrupted value")
+	}
+	return string(plaintext), nil
+}
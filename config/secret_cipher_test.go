@@ -0,0 +1,67 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package config_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/config"
+)
+
+var _ = Describe("SecretCipher", func() {
+
+	var (
+		secretCipher *config.SecretCipher
+		encrypted    string
+	)
+
+	BeforeEach(func() {
+		var err error
+		secretCipher, err = config.OpenSecretCipher("passphrase", "pbkdf2-sha256", []byte("0123456789abcdef"), 1000)
+		Expect(err).NotTo(HaveOccurred())
+		encrypted, err = secretCipher.Encrypt("s3cret")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("encrypts values which can be decrypted with the passphrase", func() {
+		Expect(config.IsEncryptedValue(encrypted)).To(BeTrue())
+		Expect(encrypted).NotTo(ContainSubstring("s3cret"))
+
+		decrypter, err := config.OpenSecretCipher("passphrase", secretCipher.Kdf, secretCipher.Salt, secretCipher.Iterations)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decrypter.Decrypt(encrypted)).To(Equal("s3cret"))
+	})
+
+	It("cannot decrypt values with another passphrase", func() {
+		decrypter, err := config.OpenSecretCipher("other", secretCipher.Kdf, secretCipher.Salt, secretCipher.Iterations)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = decrypter.Decrypt(encrypted)
+		Expect(err).To(MatchError("Cannot decrypt value: wrong passphrase or corrupted value"))
+	})
+
+	It("rejects an unsupported key derivation", func() {
+		_, err := config.OpenSecretCipher("passphrase", "scrypt", []byte("salt"), 1)
+		Expect(err).To(MatchError("Unsupported key derivation scrypt"))
+	})
+
+	It("generates a salt for a new cipher", func() {
+		newCipher, err := config.NewSecretCipher("passphrase")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(newCipher.Salt).To(HaveLen(16))
+		Expect(newCipher.Iterations).To(Equal(600000))
+	})
+})
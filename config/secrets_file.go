@@ -0,0 +1,139 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// ReadSecretsFile reads a properties, YAML or JSON file, chosen by its extension, and returns its properties with
+// nested keys flattened the way Spring flattens them, e.g. "spring.datasource.password" or "servers[0]".
+func ReadSecretsFile(fileName string) (map[string]interface{}, error) {
+	contents, err := ReadFileContents(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	var secrets map[string]interface{}
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".properties":
+		secrets, err = parseProperties(contents)
+	case ".yml", ".yaml":
+		secrets, err = parseYaml(contents)
+	case ".json":
+		secrets, err = parseJson(contents)
+	default:
+		return nil, fmt.Errorf("Unsupported file %s: use a .properties, .yml, .yaml or .json file", fileName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing file at path %s : %s", fileName, err)
+	}
+	return secrets, nil
+}
+
+func parseProperties(contents string) (map[string]interface{}, error) {
+	properties := map[string]interface{}{}
+	scanner := bufio.NewScanner(strings.NewReader(contents))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+			continue
+		}
+		separator := strings.IndexAny(line, "=:")
+		if separator < 0 {
+			properties[line] = ""
+			continue
+		}
+		properties[strings.TrimSpace(line[:separator])] = strings.TrimSpace(line[separator+1:])
+	}
+	return properties, scanner.Err()
+}
+
+// parseYaml parses a YAML file holding a single document. A file with several documents is rejected rather than having
+// documents, which may be meant for different profiles, merged or dropped.
+func parseYaml(contents string) (map[string]interface{}, error) {
+	documents := []map[string]interface{}{}
+	decoder := yaml.NewDecoder(strings.NewReader(contents))
+	for {
+		var document map[string]interface{}
+		err := decoder.Decode(&document)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if document != nil {
+			documents = append(documents, document)
+		}
+	}
+	if len(documents) > 1 {
+		return nil, fmt.Errorf("found %d YAML documents: put the secrets of each profile in a file of their own", len(documents))
+	}
+
+	properties := map[string]interface{}{}
+	for _, document := range documents {
+		flatten("", document, properties)
+	}
+	return properties, nil
+}
+
+func parseJson(contents string) (map[string]interface{}, error) {
+	var document map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(contents)))
+	decoder.UseNumber()
+	err := decoder.Decode(&document)
+	if err != nil {
+		return nil, err
+	}
+	properties := map[string]interface{}{}
+	flatten("", document, properties)
+	return properties, nil
+}
+
+func flatten(prefix string, value interface{}, properties map[string]interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if prefix == "" {
+				flatten(key, child, properties)
+			} else {
+				flatten(prefix+"."+key, child, properties)
+			}
+		}
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			converted[fmt.Sprint(key)] = child
+		}
+		flatten(prefix, converted, properties)
+	case []interface{}:
+		for i, child := range v {
+			flatten(fmt.Sprintf("%s[%d]", prefix, i), child, properties)
+		}
+	default:
+		properties[prefix] = v
+	}
+}
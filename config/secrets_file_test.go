@@ -0,0 +1,136 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package config_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/config"
+)
+
+var _ = Describe("ReadSecretsFile", func() {
+
+	var (
+		testDir  string
+		fileName string
+		contents string
+		secrets  map[string]interface{}
+		err      error
+	)
+
+	BeforeEach(func() {
+		testDir, err = ioutil.TempDir("", "scs-cli-")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(testDir)
+	})
+
+	JustBeforeEach(func() {
+		path := filepath.Join(testDir, fileName)
+		Expect(ioutil.WriteFile(path, []byte(contents), 0600)).To(Succeed())
+		secrets, err = config.ReadSecretsFile(path)
+	})
+
+	Context("when the file is a properties file", func() {
+		BeforeEach(func() {
+			fileName = "secrets.properties"
+			contents = "# comment\n! comment\n\ndb.password = s3cret\napi.token: t0k=en\n"
+		})
+
+		It("returns the properties", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(secrets).To(Equal(map[string]interface{}{"db.password": "s3cret", "api.token": "t0k=en"}))
+		})
+	})
+
+	Context("when the file is a YAML file", func() {
+		BeforeEach(func() {
+			fileName = "secrets.yml"
+			contents = "db:\n  password: s3cret\n  port: 5432\nservers:\n  - one\n  - two\n"
+		})
+
+		It("returns the flattened properties", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(secrets).To(Equal(map[string]interface{}{"db.password": "s3cret", "db.port": 5432, "servers[0]": "one", "servers[1]": "two"}))
+		})
+	})
+
+	Context("when the YAML file has several documents", func() {
+		BeforeEach(func() {
+			fileName = "secrets.yml"
+			contents = "db:\n  password: s3cret\n---\nspring.config.activate.on-profile: cloud\ndb:\n  password: cl0ud\n"
+		})
+
+		It("returns an error rather than dropping or merging documents", func() {
+			Expect(err).To(MatchError(HaveSuffix("found 2 YAML documents: put the secrets of each profile in a file of their own")))
+			Expect(secrets).To(BeNil())
+		})
+	})
+
+	Context("when the YAML file starts with a document separator", func() {
+		BeforeEach(func() {
+			fileName = "secrets.yaml"
+			contents = "---\ndb:\n  password: s3cret\n"
+		})
+
+		It("returns the properties of the document", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(secrets).To(Equal(map[string]interface{}{"db.password": "s3cret"}))
+		})
+	})
+
+	Context("when the file is a JSON file", func() {
+		BeforeEach(func() {
+			fileName = "secrets.json"
+			contents = `{"db": {"password": "s3cret", "port": 5432}}`
+		})
+
+		It("returns the flattened properties", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(secrets).To(Equal(map[string]interface{}{"db.password": "s3cret", "db.port": json.Number("5432")}))
+		})
+	})
+
+	Context("when the file is invalid", func() {
+		BeforeEach(func() {
+			fileName = "secrets.json"
+			contents = `{"db":`
+		})
+
+		It("returns an error", func() {
+			Expect(err).To(MatchError(HavePrefix("Error parsing file at path ")))
+		})
+	})
+
+	Context("when the file type is not supported", func() {
+		BeforeEach(func() {
+			fileName = "secrets.txt"
+			contents = "db.password=s3cret"
+		})
+
+		It("returns an error", func() {
+			Expect(err).To(MatchError(HaveSuffix("secrets.txt: use a .properties, .yml, .yaml or .json file")))
+		})
+	})
+})
@@ -4,7 +4,7 @@ if [ ! -z ${DEBUG} ]; then
     set -x
 fi
 
-declare -a SCS_COMMANDS=("config-server-add-credhub-secret" "config-server-remove-credhub-secret" "config-server-sync-mirrors" "spring-cloud-service-configuration" "spring-cloud-service-stop" "spring-cloud-service-start" "spring-cloud-service-restart" "spring-cloud-service-restage" "spring-cloud-service-view" "service-registry-info" "service-registry-list" "service-registry-enable" "service-registry-deregister" "service-registry-disable")
+declare -a SCS_COMMANDS=("config-server-add-credhub-secret" "config-server-remove-credhub-secret" "config-server-rotate-credhub-secret" "config-server-list-credhub-secrets" "config-server-get-credhub-secret" "config-server-import-credhub-secrets" "config-server-export-credhub-secrets" "config-server-restore-credhub-secrets" "config-server-lint" "config-server-key-info" "config-server-sync-mirrors" "config-server-get" "config-server-export-env" "config-server-diff" "config-server-compare" "config-server-explain" "spring-cloud-service-list" "spring-cloud-service-configuration" "spring-cloud-service-configuration-diff" "spring-cloud-service-configuration-set" "spring-cloud-service-clone" "spring-cloud-service-export" "spring-cloud-service-apply" "spring-cloud-service-stop" "spring-cloud-service-start" "spring-cloud-service-restart" "spring-cloud-service-restage" "spring-cloud-service-view" "service-registry-info" "service-registry-list" "service-registry-enable" "service-registry-deregister" "service-registry-disable")
 CMD_DOC_FILENAME=cli.md
 
 echo "# Spring Cloud Services CF CLI Plugin Docs
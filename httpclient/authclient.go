@@ -19,6 +19,7 @@ package httpclient
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"strings"
 )
@@ -75,7 +76,7 @@ func (c *authenticatedClient) DoAuthenticatedDelete(url string, accessToken stri
 		return 0, fmt.Errorf("Authenticated delete of '%s' failed: %s", url, err)
 	}
 	if resp.StatusCode != http.StatusOK {
-		return resp.StatusCode, fmt.Errorf("Authenticated delete of '%s' failed: %s", url, resp.Status)
+		return resp.StatusCode, fmt.Errorf("Authenticated delete of '%s' failed: %s", url, describeFailure(resp))
 	}
 	return resp.StatusCode, nil
 }
@@ -115,11 +116,28 @@ func (c *authenticatedClient) DoAuthenticatedPut(url string, bodyType string, bo
 		return 0, fmt.Errorf("Authenticated put of '%s' failed: %s", url, err)
 	}
 	if resp.StatusCode != http.StatusOK {
-		return resp.StatusCode, fmt.Errorf("Authenticated put of '%s' failed: %s", url, resp.Status)
+		return resp.StatusCode, fmt.Errorf("Authenticated put of '%s' failed: %s", url, describeFailure(resp))
 	}
 	return resp.StatusCode, nil
 }
 
+// Maximum number of bytes of a response body to include in an error message.
+const maxFailureBodySize = 1024
+
+// describeFailure returns the status of a failed response followed by its body, if any, since callers of delete and
+// put have no other access to the body. The body is closed.
+func describeFailure(resp *http.Response) string {
+	if resp.Body == nil {
+		return resp.Status
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxFailureBodySize))
+	if err != nil || len(strings.TrimSpace(string(body))) == 0 {
+		return resp.Status
+	}
+	return fmt.Sprintf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+}
+
 func addAuthorizationHeader(req *http.Request, accessToken string) {
 	req.Header.Add("Authorization", fmt.Sprintf("bearer %s", accessToken))
 }
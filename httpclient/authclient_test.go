@@ -192,6 +192,18 @@ var _ = Describe("Authclient", func() {
 				Expect(err).To(MatchError("Authenticated delete of 'https://eureka.pivotal.io/auth/request' failed: 404 Not found"))
 			})
 		})
+
+		Context("when the request returns a bad status with a body", func() {
+			BeforeEach(func() {
+				resp := &http.Response{StatusCode: http.StatusConflict, Status: "409 Conflict", Body: ioutil.NopCloser(strings.NewReader("{\"error\":\"conflict\"}\n"))}
+				fakeClient.DoReturns(resp, nil)
+			})
+
+			It("should include the body in the error", func() {
+				Expect(status).To(Equal(http.StatusConflict))
+				Expect(err).To(MatchError("Authenticated delete of 'https://eureka.pivotal.io/auth/request' failed: 409 Conflict: {\"error\":\"conflict\"}"))
+			})
+		})
 	})
 
 	Describe("DoAuthenticatedPost", func() {
@@ -381,5 +393,17 @@ var _ = Describe("Authclient", func() {
 				Expect(err).To(MatchError("Authenticated put of 'https://eureka.pivotal.io/auth/request' failed: 404 Not found"))
 			})
 		})
+
+		Context("when the request returns a bad status with a body", func() {
+			BeforeEach(func() {
+				resp := &http.Response{StatusCode: http.StatusConflict, Status: "409 Conflict", Body: ioutil.NopCloser(strings.NewReader("{\"error\":\"conflict\"}\n"))}
+				fakeClient.DoReturns(resp, nil)
+			})
+
+			It("should include the body in the error", func() {
+				Expect(status).To(Equal(http.StatusConflict))
+				Expect(err).To(MatchError("Authenticated put of 'https://eureka.pivotal.io/auth/request' failed: 409 Conflict: {\"error\":\"conflict\"}"))
+			})
+		})
 	})
 })
@@ -0,0 +1,221 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package instance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/plugin"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/httpclient"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/serviceutil"
+)
+
+// CloneOptions describes the service instance created by a clone.
+type CloneOptions struct {
+	// Plan is the service plan of the new service instance. Defaults to the plan of the source service instance.
+	Plan string
+	// BindApps binds the apps which are bound to the source service instance to the new service instance.
+	BindApps bool
+}
+
+type cloneOperation struct {
+	cliConnection       plugin.CliConnection
+	authenticatedClient httpclient.AuthenticatedClient
+	parametersOperation Operation
+	sourceInstanceName  string
+	newInstanceName     string
+	options             CloneOptions
+	timeout             time.Duration
+	pollInterval        time.Duration
+	progressWriter      io.Writer
+}
+
+type serviceCredentialBindingsResp struct {
+	Pagination struct {
+		Next *struct {
+			Href string
+		}
+	}
+	Resources []struct {
+		Relationships struct {
+			App struct {
+				Data struct {
+					Guid string
+				}
+			}
+		}
+	}
+	Included struct {
+		Apps []struct {
+			Guid string
+			Name string
+		}
+	}
+}
+
+// NewCloneOperation returns an operation which creates a service instance with the service offering, plan and
+// configuration parameters of the source service instance, waits for it to be provisioned and then, if requested,
+// binds the apps which are bound to the source service instance.
+func NewCloneOperation(cliConnection plugin.CliConnection, authenticatedClient httpclient.AuthenticatedClient, sourceInstanceName string, newInstanceName string, options CloneOptions, timeout time.Duration, pollInterval time.Duration, progressWriter io.Writer) Operation {
+	return &cloneOperation{
+		cliConnection:       cliConnection,
+		authenticatedClient: authenticatedClient,
+		parametersOperation: NewParametersOperation(authenticatedClient),
+		sourceInstanceName:  sourceInstanceName,
+		newInstanceName:     newInstanceName,
+		options:             options,
+		timeout:             timeout,
+		pollInterval:        pollInterval,
+		progressWriter:      progressWriter,
+	}
+}
+
+func (co *cloneOperation) Run(serviceInstanceManagementParameters serviceutil.ManagementParameters, accessToken string) (string, error) {
+	parameters, err := co.parametersOperation.Run(serviceInstanceManagementParameters, accessToken)
+	if err != nil {
+		return "", err
+	}
+	document, err := decodeParameters(parameters)
+	if err != nil {
+		return "", err
+	}
+	parametersJson, err := json.Marshal(document)
+	if err != nil {
+		return "", err
+	}
+
+	var appNames []string
+	if co.options.BindApps {
+		sourceModel, err := co.cliConnection.GetService(co.sourceInstanceName)
+		if err != nil {
+			return "", fmt.Errorf("Service instance not found: %s", err)
+		}
+		appNames, err = co.boundAppNames(sourceModel.Guid, accessToken)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	plan := co.options.Plan
+	if plan == "" {
+		plan = serviceInstanceManagementParameters.ServicePlanName
+	}
+	fmt.Fprintf(co.progressWriter, "Creating service instance %s of service %s with plan %s\n", co.newInstanceName, serviceInstanceManagementParameters.ServiceOfferingName, plan)
+	_, err = co.cliConnection.CliCommandWithoutTerminalOutput("create-service", serviceInstanceManagementParameters.ServiceOfferingName, plan, co.newInstanceName, "-c", string(parametersJson))
+	if err != nil {
+		return "", fmt.Errorf("Error creating service instance %s: %s", co.newInstanceName, err)
+	}
+	err = waitForLastOperation(co.cliConnection, co.newInstanceName, "creation", co.timeout, co.pollInterval, co.progressWriter)
+	if err != nil {
+		return "", err
+	}
+
+	result := fmt.Sprintf("Created service instance %s as a clone of %s", co.newInstanceName, co.sourceInstanceName)
+	if !co.options.BindApps {
+		return result, nil
+	}
+	if len(appNames) == 0 {
+		return result + fmt.Sprintf("\nNo apps are bound to %s", co.sourceInstanceName), nil
+	}
+	for _, appName := range appNames {
+		fmt.Fprintf(co.progressWriter, "Binding app %s to %s\n", appName, co.newInstanceName)
+		_, err = co.cliConnection.CliCommandWithoutTerminalOutput("bind-service", appName, co.newInstanceName)
+		if err != nil {
+			return "", fmt.Errorf("Error binding app %s to service instance %s: %s", appName, co.newInstanceName, err)
+		}
+	}
+	return result + fmt.Sprintf("\nBound apps %s: restage them to use %s", strings.Join(appNames, ", "), co.newInstanceName), nil
+}
+
+func (co *cloneOperation) IsServiceBrokerOperation() bool {
+	return co.parametersOperation.IsServiceBrokerOperation()
+}
+
+// boundAppNames returns the sorted names of the apps bound to a service instance.
+func (co *cloneOperation) boundAppNames(serviceInstanceGuid string, accessToken string) ([]string, error) {
+	apiUrl, err := co.cliConnection.ApiEndpoint()
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	query.Set("type", "app")
+	query.Set("service_instance_guids", serviceInstanceGuid)
+	query.Set("include", "app")
+
+	names := map[string]bool{}
+	nextUrl := fmt.Sprintf("%s/v3/service_credential_bindings?%s", strings.TrimSuffix(apiUrl, "/"), query.Encode())
+	for nextUrl != "" {
+		page, err := co.getBindingsPage(nextUrl, accessToken)
+		if err != nil {
+			return nil, err
+		}
+
+		appNames := map[string]string{}
+		for _, app := range page.Included.Apps {
+			appNames[app.Guid] = app.Name
+		}
+		for _, resource := range page.Resources {
+			if name, ok := appNames[resource.Relationships.App.Data.Guid]; ok {
+				names[name] = true
+			}
+		}
+
+		nextUrl = ""
+		if page.Pagination.Next != nil {
+			nextUrl = page.Pagination.Next.Href
+		}
+	}
+
+	sortedNames := make([]string, 0, len(names))
+	for name := range names {
+		sortedNames = append(sortedNames, name)
+	}
+	sort.Strings(sortedNames)
+	return sortedNames, nil
+}
+
+func (co *cloneOperation) getBindingsPage(pageUrl string, accessToken string) (*serviceCredentialBindingsResp, error) {
+	bodyReader, _, err := co.authenticatedClient.DoAuthenticatedGet(pageUrl, accessToken)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot list service bindings: %s", err)
+	}
+	if bodyReader == nil {
+		return nil, errors.New("Service bindings response body missing")
+	}
+	defer bodyReader.Close()
+
+	body, err := ioutil.ReadAll(bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read service bindings response body: %s", err)
+	}
+
+	var page serviceCredentialBindingsResp
+	err = json.Unmarshal(body, &page)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid service bindings response JSON: %s, response body: '%s'", err, string(body))
+	}
+	return &page, nil
+}
@@ -0,0 +1,172 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package instance_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/plugin/models"
+	"code.cloudfoundry.org/cli/plugin/pluginfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/httpclient/httpclientfakes"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/instance"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/serviceutil"
+)
+
+var _ = Describe("CloneOperation", func() {
+
+	const bindings = `{
+		"pagination": {"next": null},
+		"resources": [
+			{"relationships": {"app": {"data": {"guid": "app-2"}}}},
+			{"relationships": {"app": {"data": {"guid": "app-1"}}}}
+		],
+		"included": {"apps": [{"guid": "app-1", "name": "orders"}, {"guid": "app-2", "name": "billing"}]}
+	}`
+
+	var (
+		fakeCliConnection *pluginfakes.FakeCliConnection
+		fakeAuthClient    *httpclientfakes.FakeAuthenticatedClient
+		options           instance.CloneOptions
+		progress          *bytes.Buffer
+		output            string
+		err               error
+	)
+
+	BeforeEach(func() {
+		fakeCliConnection = &pluginfakes.FakeCliConnection{}
+		fakeCliConnection.AccessTokenReturns("bearer someaccesstoken", nil)
+		fakeCliConnection.ApiEndpointReturns("https://api.example.com", nil)
+		fakeCliConnection.GetServiceStub = func(name string) (plugin_models.GetService_Model, error) {
+			if name == "config-server" {
+				return plugin_models.GetService_Model{Guid: "source-guid", LastOperation: plugin_models.GetService_LastOperation{State: "succeeded"}}, nil
+			}
+			return plugin_models.GetService_Model{LastOperation: plugin_models.GetService_LastOperation{Type: "create", State: "succeeded"}}, nil
+		}
+		fakeAuthClient = &httpclientfakes.FakeAuthenticatedClient{}
+		fakeAuthClient.DoAuthenticatedGetStub = func(getUrl string, accessToken string) (io.ReadCloser, int, error) {
+			if strings.Contains(getUrl, "/v3/service_credential_bindings") {
+				return ioutil.NopCloser(strings.NewReader(bindings)), http.StatusOK, nil
+			}
+			return ioutil.NopCloser(strings.NewReader(`{"count": 2, "git": {"uri": "https://github.com/org/config", "password": "s3cret"}}`)), http.StatusOK, nil
+		}
+		options = instance.CloneOptions{}
+		progress = &bytes.Buffer{}
+	})
+
+	JustBeforeEach(func() {
+		operation := instance.NewCloneOperation(fakeCliConnection, fakeAuthClient, "config-server", "config-server-feature", options, time.Second, time.Millisecond, progress)
+		Expect(operation.IsServiceBrokerOperation()).To(BeFalse())
+		output, err = operation.Run(serviceutil.ManagementParameters{
+			Url:                 "https://servicebroker.host/cli/instances/source-guid",
+			ServiceOfferingName: "p.config-server",
+			ServicePlanName:     "standard",
+		}, "someaccesstoken")
+	})
+
+	It("creates a service instance with the offering, plan and parameters of the source", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output).To(Equal("Created service instance config-server-feature as a clone of config-server"))
+
+		url, _ := fakeAuthClient.DoAuthenticatedGetArgsForCall(0)
+		Expect(url).To(Equal("https://servicebroker.host/cli/instances/source-guid/parameters"))
+
+		Expect(fakeCliConnection.CliCommandWithoutTerminalOutputCallCount()).To(Equal(1))
+		args := fakeCliConnection.CliCommandWithoutTerminalOutputArgsForCall(0)
+		Expect(args[:5]).To(Equal([]string{"create-service", "p.config-server", "standard", "config-server-feature", "-c"}))
+		Expect(args[5]).To(MatchJSON(`{"count": 2, "git": {"uri": "https://github.com/org/config", "password": "s3cret"}}`))
+		Expect(fakeCliConnection.GetServiceArgsForCall(0)).To(Equal("config-server-feature"))
+	})
+
+	Context("when a plan is given", func() {
+		BeforeEach(func() {
+			options.Plan = "large"
+		})
+
+		It("creates the service instance with that plan", func() {
+			args := fakeCliConnection.CliCommandWithoutTerminalOutputArgsForCall(0)
+			Expect(args[2]).To(Equal("large"))
+		})
+	})
+
+	Context("when binding apps", func() {
+		BeforeEach(func() {
+			options.BindApps = true
+		})
+
+		It("binds the apps of the source to the new service instance once it is created", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output).To(Equal("Created service instance config-server-feature as a clone of config-server\nBound apps billing, orders: restage them to use config-server-feature"))
+
+			bindingsUrl, accessToken := fakeAuthClient.DoAuthenticatedGetArgsForCall(1)
+			Expect(accessToken).To(Equal("someaccesstoken"))
+			parsedUrl, err := url.Parse(bindingsUrl)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(parsedUrl.Path).To(Equal("/v3/service_credential_bindings"))
+			Expect(parsedUrl.Query().Get("service_instance_guids")).To(Equal("source-guid"))
+
+			Expect(fakeCliConnection.CliCommandWithoutTerminalOutputCallCount()).To(Equal(3))
+			Expect(fakeCliConnection.CliCommandWithoutTerminalOutputArgsForCall(1)).To(Equal([]string{"bind-service", "billing", "config-server-feature"}))
+			Expect(fakeCliConnection.CliCommandWithoutTerminalOutputArgsForCall(2)).To(Equal([]string{"bind-service", "orders", "config-server-feature"}))
+		})
+
+		Context("when the bindings cannot be listed", func() {
+			BeforeEach(func() {
+				fakeAuthClient.DoAuthenticatedGetStub = func(getUrl string, accessToken string) (io.ReadCloser, int, error) {
+					if strings.Contains(getUrl, "/v3/service_credential_bindings") {
+						return nil, http.StatusForbidden, errors.New("forbidden")
+					}
+					return ioutil.NopCloser(strings.NewReader(`{}`)), http.StatusOK, nil
+				}
+			})
+
+			It("does not create the service instance", func() {
+				Expect(err).To(MatchError("Cannot list service bindings: forbidden"))
+				Expect(fakeCliConnection.CliCommandWithoutTerminalOutputCallCount()).To(Equal(0))
+			})
+		})
+	})
+
+	Context("when the service instance cannot be created", func() {
+		BeforeEach(func() {
+			fakeCliConnection.CliCommandWithoutTerminalOutputReturns(nil, errors.New("service instance name is taken"))
+		})
+
+		It("returns a suitable error", func() {
+			Expect(err).To(MatchError("Error creating service instance config-server-feature: service instance name is taken"))
+		})
+	})
+
+	Context("when provisioning fails", func() {
+		BeforeEach(func() {
+			fakeCliConnection.GetServiceStub = nil
+			fakeCliConnection.GetServiceReturns(plugin_models.GetService_Model{LastOperation: plugin_models.GetService_LastOperation{State: "failed", Description: "quota exceeded"}}, nil)
+		})
+
+		It("returns a suitable error", func() {
+			Expect(err).To(MatchError("The creation of service instance config-server-feature failed: quota exceeded"))
+		})
+	})
+})
@@ -0,0 +1,106 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package instance
+
+import (
+	"fmt"
+	"sort"
+
+	"code.cloudfoundry.org/cli/plugin"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/cfutil"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/format"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/httpclient"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/serviceutil"
+)
+
+const crashedState = "CRASHED"
+
+// ListServiceInstances lists the Spring Cloud Services service instances of the targeted space or, if allSpaces is
+// set, of every space of the targeted org, with a summary of the health of each according to its service broker.
+func ListServiceInstances(cliConnection plugin.CliConnection, lister serviceutil.ServiceInstanceLister, authenticatedClient httpclient.AuthenticatedClient, allSpaces bool) (string, error) {
+	accessToken, err := cfutil.GetToken(cliConnection)
+	if err != nil {
+		return "", err
+	}
+
+	serviceInstances, err := lister.List(accessToken, allSpaces)
+	if err != nil {
+		return "", err
+	}
+	if len(serviceInstances) == 0 {
+		return "No Spring Cloud Services service instances found", nil
+	}
+
+	sort.SliceStable(serviceInstances, func(i, j int) bool {
+		if serviceInstances[i].SpaceName != serviceInstances[j].SpaceName {
+			return serviceInstances[i].SpaceName < serviceInstances[j].SpaceName
+		}
+		return serviceInstances[i].Name < serviceInstances[j].Name
+	})
+
+	tab := &format.Table{}
+	headings := []string{"name", "type", "plan", "generation", "dashboard", "health"}
+	if allSpaces {
+		headings = append([]string{"space"}, headings...)
+	}
+	tab.Entitle(headings)
+	for _, serviceInstance := range serviceInstances {
+		health := "unavailable"
+		view, err := fetchView(authenticatedClient, serviceutil.ManagementParameters{Url: serviceInstance.ManagementUrl}, accessToken)
+		if err == nil {
+			health = HealthSummary(view)
+		}
+
+		row := []string{serviceInstance.Name, serviceInstance.Type(), serviceInstance.ServicePlanName, serviceInstance.Generation(), serviceInstance.DashboardUrl, health}
+		if allSpaces {
+			row = append([]string{serviceInstance.SpaceName}, row...)
+		}
+		tab.AddRow(row)
+	}
+	return tab.String(), nil
+}
+
+// HealthSummary summarises the state of the backing apps of a service instance, such as "2/2 instances running".
+func HealthSummary(view *ViewInstanceResp) string {
+	if len(view.BackingApps) == 0 {
+		return "no backing apps"
+	}
+
+	running, total, crashed := 0, 0, 0
+	stopped := true
+	for _, backingApp := range view.BackingApps {
+		running += backingApp.RunningInstances
+		total += backingApp.NumInstances
+		if backingApp.RequestedState != StoppedState {
+			stopped = false
+		}
+		for _, backingAI := range backingApp.Instances {
+			if backingAI.State == crashedState {
+				crashed++
+			}
+		}
+	}
+	if stopped {
+		return "stopped"
+	}
+
+	health := fmt.Sprintf("%d/%d instances running", running, total)
+	if crashed > 0 {
+		health += fmt.Sprintf(", %d crashed", crashed)
+	}
+	return health
+}
@@ -0,0 +1,138 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package instance_test
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"code.cloudfoundry.org/cli/plugin/pluginfakes"
+	"github.com/fatih/color"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/httpclient/httpclientfakes"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/instance"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/serviceutil"
+)
+
+type stubServiceInstanceLister struct {
+	serviceInstances []serviceutil.ServiceInstance
+	err              error
+	allSpaces        bool
+}
+
+func (s *stubServiceInstanceLister) List(accessToken string, allSpaces bool) ([]serviceutil.ServiceInstance, error) {
+	s.allSpaces = allSpaces
+	return s.serviceInstances, s.err
+}
+
+var _ = Describe("ListServiceInstances", func() {
+
+	var (
+		fakeCliConnection *pluginfakes.FakeCliConnection
+		fakeAuthClient    *httpclientfakes.FakeAuthenticatedClient
+		lister            *stubServiceInstanceLister
+		views             map[string]string
+		allSpaces         bool
+		output            string
+		err               error
+	)
+
+	BeforeEach(func() {
+		color.NoColor = true
+		fakeCliConnection = &pluginfakes.FakeCliConnection{}
+		fakeCliConnection.AccessTokenReturns("bearer someaccesstoken", nil)
+		fakeAuthClient = &httpclientfakes.FakeAuthenticatedClient{}
+		views = map[string]string{
+			"https://broker/cli/instances/guid-1": `{"backing_apps": [{"name": "config-server", "requested_state": "STARTED", "num_instances": 2, "running_instances": 1, "instances": [{"state": "RUNNING"}, {"state": "CRASHED"}]}]}`,
+			"https://broker/cli/instances/guid-2": `{"backing_apps": [{"name": "eureka", "requested_state": "STOPPED", "num_instances": 1, "running_instances": 0}]}`,
+		}
+		fakeAuthClient.DoAuthenticatedGetStub = func(url string, accessToken string) (io.ReadCloser, int, error) {
+			view, ok := views[url]
+			if !ok {
+				return nil, http.StatusNotFound, errors.New("not found")
+			}
+			return ioutil.NopCloser(strings.NewReader(view)), http.StatusOK, nil
+		}
+		lister = &stubServiceInstanceLister{
+			serviceInstances: []serviceutil.ServiceInstance{
+				{Name: "registry", SpaceName: "prod", ServiceOfferingName: "p.service-registry", ServicePlanName: "standard", DashboardUrl: "https://registry/dashboard", ManagementUrl: "https://broker/cli/instances/guid-2"},
+				{Name: "config-server", SpaceName: "dev", ServiceOfferingName: "p-config-server", ServicePlanName: "standard", DashboardUrl: "https://config/dashboard", ManagementUrl: "https://broker/cli/instances/guid-1"},
+				{Name: "creating", SpaceName: "dev", ServiceOfferingName: "p.config-server", ServicePlanName: "standard", ManagementUrl: "https://broker/cli/instances/guid-3"},
+			},
+		}
+		allSpaces = false
+	})
+
+	JustBeforeEach(func() {
+		output, err = instance.ListServiceInstances(fakeCliConnection, lister, fakeAuthClient, allSpaces)
+	})
+
+	It("lists the service instances with their health", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lister.allSpaces).To(BeFalse())
+		Expect(trimLines(output)).To(Equal(`name          type             plan     generation dashboard                  health
+config-server config server    standard v2         https://config/dashboard   1/2 instances running, 1 crashed
+creating      config server    standard v3                                    unavailable
+registry      service registry standard v3         https://registry/dashboard stopped
+`))
+	})
+
+	Context("when listing all spaces", func() {
+		BeforeEach(func() {
+			allSpaces = true
+		})
+
+		It("shows the space of each service instance", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lister.allSpaces).To(BeTrue())
+			Expect(output).To(HavePrefix("space name          type "))
+		})
+	})
+
+	Context("when there are no service instances", func() {
+		BeforeEach(func() {
+			lister.serviceInstances = nil
+		})
+
+		It("says so", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output).To(Equal("No Spring Cloud Services service instances found"))
+		})
+	})
+
+	Context("when the service instances cannot be listed", func() {
+		BeforeEach(func() {
+			lister.err = errors.New("Cannot list service instances: forbidden")
+		})
+
+		It("returns the error", func() {
+			Expect(err).To(MatchError("Cannot list service instances: forbidden"))
+		})
+	})
+})
+
+func trimLines(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,280 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package instance
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"code.cloudfoundry.org/cli/plugin"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/config"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/httpclient"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/serviceutil"
+	"go.yaml.in/yaml/v3"
+)
+
+// Manifest declares the Spring Cloud Services service instances of a space.
+type Manifest struct {
+	Encryption       *ManifestEncryption       `yaml:"encryption,omitempty"`
+	ServiceInstances []ManifestServiceInstance `yaml:"service_instances"`
+}
+
+// ManifestEncryption describes how the credentials in a manifest were encrypted, so they can be decrypted with the
+// same passphrase.
+type ManifestEncryption struct {
+	Kdf        string `yaml:"kdf"`
+	Iterations int    `yaml:"iterations"`
+	Salt       string `yaml:"salt"`
+}
+
+// ManifestServiceInstance declares a service instance: its service offering, plan and configuration parameters, and
+// the apps bound to it.
+type ManifestServiceInstance struct {
+	Name       string                 `yaml:"name"`
+	Service    string                 `yaml:"service"`
+	Plan       string                 `yaml:"plan"`
+	Parameters map[string]interface{} `yaml:"parameters,omitempty"`
+	BoundApps  []string               `yaml:"bound_apps,omitempty"`
+}
+
+// ExportManifest returns a manifest of the Spring Cloud Services service instances of the targeted space. Credentials
+// in the configuration parameters are redacted or, if a cipher is given, encrypted.
+func ExportManifest(cliConnection plugin.CliConnection, operationRunner OperationRunner, authenticatedClient httpclient.AuthenticatedClient, secretCipher *config.SecretCipher) (*Manifest, error) {
+	services, err := cliConnection.GetServices()
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{ServiceInstances: []ManifestServiceInstance{}}
+	if secretCipher != nil {
+		manifest.Encryption = &ManifestEncryption{
+			Kdf:        secretCipher.Kdf,
+			Iterations: secretCipher.Iterations,
+			Salt:       base64.StdEncoding.EncodeToString(secretCipher.Salt),
+		}
+	}
+	for _, service := range services {
+		if !serviceutil.IsScsServiceOffering(service.Service.Name) {
+			continue
+		}
+
+		parameters, err := operationRunner.RunOperation(service.Name, NewParametersOperation(authenticatedClient))
+		if err != nil {
+			return nil, fmt.Errorf("Error exporting service instance %s: %s", service.Name, err)
+		}
+		document, err := decodeParameters(parameters)
+		if err != nil {
+			return nil, err
+		}
+		err = protectSecrets("", document, secretCipher)
+		if err != nil {
+			return nil, err
+		}
+
+		boundApps := append([]string{}, service.ApplicationNames...)
+		sort.Strings(boundApps)
+		manifest.ServiceInstances = append(manifest.ServiceInstances, ManifestServiceInstance{
+			Name:       service.Name,
+			Service:    service.Service.Name,
+			Plan:       service.ServicePlan.Name,
+			Parameters: document,
+			BoundApps:  boundApps,
+		})
+	}
+	sort.Slice(manifest.ServiceInstances, func(i, j int) bool {
+		return manifest.ServiceInstances[i].Name < manifest.ServiceInstances[j].Name
+	})
+	return manifest, nil
+}
+
+// RenderManifest renders a manifest as YAML.
+func RenderManifest(manifest *Manifest) (string, error) {
+	contents, err := yaml.Marshal(manifest)
+	if err != nil {
+		return "", err
+	}
+	return string(contents), nil
+}
+
+// WriteManifestFile writes a manifest to a file which only the current user can read, since it may contain encrypted
+// credentials.
+func WriteManifestFile(fileName string, contents string) error {
+	err := ioutil.WriteFile(fileName, []byte(contents), 0600)
+	if err != nil {
+		return fmt.Errorf("Error writing file at path %s : %s", fileName, err)
+	}
+	return nil
+}
+
+// ReadManifest reads and checks a manifest of service instances.
+func ReadManifest(fileName string) (*Manifest, error) {
+	contents, err := config.ReadFileContents(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{}
+	err = yaml.Unmarshal([]byte(contents), manifest)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing file at path %s : %s", fileName, err)
+	}
+
+	names := map[string]bool{}
+	for i := range manifest.ServiceInstances {
+		serviceInstance := &manifest.ServiceInstances[i]
+		if serviceInstance.Name == "" || serviceInstance.Service == "" || serviceInstance.Plan == "" {
+			return nil, fmt.Errorf("Invalid manifest %s: service instance %d must have a name, service and plan", fileName, i+1)
+		}
+		if names[serviceInstance.Name] {
+			return nil, fmt.Errorf("Invalid manifest %s: service instance %s is declared more than once", fileName, serviceInstance.Name)
+		}
+		names[serviceInstance.Name] = true
+
+		// Round trip through JSON so that the parameters have the same types as those fetched from the service broker.
+		parametersJson, err := json.Marshal(serviceInstance.Parameters)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid parameters of service instance %s in manifest %s: %s", serviceInstance.Name, fileName, err)
+		}
+		serviceInstance.Parameters, err = decodeParameters(string(parametersJson))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return manifest, nil
+}
+
+// OpenManifestCipher returns a cipher which decrypts the credentials of a manifest, or nil if they are not encrypted.
+func OpenManifestCipher(manifest *Manifest, passphrase string) (*config.SecretCipher, error) {
+	if manifest.Encryption == nil {
+		return nil, nil
+	}
+	salt, err := base64.StdEncoding.DecodeString(manifest.Encryption.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid manifest encryption salt: %s", err)
+	}
+	return config.OpenSecretCipher(passphrase, manifest.Encryption.Kdf, salt, manifest.Encryption.Iterations)
+}
+
+// protectSecrets replaces the values of credentials, such as passwords and tokens, with encrypted values or, if there
+// is no cipher, with masked values.
+func protectSecrets(key string, value interface{}, secretCipher *config.SecretCipher) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for childKey, child := range v {
+			childPath := joinParameterKey(key, childKey)
+			protected, err := protectSecret(childPath, child, secretCipher)
+			if err != nil {
+				return err
+			}
+			v[childKey] = protected
+		}
+	case []interface{}:
+		for i, child := range v {
+			protected, err := protectSecret(fmt.Sprintf("%s[%d]", key, i), child, secretCipher)
+			if err != nil {
+				return err
+			}
+			v[i] = protected
+		}
+	}
+	return nil
+}
+
+func protectSecret(key string, value interface{}, secretCipher *config.SecretCipher) (interface{}, error) {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return value, protectSecrets(key, value, secretCipher)
+	}
+	if value == nil || config.DisplayValue(config.Property{Key: key, Value: parameterString(value)}, false) != config.MaskedValue {
+		return value, nil
+	}
+	if secretCipher == nil {
+		return config.MaskedValue, nil
+	}
+	return secretCipher.Encrypt(parameterString(value))
+}
+
+// decryptSecrets replaces encrypted values with their plain text.
+func decryptSecrets(value interface{}, secretCipher *config.SecretCipher) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			decrypted, err := decryptSecrets(child, secretCipher)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = decrypted
+		}
+	case []interface{}:
+		for i, child := range v {
+			decrypted, err := decryptSecrets(child, secretCipher)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = decrypted
+		}
+	case string:
+		if !config.IsEncryptedValue(v) {
+			return v, nil
+		}
+		if secretCipher == nil {
+			return nil, fmt.Errorf("Cannot decrypt value: the manifest has no encryption settings")
+		}
+		return secretCipher.Decrypt(v)
+	}
+	return value, nil
+}
+
+// restoreRedactedSecrets replaces the masked values of desired parameters with the current values at the same paths,
+// since a redacted credential means the credential is unchanged. It returns the paths of masked values which have no
+// current value.
+func restoreRedactedSecrets(key string, desired interface{}, current interface{}) []string {
+	missing := []string{}
+	switch v := desired.(type) {
+	case map[string]interface{}:
+		for childKey, child := range v {
+			childPath := joinParameterKey(key, childKey)
+			if child == config.MaskedValue {
+				if currentValue, err := queryParameter(current, childPath); err == nil {
+					v[childKey] = currentValue
+					continue
+				}
+				missing = append(missing, childPath)
+				continue
+			}
+			missing = append(missing, restoreRedactedSecrets(childPath, child, current)...)
+		}
+	case []interface{}:
+		for i, child := range v {
+			childPath := fmt.Sprintf("%s[%d]", key, i)
+			if child == config.MaskedValue {
+				if currentValue, err := queryParameter(current, childPath); err == nil {
+					v[i] = currentValue
+					continue
+				}
+				missing = append(missing, childPath)
+				continue
+			}
+			missing = append(missing, restoreRedactedSecrets(childPath, child, current)...)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
@@ -0,0 +1,227 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package instance
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/plugin"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/config"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/httpclient"
+)
+
+// ManifestApplyOptions controls how a manifest is applied.
+type ManifestApplyOptions struct {
+	// DryRun shows the plan of changes without making them.
+	DryRun       bool
+	Timeout      time.Duration
+	PollInterval time.Duration
+}
+
+// manifestChange is the change needed for a service instance to match its declaration in a manifest.
+type manifestChange struct {
+	serviceInstance   ManifestServiceInstance
+	create            bool
+	currentPlan       string
+	parametersJson    string
+	parametersChanged bool
+	parametersDiff    string
+	appsToBind        []string
+}
+
+func (mc *manifestChange) planChanged() bool {
+	return !mc.create && mc.currentPlan != mc.serviceInstance.Plan
+}
+
+func (mc *manifestChange) empty() bool {
+	return !mc.create && !mc.planChanged() && !mc.parametersChanged && len(mc.appsToBind) == 0
+}
+
+// ApplyManifest creates or updates the service instances of the targeted space to match a manifest and binds the apps
+// the manifest declares. It shows the plan of changes before making any. Redacted credentials of existing service
+// instances are left unchanged, service instances missing from the manifest are left alone and apps are not unbound.
+func ApplyManifest(cliConnection plugin.CliConnection, operationRunner OperationRunner, authenticatedClient httpclient.AuthenticatedClient, manifest *Manifest, secretCipher *config.SecretCipher, options ManifestApplyOptions, progressWriter io.Writer) (string, error) {
+	services, err := cliConnection.GetServices()
+	if err != nil {
+		return "", err
+	}
+	existingServices := map[string]int{}
+	for i, service := range services {
+		existingServices[service.Name] = i
+	}
+
+	changes := []*manifestChange{}
+	for _, serviceInstance := range manifest.ServiceInstances {
+		change := &manifestChange{serviceInstance: serviceInstance, create: true}
+		desired, err := decryptSecrets(serviceInstance.Parameters, secretCipher)
+		if err != nil {
+			return "", fmt.Errorf("Service instance %s: %s", serviceInstance.Name, err)
+		}
+		desiredParameters, _ := desired.(map[string]interface{})
+		if desiredParameters == nil {
+			desiredParameters = map[string]interface{}{}
+		}
+
+		var currentParameters map[string]interface{}
+		boundApps := map[string]bool{}
+		if i, ok := existingServices[serviceInstance.Name]; ok {
+			existing := services[i]
+			if existing.Service.Name != serviceInstance.Service {
+				return "", fmt.Errorf("Service instance %s: cannot change its service from %s to %s", serviceInstance.Name, existing.Service.Name, serviceInstance.Service)
+			}
+			change.create = false
+			change.currentPlan = existing.ServicePlan.Name
+			for _, appName := range existing.ApplicationNames {
+				boundApps[appName] = true
+			}
+
+			parameters, err := operationRunner.RunOperation(serviceInstance.Name, NewParametersOperation(authenticatedClient))
+			if err != nil {
+				return "", fmt.Errorf("Service instance %s: %s", serviceInstance.Name, err)
+			}
+			currentParameters, err = decodeParameters(parameters)
+			if err != nil {
+				return "", err
+			}
+		}
+
+		missing := restoreRedactedSecrets("", desiredParameters, currentParameters)
+		if len(missing) > 0 {
+			return "", fmt.Errorf("Service instance %s: %s redacted in the manifest and cannot be copied from the service instance: export the manifest with --encrypt or set the values", serviceInstance.Name, strings.Join(missing, ", "))
+		}
+		err = ValidateParameters(serviceInstance.Service, desiredParameters)
+		if err != nil {
+			return "", fmt.Errorf("Service instance %s: %s", serviceInstance.Name, err)
+		}
+
+		desiredJson, err := json.Marshal(desiredParameters)
+		if err != nil {
+			return "", err
+		}
+		change.parametersJson = string(desiredJson)
+		if !change.create {
+			currentJson, err := json.Marshal(currentParameters)
+			if err != nil {
+				return "", err
+			}
+			differences, err := DiffParameters(serviceInstance.Name, string(currentJson), serviceInstance.Name, change.parametersJson)
+			if err != nil {
+				return "", err
+			}
+			change.parametersChanged = len(differences) > 0
+			if change.parametersChanged {
+				change.parametersDiff = RenderParametersDiff(serviceInstance.Name+" (current)", serviceInstance.Name+" (manifest)", differences, false)
+			}
+		}
+
+		for _, appName := range serviceInstance.BoundApps {
+			if !boundApps[appName] {
+				change.appsToBind = append(change.appsToBind, appName)
+			}
+		}
+		sort.Strings(change.appsToBind)
+		changes = append(changes, change)
+	}
+
+	if !renderManifestPlan(changes, progressWriter) {
+		return "The service instances match the manifest", nil
+	}
+	if options.DryRun {
+		return "Dry run: no service instances were changed", nil
+	}
+
+	created, updated, bound := 0, 0, 0
+	for _, change := range changes {
+		name := change.serviceInstance.Name
+		if change.create {
+			fmt.Fprintf(progressWriter, "Creating service instance %s\n", name)
+			_, err = cliConnection.CliCommandWithoutTerminalOutput("create-service", change.serviceInstance.Service, change.serviceInstance.Plan, name, "-c", change.parametersJson)
+			if err != nil {
+				return "", fmt.Errorf("Error creating service instance %s: %s", name, err)
+			}
+			err = waitForLastOperation(cliConnection, name, "creation", options.Timeout, options.PollInterval, progressWriter)
+			if err != nil {
+				return "", err
+			}
+			created++
+		} else if change.planChanged() || change.parametersChanged {
+			args := []string{"update-service", name}
+			if change.planChanged() {
+				args = append(args, "-p", change.serviceInstance.Plan)
+			}
+			if change.parametersChanged {
+				args = append(args, "-c", change.parametersJson)
+			}
+			fmt.Fprintf(progressWriter, "Updating service instance %s\n", name)
+			_, err = cliConnection.CliCommandWithoutTerminalOutput(args...)
+			if err != nil {
+				return "", fmt.Errorf("Error updating service instance %s: %s", name, err)
+			}
+			err = waitForLastOperation(cliConnection, name, "update", options.Timeout, options.PollInterval, progressWriter)
+			if err != nil {
+				return "", err
+			}
+			updated++
+		}
+
+		for _, appName := range change.appsToBind {
+			fmt.Fprintf(progressWriter, "Binding app %s to %s\n", appName, name)
+			_, err = cliConnection.CliCommandWithoutTerminalOutput("bind-service", appName, name)
+			if err != nil {
+				return "", fmt.Errorf("Error binding app %s to service instance %s: %s", appName, name, err)
+			}
+			bound++
+		}
+	}
+	return fmt.Sprintf("Applied the manifest: created %d and updated %d service instances, and bound %d apps", created, updated, bound), nil
+}
+
+// renderManifestPlan writes the changes needed to apply a manifest and reports whether there are any.
+func renderManifestPlan(changes []*manifestChange, progressWriter io.Writer) bool {
+	changed := false
+	for _, change := range changes {
+		name := change.serviceInstance.Name
+		if change.empty() {
+			fmt.Fprintf(progressWriter, "= %s is up to date\n", name)
+			continue
+		}
+		changed = true
+		if change.create {
+			fmt.Fprintf(progressWriter, "+ create %s (service %s, plan %s)\n", name, change.serviceInstance.Service, change.serviceInstance.Plan)
+		} else if change.planChanged() || change.parametersChanged {
+			fmt.Fprintf(progressWriter, "~ update %s\n", name)
+			if change.planChanged() {
+				fmt.Fprintf(progressWriter, "    plan: %s -> %s\n", change.currentPlan, change.serviceInstance.Plan)
+			}
+			if change.parametersChanged {
+				for _, line := range strings.Split(strings.TrimRight(change.parametersDiff, "\n"), "\n") {
+					fmt.Fprintf(progressWriter, "    %s\n", line)
+				}
+			}
+		}
+		for _, appName := range change.appsToBind {
+			fmt.Fprintf(progressWriter, "+ bind %s to %s\n", appName, name)
+		}
+	}
+	fmt.Fprintln(progressWriter)
+	return changed
+}
@@ -0,0 +1,314 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package instance_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/plugin/models"
+	"code.cloudfoundry.org/cli/plugin/pluginfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/config"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/httpclient/httpclientfakes"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/instance"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/serviceutil"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/serviceutil/serviceutilfakes"
+)
+
+var _ = Describe("Manifest", func() {
+
+	var (
+		fakeCliConnection *pluginfakes.FakeCliConnection
+		fakeAuthClient    *httpclientfakes.FakeAuthenticatedClient
+		operationRunner   instance.OperationRunner
+		parameters        map[string]string
+		services          []plugin_models.GetServices_Model
+	)
+
+	BeforeEach(func() {
+		fakeCliConnection = &pluginfakes.FakeCliConnection{}
+		fakeCliConnection.AccessTokenReturns("bearer someaccesstoken", nil)
+		fakeCliConnection.GetServiceReturns(plugin_models.GetService_Model{LastOperation: plugin_models.GetService_LastOperation{State: "succeeded"}}, nil)
+		services = []plugin_models.GetServices_Model{
+			{
+				Name:             "registry",
+				Service:          plugin_models.GetServices_ServiceFields{Name: "p.service-registry"},
+				ServicePlan:      plugin_models.GetServices_ServicePlan{Name: "standard"},
+				ApplicationNames: []string{"orders"},
+			},
+			{
+				Name:        "mysql",
+				Service:     plugin_models.GetServices_ServiceFields{Name: "p.mysql"},
+				ServicePlan: plugin_models.GetServices_ServicePlan{Name: "db-small"},
+			},
+			{
+				Name:             "config-server",
+				Service:          plugin_models.GetServices_ServiceFields{Name: "p.config-server"},
+				ServicePlan:      plugin_models.GetServices_ServicePlan{Name: "standard"},
+				ApplicationNames: []string{"orders", "billing"},
+			},
+		}
+		fakeCliConnection.GetServicesStub = func() ([]plugin_models.GetServices_Model, error) {
+			return services, nil
+		}
+
+		parameters = map[string]string{
+			"https://broker/cli/instances/config-server/parameters": `{"count": 1, "git": {"uri": "https://github.com/org/config", "password": "s3cret"}}`,
+			"https://broker/cli/instances/registry/parameters":      `{"count": 2}`,
+		}
+		fakeAuthClient = &httpclientfakes.FakeAuthenticatedClient{}
+		fakeAuthClient.DoAuthenticatedGetStub = func(url string, accessToken string) (io.ReadCloser, int, error) {
+			return ioutil.NopCloser(strings.NewReader(parameters[url])), http.StatusOK, nil
+		}
+
+		fakeResolver := &serviceutilfakes.FakeServiceInstanceResolver{}
+		fakeResolver.GetManagementParametersStub = func(name string, accessToken string, lifecycleOperation bool) (serviceutil.ManagementParameters, error) {
+			return serviceutil.ManagementParameters{Url: "https://broker/cli/instances/" + name}, nil
+		}
+		operationRunner = instance.NewAuthenticatedOperationRunner(fakeCliConnection, fakeResolver)
+	})
+
+	Describe("ExportManifest", func() {
+		It("exports the SCS service instances with redacted credentials", func() {
+			manifest, err := instance.ExportManifest(fakeCliConnection, operationRunner, fakeAuthClient, nil)
+			Expect(err).NotTo(HaveOccurred())
+			contents, err := instance.RenderManifest(manifest)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(contents).To(Equal(`service_instances:
+    - name: config-server
+      service: p.config-server
+      plan: standard
+      parameters:
+        count: 1
+        git:
+            password: '******'
+            uri: https://github.com/org/config
+      bound_apps:
+        - billing
+        - orders
+    - name: registry
+      service: p.service-registry
+      plan: standard
+      parameters:
+        count: 2
+      bound_apps:
+        - orders
+`))
+		})
+
+		It("encrypts credentials with a cipher", func() {
+			secretCipher, err := config.OpenSecretCipher("passphrase", "pbkdf2-sha256", []byte("0123456789abcdef"), 1000)
+			Expect(err).NotTo(HaveOccurred())
+
+			manifest, err := instance.ExportManifest(fakeCliConnection, operationRunner, fakeAuthClient, secretCipher)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(manifest.Encryption).To(Equal(&instance.ManifestEncryption{Kdf: "pbkdf2-sha256", Iterations: 1000, Salt: "MDEyMzQ1Njc4OWFiY2RlZg=="}))
+			password := manifest.ServiceInstances[0].Parameters["git"].(map[string]interface{})["password"].(string)
+			Expect(config.IsEncryptedValue(password)).To(BeTrue())
+			Expect(secretCipher.Decrypt(password)).To(Equal("s3cret"))
+		})
+	})
+
+	Describe("ReadManifest", func() {
+		var fileName string
+
+		BeforeEach(func() {
+			dir, err := ioutil.TempDir("", "manifest")
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(os.RemoveAll, dir)
+			fileName = filepath.Join(dir, "scs.yml")
+		})
+
+		It("reads service instances with their parameters", func() {
+			Expect(ioutil.WriteFile(fileName, []byte("service_instances:\n- name: config-server\n  service: p.config-server\n  plan: standard\n  parameters:\n    count: 2\n"), 0600)).To(Succeed())
+			manifest, err := instance.ReadManifest(fileName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(manifest.ServiceInstances).To(Equal([]instance.ManifestServiceInstance{
+				{Name: "config-server", Service: "p.config-server", Plan: "standard", Parameters: map[string]interface{}{"count": int64(2)}},
+			}))
+		})
+
+		It("rejects a service instance without a plan", func() {
+			Expect(ioutil.WriteFile(fileName, []byte("service_instances:\n- name: config-server\n  service: p.config-server\n"), 0600)).To(Succeed())
+			_, err := instance.ReadManifest(fileName)
+			Expect(err).To(MatchError("Invalid manifest " + fileName + ": service instance 1 must have a name, service and plan"))
+		})
+
+		It("rejects a service instance declared twice", func() {
+			Expect(ioutil.WriteFile(fileName, []byte("service_instances:\n- {name: a, service: p.config-server, plan: standard}\n- {name: a, service: p.config-server, plan: standard}\n"), 0600)).To(Succeed())
+			_, err := instance.ReadManifest(fileName)
+			Expect(err).To(MatchError("Invalid manifest " + fileName + ": service instance a is declared more than once"))
+		})
+	})
+
+	Describe("ApplyManifest", func() {
+		var (
+			manifest     *instance.Manifest
+			secretCipher *config.SecretCipher
+			options      instance.ManifestApplyOptions
+			progress     *bytes.Buffer
+			output       string
+			err          error
+		)
+
+		BeforeEach(func() {
+			manifest = &instance.Manifest{ServiceInstances: []instance.ManifestServiceInstance{
+				{
+					Name:       "config-server",
+					Service:    "p.config-server",
+					Plan:       "standard",
+					Parameters: map[string]interface{}{"count": int64(2), "git": map[string]interface{}{"uri": "https://github.com/org/config", "password": config.MaskedValue}},
+					BoundApps:  []string{"billing", "orders"},
+				},
+				{
+					Name:       "registry",
+					Service:    "p.service-registry",
+					Plan:       "standard",
+					Parameters: map[string]interface{}{"count": int64(2)},
+					BoundApps:  []string{"orders", "shipping"},
+				},
+				{
+					Name:       "config-server-feature",
+					Service:    "p.config-server",
+					Plan:       "standard",
+					Parameters: map[string]interface{}{"git": map[string]interface{}{"uri": "https://github.com/org/config"}},
+				},
+			}}
+			secretCipher = nil
+			options = instance.ManifestApplyOptions{Timeout: time.Second, PollInterval: time.Millisecond}
+			progress = &bytes.Buffer{}
+		})
+
+		JustBeforeEach(func() {
+			output, err = instance.ApplyManifest(fakeCliConnection, operationRunner, fakeAuthClient, manifest, secretCipher, options, progress)
+		})
+
+		It("shows the plan and then creates, updates and binds service instances to match the manifest", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output).To(Equal("Applied the manifest: created 1 and updated 1 service instances, and bound 1 apps"))
+			Expect(progress.String()).To(HavePrefix("~ update config-server\n    from: config-server (current)\n"))
+			Expect(progress.String()).To(ContainSubstring("+ bind shipping to registry\n+ create config-server-feature (service p.config-server, plan standard)\n\n"))
+			Expect(progress.String()).NotTo(ContainSubstring("s3cret"))
+
+			Expect(fakeCliConnection.CliCommandWithoutTerminalOutputCallCount()).To(Equal(3))
+			args := fakeCliConnection.CliCommandWithoutTerminalOutputArgsForCall(0)
+			Expect(args[:3]).To(Equal([]string{"update-service", "config-server", "-c"}))
+			Expect(args[3]).To(MatchJSON(`{"count": 2, "git": {"uri": "https://github.com/org/config", "password": "s3cret"}}`))
+			Expect(fakeCliConnection.CliCommandWithoutTerminalOutputArgsForCall(1)).To(Equal([]string{"bind-service", "shipping", "registry"}))
+			args = fakeCliConnection.CliCommandWithoutTerminalOutputArgsForCall(2)
+			Expect(args[:5]).To(Equal([]string{"create-service", "p.config-server", "standard", "config-server-feature", "-c"}))
+			Expect(args[5]).To(MatchJSON(`{"git": {"uri": "https://github.com/org/config"}}`))
+		})
+
+		Context("when it is a dry run", func() {
+			BeforeEach(func() {
+				options.DryRun = true
+			})
+
+			It("shows the plan without making changes", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(output).To(Equal("Dry run: no service instances were changed"))
+				Expect(progress.String()).To(ContainSubstring("+ create config-server-feature"))
+				Expect(fakeCliConnection.CliCommandWithoutTerminalOutputCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the service instances match the manifest", func() {
+			BeforeEach(func() {
+				manifest.ServiceInstances = manifest.ServiceInstances[1:2]
+				manifest.ServiceInstances[0].BoundApps = []string{"orders"}
+			})
+
+			It("makes no changes", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(output).To(Equal("The service instances match the manifest"))
+				Expect(progress.String()).To(Equal("= registry is up to date\n\n"))
+				Expect(fakeCliConnection.CliCommandWithoutTerminalOutputCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the plan of a service instance changes", func() {
+			BeforeEach(func() {
+				manifest.ServiceInstances = manifest.ServiceInstances[1:2]
+				manifest.ServiceInstances[0].Plan = "large"
+				manifest.ServiceInstances[0].BoundApps = nil
+			})
+
+			It("updates the plan alone", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(progress.String()).To(HavePrefix("~ update registry\n    plan: standard -> large\n\n"))
+				Expect(fakeCliConnection.CliCommandWithoutTerminalOutputArgsForCall(0)).To(Equal([]string{"update-service", "registry", "-p", "large"}))
+			})
+		})
+
+		Context("when credentials are encrypted", func() {
+			BeforeEach(func() {
+				secretCipher, err = config.OpenSecretCipher("passphrase", "pbkdf2-sha256", []byte("0123456789abcdef"), 1000)
+				Expect(err).NotTo(HaveOccurred())
+				encrypted, err := secretCipher.Encrypt("n3w-s3cret")
+				Expect(err).NotTo(HaveOccurred())
+				manifest.ServiceInstances = manifest.ServiceInstances[2:]
+				manifest.ServiceInstances[0].Parameters["git"].(map[string]interface{})["password"] = encrypted
+			})
+
+			It("creates the service instance with the decrypted credentials", func() {
+				Expect(err).NotTo(HaveOccurred())
+				args := fakeCliConnection.CliCommandWithoutTerminalOutputArgsForCall(0)
+				Expect(args[5]).To(MatchJSON(`{"git": {"uri": "https://github.com/org/config", "password": "n3w-s3cret"}}`))
+			})
+		})
+
+		Context("when a new service instance has redacted credentials", func() {
+			BeforeEach(func() {
+				manifest.ServiceInstances[2].Parameters["git"].(map[string]interface{})["password"] = config.MaskedValue
+			})
+
+			It("makes no changes", func() {
+				Expect(err).To(MatchError("Service instance config-server-feature: git.password redacted in the manifest and cannot be copied from the service instance: export the manifest with --encrypt or set the values"))
+				Expect(fakeCliConnection.CliCommandWithoutTerminalOutputCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the service of a service instance changes", func() {
+			BeforeEach(func() {
+				manifest.ServiceInstances[1].Service = "p.config-server"
+			})
+
+			It("returns a suitable error", func() {
+				Expect(err).To(MatchError("Service instance registry: cannot change its service from p.service-registry to p.config-server"))
+			})
+		})
+
+		Context("when the parameters are invalid", func() {
+			BeforeEach(func() {
+				manifest.ServiceInstances[2].Parameters["git"].(map[string]interface{})["uri"] = "github.com/org/config"
+			})
+
+			It("returns a suitable error", func() {
+				Expect(err).To(MatchError(HavePrefix("Service instance config-server-feature: Invalid configuration parameters:\n  git.uri github.com/org/config is not a Git repository URI")))
+			})
+		})
+	})
+})
@@ -17,8 +17,11 @@
 package instance
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/config"
 	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/httpclient"
 	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/serviceutil"
 	"io/ioutil"
@@ -60,3 +63,49 @@ func NewParametersOperation(authenticatedClient httpclient.AuthenticatedClient)
 		authenticatedClient: authenticatedClient,
 	}
 }
+
+// DiffParameters compares two sets of service instance configuration parameters, as displayed by
+// spring-cloud-service-configuration --output json, with nested parameters flattened, e.g. "git.uri". Each set is
+// named after the service instance or file it came from. A masked value is not reported as changed.
+func DiffParameters(fromName string, fromParameters string, toName string, toParameters string) ([]config.PropertyDifference, error) {
+	from, err := parametersEnvironment(fromName, fromParameters)
+	if err != nil {
+		return nil, err
+	}
+	to, err := parametersEnvironment(toName, toParameters)
+	if err != nil {
+		return nil, err
+	}
+
+	differences := []config.PropertyDifference{}
+	for _, difference := range config.DiffEnvironments(from, to) {
+		if difference.Change == config.Changed && (difference.From.Value == config.MaskedValue || difference.To.Value == config.MaskedValue) {
+			continue
+		}
+		differences = append(differences, difference)
+	}
+	return differences, nil
+}
+
+// RenderParametersDiff renders the differences between two sets of configuration parameters.
+func RenderParametersDiff(from string, to string, differences []config.PropertyDifference, reveal bool) string {
+	header := fmt.Sprintf("from: %s\nto:   %s\n\n", from, to)
+	if len(differences) == 0 {
+		return header + "No differences found\n"
+	}
+
+	return header + config.RenderPropertyDifferences(differences, reveal)
+}
+
+// parametersEnvironment holds the flattened parameters in a single property source so that they can be compared like
+// the environments served by a config server.
+func parametersEnvironment(name string, parameters string) (*config.Environment, error) {
+	var document map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(parameters)))
+	decoder.UseNumber()
+	err := decoder.Decode(&document)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid configuration parameters of %s: %s", name, err)
+	}
+	return &config.Environment{PropertySources: []config.PropertySource{{Name: name, Source: flattenParameters("", document)}}}, nil
+}
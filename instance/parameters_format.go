@@ -0,0 +1,322 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package instance
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/config"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/httpclient"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/serviceutil"
+	"go.yaml.in/yaml/v3"
+)
+
+const generalSection = "general"
+
+// The titles of the sections of the configuration parameters of the backends of a config server.
+var parameterSections = map[string]string{
+	"git":       "Git repository",
+	"vault":     "Vault",
+	"credhub":   "CredHub",
+	"composite": "Composite backend",
+}
+
+var querySegmentPattern = regexp.MustCompile(`^([^\[\]]*)((?:\[\d+\])*)$`)
+
+type formattedParametersOperation struct {
+	parametersOperation Operation
+	outputFormat        string
+	query               string
+	reveal              bool
+}
+
+// NewFormattedParametersOperation returns an operation which renders the configuration parameters of a service
+// instance, with credentials masked unless reveal is set. Without an output format, the parameters are grouped into
+// sections for people to read. If query is not empty, only the parameter at that path, such as git.uri or
+// composite[0].type, is rendered.
+func NewFormattedParametersOperation(authenticatedClient httpclient.AuthenticatedClient, outputFormat string, query string, reveal bool) Operation {
+	return &formattedParametersOperation{
+		parametersOperation: NewParametersOperation(authenticatedClient),
+		outputFormat:        outputFormat,
+		query:               query,
+		reveal:              reveal,
+	}
+}
+
+func (fpo *formattedParametersOperation) Run(serviceInstanceManagementParameters serviceutil.ManagementParameters, accessToken string) (string, error) {
+	parameters, err := fpo.parametersOperation.Run(serviceInstanceManagementParameters, accessToken)
+	if err != nil {
+		return "", err
+	}
+	return RenderParameters(parameters, fpo.outputFormat, fpo.query, fpo.reveal)
+}
+
+func (fpo *formattedParametersOperation) IsServiceBrokerOperation() bool {
+	return fpo.parametersOperation.IsServiceBrokerOperation()
+}
+
+// RenderParameters renders configuration parameters, as returned by the service broker, in the given output format,
+// json or yaml, or grouped into sections if the format is empty.
+func RenderParameters(parameters string, outputFormat string, query string, reveal bool) (string, error) {
+	var document interface{}
+	decoder := json.NewDecoder(strings.NewReader(parameters))
+	decoder.UseNumber()
+	err := decoder.Decode(&document)
+	if err != nil {
+		return "", fmt.Errorf("Invalid service instance configuration response JSON: %s, response body: '%s'", err, parameters)
+	}
+	document = normaliseParameter("", document, reveal)
+
+	if query != "" {
+		document, err = queryParameter(document, query)
+		if err != nil {
+			return "", err
+		}
+		switch document.(type) {
+		case map[string]interface{}, []interface{}:
+		default:
+			return parameterString(document), nil
+		}
+	}
+
+	switch outputFormat {
+	case "":
+		if query != "" {
+			return renderParameterSections(query, document), nil
+		}
+		return renderParameterSections(generalSection, document), nil
+	case JsonOutputFormat:
+		contents, err := json.MarshalIndent(document, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(contents), nil
+	case YamlOutputFormat:
+		contents, err := yaml.Marshal(document)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(string(contents), "\n"), nil
+	default:
+		return "", fmt.Errorf("Unsupported output format '%s': use json or yaml", outputFormat)
+	}
+}
+
+// normaliseParameter converts JSON numbers to integers or floats, so that they render the same in every format, and
+// masks the values of credentials.
+func normaliseParameter(key string, value interface{}, reveal bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for childKey, child := range v {
+			v[childKey] = normaliseParameter(joinParameterKey(key, childKey), child, reveal)
+		}
+		return v
+	case []interface{}:
+		for i, child := range v {
+			v[i] = normaliseParameter(fmt.Sprintf("%s[%d]", key, i), child, reveal)
+		}
+		return v
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			value = i
+		} else if f, err := v.Float64(); err == nil {
+			value = f
+		}
+	}
+
+	if value != nil && config.DisplayValue(config.Property{Key: key, Value: parameterString(value)}, reveal) == config.MaskedValue {
+		return config.MaskedValue
+	}
+	return value
+}
+
+func joinParameterKey(prefix string, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+func parameterString(value interface{}) string {
+	if value == nil {
+		return "null"
+	}
+	return fmt.Sprint(value)
+}
+
+// queryParameter selects the parameter at a path of keys separated by "." with optional array indices, such as
+// composite[0].uri.
+func queryParameter(document interface{}, query string) (interface{}, error) {
+	notFound := fmt.Errorf("No configuration parameter found at '%s'", query)
+	current := document
+	for _, segment := range strings.Split(query, ".") {
+		match := querySegmentPattern.FindStringSubmatch(segment)
+		if match == nil {
+			return nil, fmt.Errorf("Invalid query '%s': expected keys separated by '.' with optional [INDEX] suffixes", query)
+		}
+
+		if match[1] != "" {
+			object, ok := current.(map[string]interface{})
+			if !ok {
+				return nil, notFound
+			}
+			current, ok = object[match[1]]
+			if !ok {
+				return nil, notFound
+			}
+		}
+
+		for _, index := range strings.FieldsFunc(match[2], func(r rune) bool { return r == '[' || r == ']' }) {
+			array, ok := current.([]interface{})
+			if !ok {
+				return nil, notFound
+			}
+			i, _ := strconv.Atoi(index)
+			if i >= len(array) {
+				return nil, notFound
+			}
+			current = array[i]
+		}
+	}
+	return current, nil
+}
+
+// renderParameterSections renders the scalar parameters at the top level in a general section followed by a section
+// for each object, such as the Git repository, and for each element of an array, such as a composite backend.
+func renderParameterSections(generalTitle string, document interface{}) string {
+	object, ok := document.(map[string]interface{})
+	if !ok {
+		object = map[string]interface{}{"": document}
+	}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	general := map[string]interface{}{}
+	sections := []string{}
+	sectionProperties := map[string]map[string]interface{}{}
+	for _, key := range keys {
+		switch value := object[key].(type) {
+		case map[string]interface{}:
+			title := sectionTitle(key, key)
+			sections = append(sections, title)
+			sectionProperties[title] = flattenParameters("", value)
+		case []interface{}:
+			if len(value) == 0 {
+				general[key] = "[]"
+				break
+			}
+			if !allObjects(value) {
+				for childKey, childValue := range flattenParameters(key, value) {
+					general[childKey] = childValue
+				}
+				break
+			}
+			for i, element := range value {
+				path := fmt.Sprintf("%s[%d]", key, i)
+				if backendType, ok := element.(map[string]interface{})["type"].(string); ok {
+					path = fmt.Sprintf("%s: %s", path, backendType)
+				}
+				title := sectionTitle(key, path)
+				sections = append(sections, title)
+				sectionProperties[title] = flattenParameters("", element)
+			}
+		default:
+			general[key] = value
+		}
+	}
+
+	var buffer bytes.Buffer
+	if len(general) > 0 {
+		buffer.WriteString(renderParameterSection(generalTitle, general))
+	}
+	for _, title := range sections {
+		if buffer.Len() > 0 {
+			buffer.WriteString("\n")
+		}
+		buffer.WriteString(renderParameterSection(title, sectionProperties[title]))
+	}
+	if buffer.Len() == 0 {
+		return "No configuration parameters"
+	}
+	return strings.TrimSuffix(buffer.String(), "\n")
+}
+
+func sectionTitle(key string, path string) string {
+	if title, ok := parameterSections[key]; ok {
+		return fmt.Sprintf("%s (%s)", title, path)
+	}
+	return path
+}
+
+func allObjects(array []interface{}) bool {
+	for _, element := range array {
+		if _, ok := element.(map[string]interface{}); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func flattenParameters(prefix string, value interface{}) map[string]interface{} {
+	properties := map[string]interface{}{}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			for childKey, childValue := range flattenParameters(joinParameterKey(prefix, key), child) {
+				properties[childKey] = childValue
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			for childKey, childValue := range flattenParameters(fmt.Sprintf("%s[%d]", prefix, i), child) {
+				properties[childKey] = childValue
+			}
+		}
+	default:
+		properties[prefix] = v
+	}
+	return properties
+}
+
+func renderParameterSection(title string, properties map[string]interface{}) string {
+	keys := make([]string, 0, len(properties))
+	width := 0
+	for key := range properties {
+		keys = append(keys, key)
+		if len(key) > width {
+			width = len(key)
+		}
+	}
+	sort.Strings(keys)
+
+	var buffer bytes.Buffer
+	buffer.WriteString(title + ":\n")
+	for _, key := range keys {
+		buffer.WriteString(fmt.Sprintf("  %-*s %s\n", width+1, key+":", parameterString(properties[key])))
+	}
+	return buffer.String()
+}
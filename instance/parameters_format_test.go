@@ -0,0 +1,210 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package instance_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/httpclient/httpclientfakes"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/instance"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/serviceutil"
+)
+
+var _ = Describe("RenderParameters", func() {
+
+	const parameters = `{
+		"count": 2,
+		"git": {"uri": "https://github.com/org/config", "label": "main", "username": "user", "password": "s3cret"},
+		"composite": [
+			{"type": "vault", "host": "vault.example.com", "port": 8200, "token": "t0ken"},
+			{"type": "credhub"}
+		],
+		"application-security-groups": ["asg-1", "asg-2"]
+	}`
+
+	var (
+		outputFormat string
+		query        string
+		reveal       bool
+		output       string
+		err          error
+	)
+
+	BeforeEach(func() {
+		outputFormat = ""
+		query = ""
+		reveal = false
+	})
+
+	JustBeforeEach(func() {
+		output, err = instance.RenderParameters(parameters, outputFormat, query, reveal)
+	})
+
+	It("groups the parameters into sections and masks credentials", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output).To(Equal(`general:
+  application-security-groups[0]: asg-1
+  application-security-groups[1]: asg-2
+  count:                          2
+
+Composite backend (composite[0]: vault):
+  host:  vault.example.com
+  port:  8200
+  token: ******
+  type:  vault
+
+Composite backend (composite[1]: credhub):
+  type: credhub
+
+Git repository (git):
+  label:    main
+  password: ******
+  uri:      https://github.com/org/config
+  username: user`))
+	})
+
+	Context("when credentials are revealed", func() {
+		BeforeEach(func() {
+			reveal = true
+		})
+
+		It("shows the credentials", func() {
+			Expect(output).To(ContainSubstring("  password: s3cret\n"))
+			Expect(output).To(ContainSubstring("  token: t0ken\n"))
+		})
+	})
+
+	Context("when the format is json", func() {
+		BeforeEach(func() {
+			outputFormat = instance.JsonOutputFormat
+		})
+
+		It("renders the masked parameters as JSON", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output).To(MatchJSON(`{
+				"count": 2,
+				"git": {"uri": "https://github.com/org/config", "label": "main", "username": "user", "password": "******"},
+				"composite": [
+					{"type": "vault", "host": "vault.example.com", "port": 8200, "token": "******"},
+					{"type": "credhub"}
+				],
+				"application-security-groups": ["asg-1", "asg-2"]
+			}`))
+		})
+	})
+
+	Context("when the format is yaml", func() {
+		BeforeEach(func() {
+			outputFormat = instance.YamlOutputFormat
+		})
+
+		It("renders the masked parameters as YAML", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output).To(ContainSubstring("count: 2\n"))
+			Expect(output).To(ContainSubstring("git:\n    label: main\n    password: '******'\n"))
+		})
+	})
+
+	Context("when the format is not supported", func() {
+		BeforeEach(func() {
+			outputFormat = "xml"
+		})
+
+		It("returns a suitable error", func() {
+			Expect(err).To(MatchError("Unsupported output format 'xml': use json or yaml"))
+		})
+	})
+
+	Context("when querying a single value", func() {
+		BeforeEach(func() {
+			query = "composite[0].port"
+		})
+
+		It("prints the value alone", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output).To(Equal("8200"))
+		})
+	})
+
+	Context("when querying a credential", func() {
+		BeforeEach(func() {
+			query = "git.password"
+		})
+
+		It("masks the value", func() {
+			Expect(output).To(Equal("******"))
+		})
+	})
+
+	Context("when querying an object", func() {
+		BeforeEach(func() {
+			query = "git"
+			outputFormat = instance.JsonOutputFormat
+		})
+
+		It("renders the object in the output format", func() {
+			Expect(output).To(MatchJSON(`{"uri": "https://github.com/org/config", "label": "main", "username": "user", "password": "******"}`))
+		})
+	})
+
+	Context("when nothing matches the query", func() {
+		BeforeEach(func() {
+			query = "composite[2].type"
+		})
+
+		It("returns a suitable error", func() {
+			Expect(err).To(MatchError("No configuration parameter found at 'composite[2].type'"))
+		})
+	})
+
+	Context("when the query is invalid", func() {
+		BeforeEach(func() {
+			query = "composite[x]"
+		})
+
+		It("returns a suitable error", func() {
+			Expect(err).To(MatchError("Invalid query 'composite[x]': expected keys separated by '.' with optional [INDEX] suffixes"))
+		})
+	})
+})
+
+var _ = Describe("FormattedParametersOperation", func() {
+	It("renders the parameters fetched from the service broker", func() {
+		fakeAuthClient := &httpclientfakes.FakeAuthenticatedClient{}
+		fakeAuthClient.DoAuthenticatedGetReturns(ioutil.NopCloser(bytes.NewBufferString(`{"count": 1}`)), http.StatusOK, nil)
+
+		operation := instance.NewFormattedParametersOperation(fakeAuthClient, "", "", false)
+		Expect(operation.IsServiceBrokerOperation()).To(BeFalse())
+		output, err := operation.Run(serviceutil.ManagementParameters{Url: "https://servicebroker.host/cli/instances/si-guid"}, "someaccesstoken")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output).To(Equal("general:\n  count: 1"))
+		url, _ := fakeAuthClient.DoAuthenticatedGetArgsForCall(0)
+		Expect(url).To(Equal("https://servicebroker.host/cli/instances/si-guid/parameters"))
+	})
+
+	It("reports a response which is not JSON", func() {
+		fakeAuthClient := &httpclientfakes.FakeAuthenticatedClient{}
+		fakeAuthClient.DoAuthenticatedGetReturns(ioutil.NopCloser(bytes.NewBufferString(`{`)), http.StatusOK, nil)
+
+		_, err := instance.NewFormattedParametersOperation(fakeAuthClient, "", "", false).Run(serviceutil.ManagementParameters{}, "someaccesstoken")
+		Expect(err).To(MatchError("Invalid service instance configuration response JSON: unexpected EOF, response body: '{'"))
+	})
+})
@@ -102,3 +102,30 @@ var _ = Describe("Parameters", func() {
 		})
 	})
 })
+
+var _ = Describe("DiffParameters", func() {
+	It("does not report masked values as changed", func() {
+		differences, err := instance.DiffParameters(
+			"config-server", `{"git": {"uri": "https://github.com/org/config.git", "password": "s3cret", "searchPaths": ["app*", "shared"]}}`,
+			"params.json", `{"git": {"uri": "https://github.com/org/config.git", "password": "******", "searchPaths": ["app*"]}}`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(trimLines(instance.RenderParametersDiff("config-server", "params.json", differences, false))).To(Equal(`from: config-server
+to:   params.json
+
+property           change  from   to
+git.searchPaths[1] removed shared
+`))
+	})
+
+	It("renders the absence of differences", func() {
+		differences, err := instance.DiffParameters("staging-config", `{"count": 1}`, "prod-config", `{"count": 1}`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(instance.RenderParametersDiff("staging-config", "prod-config", differences, false)).To(Equal("from: staging-config\nto:   prod-config\n\nNo differences found\n"))
+	})
+
+	It("rejects invalid parameters", func() {
+		_, err := instance.DiffParameters("old-config", "not json", "new-config", "{}")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(HavePrefix("Invalid configuration parameters of old-config: "))
+	})
+})
@@ -0,0 +1,451 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package instance
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/plugin"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/cfutil"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/config"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/httpclient"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/serviceutil"
+	"go.yaml.in/yaml/v3"
+)
+
+const (
+	lastOperationInProgress = "in progress"
+	lastOperationFailed     = "failed"
+)
+
+// The backends which may be combined in the composite parameter of a config server.
+var compositeBackendTypes = map[string]bool{
+	"git":     true,
+	"vault":   true,
+	"credhub": true,
+}
+
+var scpLikeGitUriPattern = regexp.MustCompile(`^[\w.-]+@[\w.-]+:.+$`)
+
+// ParametersUpdate describes changes to the configuration parameters of a service instance: parameters read from a
+// file, which are merged into the current parameters, followed by edits of the form key.path=value.
+type ParametersUpdate struct {
+	Changes map[string]interface{}
+	Edits   []string
+	DryRun  bool
+	Reveal  bool
+}
+
+type updateParametersOperation struct {
+	cliConnection       plugin.CliConnection
+	parametersOperation Operation
+	serviceInstanceName string
+	update              ParametersUpdate
+	timeout             time.Duration
+	pollInterval        time.Duration
+	progressWriter      io.Writer
+}
+
+// NewUpdateParametersOperation returns an operation which applies changes to the current configuration parameters of
+// a service instance, shows the differences, validates the result for the type of service instance and then, unless
+// it is a dry run, updates the service instance and waits for the update to finish.
+func NewUpdateParametersOperation(cliConnection plugin.CliConnection, authenticatedClient httpclient.AuthenticatedClient, serviceInstanceName string, update ParametersUpdate, timeout time.Duration, pollInterval time.Duration, progressWriter io.Writer) Operation {
+	return &updateParametersOperation{
+		cliConnection:       cliConnection,
+		parametersOperation: NewParametersOperation(authenticatedClient),
+		serviceInstanceName: serviceInstanceName,
+		update:              update,
+		timeout:             timeout,
+		pollInterval:        pollInterval,
+		progressWriter:      progressWriter,
+	}
+}
+
+func (upo *updateParametersOperation) Run(serviceInstanceManagementParameters serviceutil.ManagementParameters, accessToken string) (string, error) {
+	currentParameters, err := upo.parametersOperation.Run(serviceInstanceManagementParameters, accessToken)
+	if err != nil {
+		return "", err
+	}
+	current, err := decodeParameters(currentParameters)
+	if err != nil {
+		return "", err
+	}
+	updated, err := decodeParameters(currentParameters)
+	if err != nil {
+		return "", err
+	}
+
+	MergeParameters(updated, upo.update.Changes)
+	for _, edit := range upo.update.Edits {
+		err = EditParameter(updated, edit)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	currentJson, err := json.Marshal(current)
+	if err != nil {
+		return "", err
+	}
+	updatedJson, err := json.Marshal(updated)
+	if err != nil {
+		return "", err
+	}
+	differences, err := DiffParameters("current", string(currentJson), "updated", string(updatedJson))
+	if err != nil {
+		return "", err
+	}
+	if len(differences) == 0 {
+		return fmt.Sprintf("The configuration parameters of %s are unchanged", upo.serviceInstanceName), nil
+	}
+	fmt.Fprint(upo.progressWriter, RenderParametersDiff(upo.serviceInstanceName+" (current)", upo.serviceInstanceName+" (updated)", differences, upo.update.Reveal))
+	fmt.Fprintln(upo.progressWriter)
+
+	err = ValidateParameters(serviceInstanceManagementParameters.ServiceOfferingName, updated)
+	if err != nil {
+		return "", err
+	}
+	if upo.update.DryRun {
+		return fmt.Sprintf("Dry run: the configuration parameters of %s were not updated", upo.serviceInstanceName), nil
+	}
+
+	fmt.Fprintf(upo.progressWriter, "Updating service instance %s\n", upo.serviceInstanceName)
+	_, err = upo.cliConnection.CliCommandWithoutTerminalOutput("update-service", upo.serviceInstanceName, "-c", string(updatedJson))
+	if err != nil {
+		return "", fmt.Errorf("Error updating service instance %s: %s", upo.serviceInstanceName, err)
+	}
+	err = waitForLastOperation(upo.cliConnection, upo.serviceInstanceName, "update", upo.timeout, upo.pollInterval, upo.progressWriter)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Updated the configuration parameters of %s", upo.serviceInstanceName), nil
+}
+
+func (upo *updateParametersOperation) IsServiceBrokerOperation() bool {
+	return upo.parametersOperation.IsServiceBrokerOperation()
+}
+
+// waitForLastOperation polls the last operation of a service instance, such as its creation or an update, until the
+// service broker has finished it.
+func waitForLastOperation(cliConnection plugin.CliConnection, serviceInstanceName string, description string, timeout time.Duration, pollInterval time.Duration, progressWriter io.Writer) error {
+	fmt.Fprintf(progressWriter, "Waiting for the %s of %s to finish\n", description, serviceInstanceName)
+	deadline := time.Now().Add(timeout)
+	for {
+		// Fetching the access token refreshes it once it expires, so that a long wait does not fail part way through.
+		_, err := cfutil.GetToken(cliConnection)
+		if err != nil {
+			return err
+		}
+		serviceModel, err := cliConnection.GetService(serviceInstanceName)
+		if err != nil {
+			return fmt.Errorf("Service instance not found: %s", err)
+		}
+
+		lastOperation := serviceModel.LastOperation
+		switch lastOperation.State {
+		case lastOperationInProgress:
+		case lastOperationFailed:
+			return fmt.Errorf("The %s of service instance %s failed: %s", description, serviceInstanceName, lastOperation.Description)
+		default:
+			return nil
+		}
+
+		if !time.Now().Add(pollInterval).Before(deadline) {
+			return fmt.Errorf("timed out after %s waiting for the %s of %s to finish", timeout, description, serviceInstanceName)
+		}
+		fmt.Fprintf(progressWriter, "The %s of %s is %s\n", description, serviceInstanceName, lastOperation.State)
+		time.Sleep(pollInterval)
+	}
+}
+
+// ReadParametersFile reads configuration parameters from a YAML or JSON file.
+func ReadParametersFile(fileName string) (map[string]interface{}, error) {
+	contents, err := config.ReadFileContents(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	var parameters map[string]interface{}
+	err = yaml.Unmarshal([]byte(contents), &parameters)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing file at path %s : %s", fileName, err)
+	}
+
+	// Round trip through JSON so that the parameters have the same types as those fetched from the service broker.
+	parametersJson, err := json.Marshal(parameters)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing file at path %s : %s", fileName, err)
+	}
+	return decodeParameters(string(parametersJson))
+}
+
+func decodeParameters(parameters string) (map[string]interface{}, error) {
+	var document map[string]interface{}
+	decoder := json.NewDecoder(strings.NewReader(parameters))
+	decoder.UseNumber()
+	err := decoder.Decode(&document)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid service instance configuration response JSON: %s, response body: '%s'", err, parameters)
+	}
+	if document == nil {
+		document = map[string]interface{}{}
+	}
+	return normaliseParameter("", document, true).(map[string]interface{}), nil
+}
+
+// MergeParameters merges changes into parameters: objects are merged key by key, while arrays and other values are
+// replaced, and a null value removes a parameter.
+func MergeParameters(parameters map[string]interface{}, changes map[string]interface{}) {
+	for key, change := range changes {
+		if change == nil {
+			delete(parameters, key)
+			continue
+		}
+		changeObject, changeIsObject := change.(map[string]interface{})
+		existingObject, existingIsObject := parameters[key].(map[string]interface{})
+		if changeIsObject && existingIsObject {
+			MergeParameters(existingObject, changeObject)
+			continue
+		}
+		parameters[key] = change
+	}
+}
+
+// EditParameter applies an edit of the form key.path=value, such as git.label=main or composite[1].uri=URI, to
+// parameters. The value is parsed as JSON if possible, so that numbers, booleans, arrays and objects can be given, and
+// is otherwise a string. A null value removes the parameter.
+func EditParameter(parameters map[string]interface{}, edit string) error {
+	separator := strings.Index(edit, "=")
+	if separator <= 0 {
+		return fmt.Errorf("Invalid edit '%s': expected KEY.PATH=VALUE", edit)
+	}
+	path := edit[:separator]
+
+	var value interface{}
+	decoder := json.NewDecoder(strings.NewReader(edit[separator+1:]))
+	decoder.UseNumber()
+	if decoder.Decode(&value) != nil || decoder.More() {
+		value = edit[separator+1:]
+	} else {
+		value = normaliseParameter("", value, true)
+	}
+
+	segments := strings.Split(path, ".")
+	var parent interface{} = parameters
+	for i, segment := range segments {
+		match := querySegmentPattern.FindStringSubmatch(segment)
+		if match == nil || match[1] == "" {
+			return fmt.Errorf("Invalid edit '%s': expected keys separated by '.' with optional [INDEX] suffixes", edit)
+		}
+		last := i == len(segments)-1
+		indices := strings.FieldsFunc(match[2], func(r rune) bool { return r == '[' || r == ']' })
+
+		object, ok := parent.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("Invalid edit '%s': %s is not an object", edit, strings.Join(segments[:i], "."))
+		}
+		if last && len(indices) == 0 {
+			if value == nil {
+				delete(object, match[1])
+			} else {
+				object[match[1]] = value
+			}
+			return nil
+		}
+		if _, ok := object[match[1]]; !ok {
+			if len(indices) > 0 {
+				object[match[1]] = []interface{}{}
+			} else {
+				object[match[1]] = map[string]interface{}{}
+			}
+		}
+
+		// Walk the array indices, allowing an element to be appended at the end of an array.
+		container := object[match[1]]
+		setContainer := func(v interface{}) { object[match[1]] = v }
+		for j, index := range indices {
+			array, ok := container.([]interface{})
+			if !ok {
+				return fmt.Errorf("Invalid edit '%s': %s is not an array", edit, match[1])
+			}
+			n, _ := strconv.Atoi(index)
+			if n > len(array) {
+				return fmt.Errorf("Invalid edit '%s': index %d is beyond the end of %s", edit, n, match[1])
+			}
+			if n == len(array) {
+				array = append(array, map[string]interface{}{})
+				setContainer(array)
+			}
+			if last && j == len(indices)-1 {
+				if value == nil {
+					array = append(array[:n], array[n+1:]...)
+				} else {
+					array[n] = value
+				}
+				setContainer(array)
+				return nil
+			}
+			setContainer = func(v interface{}) { array[n] = v }
+			container = array[n]
+		}
+		parent = container
+	}
+	return nil
+}
+
+// ValidateParameters checks the structure of the configuration parameters of a service instance of the given service
+// offering, such as the format of Git URIs and the backends of a config server, and reports every problem found.
+func ValidateParameters(serviceOfferingName string, parameters map[string]interface{}) error {
+	problems := []string{}
+	if count, ok := parameters["count"]; ok {
+		if n, ok := count.(int64); !ok || n < 1 {
+			problems = append(problems, fmt.Sprintf("count must be a positive integer, not %s", parameterString(count)))
+		}
+	}
+
+	properties := flattenParameters("", parameters)
+	for _, key := range sortedKeys(properties) {
+		if value, ok := properties[key].(string); ok && value == config.MaskedValue {
+			problems = append(problems, fmt.Sprintf("%s is masked: set its value explicitly", key))
+		}
+	}
+
+	if strings.HasSuffix(serviceOfferingName, "config-server") {
+		problems = append(problems, validateConfigServerParameters(parameters)...)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("Invalid configuration parameters:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+func validateConfigServerParameters(parameters map[string]interface{}) []string {
+	problems := []string{}
+	if git, ok := parameters["git"]; ok {
+		problems = append(problems, validateGitParameters("git", git, false)...)
+	}
+
+	composite, ok := parameters["composite"]
+	if !ok {
+		return problems
+	}
+	backends, ok := composite.([]interface{})
+	if !ok {
+		return append(problems, "composite must be an array of backends, in order of precedence")
+	}
+	orders := map[int64]int{}
+	for i, backend := range backends {
+		path := fmt.Sprintf("composite[%d]", i)
+		backendObject, ok := backend.(map[string]interface{})
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s must be an object", path))
+			continue
+		}
+		backendType, _ := backendObject["type"].(string)
+		if !compositeBackendTypes[backendType] {
+			problems = append(problems, fmt.Sprintf("%s.type must be one of credhub, git or vault, not %s", path, parameterString(backendObject["type"])))
+		}
+		if backendType == "git" {
+			problems = append(problems, validateGitParameters(path, backendObject, true)...)
+		}
+		if order, ok := backendObject["order"]; ok {
+			n, ok := order.(int64)
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s.order must be an integer", path))
+			} else if previous, ok := orders[n]; ok {
+				problems = append(problems, fmt.Sprintf("%s.order %d is the same as composite[%d].order", path, n, previous))
+			} else {
+				orders[n] = i
+			}
+		}
+	}
+	return problems
+}
+
+func validateGitParameters(path string, git interface{}, uriRequired bool) []string {
+	gitObject, ok := git.(map[string]interface{})
+	if !ok {
+		return []string{fmt.Sprintf("%s must be an object", path)}
+	}
+
+	problems := []string{}
+	if uri, ok := gitObject["uri"]; ok {
+		if problem := validateGitUri(path+".uri", uri); problem != "" {
+			problems = append(problems, problem)
+		}
+	} else if uriRequired {
+		problems = append(problems, fmt.Sprintf("%s.uri is required", path))
+	}
+
+	if repos, ok := gitObject["repos"].(map[string]interface{}); ok {
+		for _, name := range sortedKeys(repos) {
+			repoPath := fmt.Sprintf("%s.repos.%s", path, name)
+			repo, ok := repos[name].(map[string]interface{})
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s must be an object", repoPath))
+				continue
+			}
+			if problem := validateGitUri(repoPath+".uri", repo["uri"]); problem != "" {
+				problems = append(problems, problem)
+			}
+		}
+	}
+	return problems
+}
+
+// validateGitUri accepts the URIs of Git repositories which the config server can clone: http, https, ssh, git and
+// file URLs, and scp-like SSH addresses such as git@github.com:org/repo.git.
+func validateGitUri(path string, uri interface{}) string {
+	uriString, ok := uri.(string)
+	if !ok || uriString == "" {
+		return fmt.Sprintf("%s is required", path)
+	}
+	if scpLikeGitUriPattern.MatchString(uriString) && !strings.Contains(uriString, "://") {
+		return ""
+	}
+
+	parsedUri, err := url.Parse(uriString)
+	if err == nil {
+		switch parsedUri.Scheme {
+		case "http", "https", "ssh", "git":
+			if parsedUri.Host != "" {
+				return ""
+			}
+		case "file":
+			return ""
+		}
+	}
+	return fmt.Sprintf("%s %s is not a Git repository URI: use an http, https, ssh, git or file URL or an address such as git@github.com:org/repo.git", path, uriString)
+}
+
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,234 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package instance_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/cli/plugin/models"
+	"code.cloudfoundry.org/cli/plugin/pluginfakes"
+	"github.com/fatih/color"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/httpclient/httpclientfakes"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/instance"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/serviceutil"
+)
+
+var _ = Describe("UpdateParametersOperation", func() {
+
+	const currentParameters = `{
+		"count": 1,
+		"git": {"uri": "https://github.com/org/config", "label": "main", "password": "s3cret"}
+	}`
+
+	var (
+		fakeCliConnection   *pluginfakes.FakeCliConnection
+		fakeAuthClient      *httpclientfakes.FakeAuthenticatedClient
+		update              instance.ParametersUpdate
+		serviceOfferingName string
+		progress            *bytes.Buffer
+		output              string
+		err                 error
+	)
+
+	BeforeEach(func() {
+		color.NoColor = true
+		fakeCliConnection = &pluginfakes.FakeCliConnection{}
+		fakeCliConnection.AccessTokenReturns("bearer someaccesstoken", nil)
+		fakeCliConnection.GetServiceReturns(plugin_models.GetService_Model{LastOperation: plugin_models.GetService_LastOperation{Type: "update", State: "succeeded"}}, nil)
+		fakeAuthClient = &httpclientfakes.FakeAuthenticatedClient{}
+		fakeAuthClient.DoAuthenticatedGetReturns(ioutil.NopCloser(bytes.NewBufferString(currentParameters)), http.StatusOK, nil)
+		update = instance.ParametersUpdate{Edits: []string{"git.label=release", "count=2"}}
+		serviceOfferingName = "p.config-server"
+		progress = &bytes.Buffer{}
+	})
+
+	JustBeforeEach(func() {
+		operation := instance.NewUpdateParametersOperation(fakeCliConnection, fakeAuthClient, "config-server", update, time.Second, time.Millisecond, progress)
+		output, err = operation.Run(serviceutil.ManagementParameters{Url: "https://servicebroker.host/cli/instances/si-guid", ServiceOfferingName: serviceOfferingName}, "someaccesstoken")
+	})
+
+	It("shows the differences and updates the service instance", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output).To(Equal("Updated the configuration parameters of config-server"))
+		Expect(progress.String()).To(ContainSubstring("from: config-server (current)\nto:   config-server (updated)\n"))
+		Expect(progress.String()).To(ContainSubstring("git.label"))
+		Expect(progress.String()).NotTo(ContainSubstring("s3cret"))
+
+		Expect(fakeCliConnection.CliCommandWithoutTerminalOutputCallCount()).To(Equal(1))
+		args := fakeCliConnection.CliCommandWithoutTerminalOutputArgsForCall(0)
+		Expect(args[:3]).To(Equal([]string{"update-service", "config-server", "-c"}))
+		Expect(args[3]).To(MatchJSON(`{"count": 2, "git": {"uri": "https://github.com/org/config", "label": "release", "password": "s3cret"}}`))
+		Expect(fakeCliConnection.GetServiceArgsForCall(0)).To(Equal("config-server"))
+	})
+
+	Context("when parameters are read from a file", func() {
+		BeforeEach(func() {
+			dir, err := ioutil.TempDir("", "parameters")
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(os.RemoveAll, dir)
+			fileName := filepath.Join(dir, "params.yml")
+			Expect(ioutil.WriteFile(fileName, []byte("count: 3\ngit:\n  label: develop\n  password: null\n"), 0600)).To(Succeed())
+
+			update.Changes, err = instance.ReadParametersFile(fileName)
+			Expect(err).NotTo(HaveOccurred())
+			update.Edits = []string{"composite[0]={\"type\": \"credhub\"}"}
+		})
+
+		It("merges them into the current parameters before applying the edits", func() {
+			Expect(err).NotTo(HaveOccurred())
+			args := fakeCliConnection.CliCommandWithoutTerminalOutputArgsForCall(0)
+			Expect(args[3]).To(MatchJSON(`{"count": 3, "git": {"uri": "https://github.com/org/config", "label": "develop"}, "composite": [{"type": "credhub"}]}`))
+		})
+	})
+
+	Context("when it is a dry run", func() {
+		BeforeEach(func() {
+			update.DryRun = true
+		})
+
+		It("shows the differences without updating the service instance", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output).To(Equal("Dry run: the configuration parameters of config-server were not updated"))
+			Expect(progress.String()).To(ContainSubstring("git.label"))
+			Expect(fakeCliConnection.CliCommandWithoutTerminalOutputCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when nothing changes", func() {
+		BeforeEach(func() {
+			update.Edits = []string{"count=1"}
+		})
+
+		It("does not update the service instance", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output).To(Equal("The configuration parameters of config-server are unchanged"))
+			Expect(fakeCliConnection.CliCommandWithoutTerminalOutputCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when the updated parameters are invalid", func() {
+		BeforeEach(func() {
+			update.Edits = []string{"count=0", "git.uri=github.com/org/config", `composite=[{"type": "git", "order": 1}, {"type": "s3", "order": 1}]`}
+		})
+
+		It("reports every problem without updating the service instance", func() {
+			Expect(err).To(MatchError(`Invalid configuration parameters:
+  count must be a positive integer, not 0
+  git.uri github.com/org/config is not a Git repository URI: use an http, https, ssh, git or file URL or an address such as git@github.com:org/repo.git
+  composite[0].uri is required
+  composite[1].type must be one of credhub, git or vault, not s3
+  composite[1].order 1 is the same as composite[0].order`))
+			Expect(fakeCliConnection.CliCommandWithoutTerminalOutputCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when the service instance is a service registry", func() {
+		BeforeEach(func() {
+			serviceOfferingName = "p.service-registry"
+			update.Edits = []string{"git.uri=not a uri"}
+		})
+
+		It("does not validate config server parameters", func() {
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("when an edit is invalid", func() {
+		BeforeEach(func() {
+			update.Edits = []string{"git.uri"}
+		})
+
+		It("returns a suitable error", func() {
+			Expect(err).To(MatchError("Invalid edit 'git.uri': expected KEY.PATH=VALUE"))
+		})
+	})
+
+	Context("when the update cannot be started", func() {
+		BeforeEach(func() {
+			fakeCliConnection.CliCommandWithoutTerminalOutputReturns(nil, errors.New("forbidden"))
+		})
+
+		It("returns a suitable error", func() {
+			Expect(err).To(MatchError("Error updating service instance config-server: forbidden"))
+		})
+	})
+
+	Context("when the update is in progress", func() {
+		BeforeEach(func() {
+			fakeCliConnection.GetServiceReturnsOnCall(0, plugin_models.GetService_Model{LastOperation: plugin_models.GetService_LastOperation{State: "in progress"}}, nil)
+		})
+
+		It("waits for the update to finish, refreshing the access token for each poll", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeCliConnection.GetServiceCallCount()).To(Equal(2))
+			Expect(fakeCliConnection.AccessTokenCallCount()).To(Equal(2))
+			Expect(progress.String()).To(ContainSubstring("The update of config-server is in progress\n"))
+		})
+	})
+
+	Context("when the update fails", func() {
+		BeforeEach(func() {
+			fakeCliConnection.GetServiceReturns(plugin_models.GetService_Model{LastOperation: plugin_models.GetService_LastOperation{State: "failed", Description: "invalid git uri"}}, nil)
+		})
+
+		It("returns a suitable error", func() {
+			Expect(err).To(MatchError("The update of service instance config-server failed: invalid git uri"))
+		})
+	})
+
+	Context("when the update does not finish in time", func() {
+		BeforeEach(func() {
+			fakeCliConnection.GetServiceReturns(plugin_models.GetService_Model{LastOperation: plugin_models.GetService_LastOperation{State: "in progress"}}, nil)
+		})
+
+		It("times out", func() {
+			Expect(err).To(MatchError("timed out after 1s waiting for the update of config-server to finish"))
+		})
+	})
+})
+
+var _ = Describe("EditParameter", func() {
+	It("appends to arrays and removes parameters", func() {
+		parameters := map[string]interface{}{
+			"composite": []interface{}{map[string]interface{}{"type": "vault"}},
+			"count":     int64(1),
+		}
+		Expect(instance.EditParameter(parameters, "composite[1].type=git")).To(Succeed())
+		Expect(instance.EditParameter(parameters, "composite[1].uri=git@github.com:org/config.git")).To(Succeed())
+		Expect(instance.EditParameter(parameters, "count=null")).To(Succeed())
+		Expect(parameters).To(Equal(map[string]interface{}{
+			"composite": []interface{}{
+				map[string]interface{}{"type": "vault"},
+				map[string]interface{}{"type": "git", "uri": "git@github.com:org/config.git"},
+			},
+		}))
+		Expect(instance.ValidateParameters("p-config-server", parameters)).To(Succeed())
+	})
+
+	It("rejects indices beyond the end of an array", func() {
+		parameters := map[string]interface{}{"composite": []interface{}{}}
+		Expect(instance.EditParameter(parameters, "composite[1].type=git")).To(MatchError("Invalid edit 'composite[1].type=git': index 1 is beyond the end of composite"))
+	})
+})
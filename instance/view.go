@@ -31,6 +31,7 @@ import (
 
 	"code.cloudfoundry.org/bytefmt"
 	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/httpclient"
+	"go.yaml.in/yaml/v3"
 )
 
 type ViewInstanceResp struct {
@@ -64,32 +65,45 @@ type BackingAppInstance struct {
 
 type viewOperation struct {
 	authenticatedClient httpclient.AuthenticatedClient
+	outputFormat        string
 }
 
 func (vo *viewOperation) Run(serviceInstanceManagementParameters serviceutil.ManagementParameters, accessToken string) (string, error) {
-	bodyReader, statusCode, err := vo.authenticatedClient.DoAuthenticatedGet(serviceInstanceManagementParameters.Url, accessToken)
+	viewInstanceResp, err := fetchView(vo.authenticatedClient, serviceInstanceManagementParameters, accessToken)
 	if err != nil {
 		return "", err
 	}
+
+	if vo.outputFormat != "" {
+		return RenderViewAs(viewInstanceResp, vo.outputFormat)
+	}
+	return RenderView(viewInstanceResp)
+}
+
+func fetchView(authenticatedClient httpclient.AuthenticatedClient, serviceInstanceManagementParameters serviceutil.ManagementParameters, accessToken string) (*ViewInstanceResp, error) {
+	bodyReader, statusCode, err := authenticatedClient.DoAuthenticatedGet(serviceInstanceManagementParameters.Url, accessToken)
+	if err != nil {
+		return nil, err
+	}
 	if statusCode != http.StatusOK {
-		return "", fmt.Errorf("Service broker view instance failed: %d", statusCode)
+		return nil, fmt.Errorf("Service broker view instance failed: %d", statusCode)
 	}
 
 	if bodyReader == nil {
-		return "", errors.New("Service broker view instance response body missing")
+		return nil, errors.New("Service broker view instance response body missing")
 	}
 	body, err := ioutil.ReadAll(bodyReader)
 	if err != nil {
-		return "", fmt.Errorf("Cannot read service broker view instance response body: %s", err)
+		return nil, fmt.Errorf("Cannot read service broker view instance response body: %s", err)
 	}
 
 	var viewInstanceResp ViewInstanceResp
 	err = json.Unmarshal(body, &viewInstanceResp)
 	if err != nil {
-		return "", fmt.Errorf("Invalid service broker view instance response JSON: %s, response body: '%s'", err, string(body))
+		return nil, fmt.Errorf("Invalid service broker view instance response JSON: %s, response body: '%s'", err, string(body))
 	}
 
-	return RenderView(&viewInstanceResp)
+	return &viewInstanceResp, nil
 }
 
 func (vo *viewOperation) IsServiceBrokerOperation() bool {
@@ -102,6 +116,15 @@ func NewViewOperation(authenticatedClient httpclient.AuthenticatedClient) Operat
 	}
 }
 
+// NewFormattedViewOperation returns an operation which renders the service instance in the given machine readable
+// format, json or yaml, rather than as text.
+func NewFormattedViewOperation(authenticatedClient httpclient.AuthenticatedClient, outputFormat string) Operation {
+	return &viewOperation{
+		authenticatedClient: authenticatedClient,
+		outputFormat:        outputFormat,
+	}
+}
+
 func RenderView(viewInstanceResp *ViewInstanceResp) (string, error) {
 	const maxWidth = 150
 
@@ -143,6 +166,101 @@ buildpack:        %s
 	return buffer.String(), nil
 }
 
+const (
+	JsonOutputFormat = "json"
+	YamlOutputFormat = "yaml"
+)
+
+type viewOutput struct {
+	BackingApps []backingAppOutput `json:"backing_apps" yaml:"backing_apps"`
+}
+
+type backingAppOutput struct {
+	Name             string                     `json:"name" yaml:"name"`
+	RequestedState   string                     `json:"requested_state" yaml:"requested_state"`
+	NumInstances     int                        `json:"num_instances" yaml:"num_instances"`
+	RunningInstances int                        `json:"running_instances" yaml:"running_instances"`
+	Memory           int                        `json:"memory" yaml:"memory"`
+	Routes           []string                   `json:"routes" yaml:"routes"`
+	LastUploaded     string                     `json:"last_uploaded,omitempty" yaml:"last_uploaded,omitempty"`
+	Stack            string                     `json:"stack" yaml:"stack"`
+	Buildpack        string                     `json:"buildpack" yaml:"buildpack"`
+	Instances        []backingAppInstanceOutput `json:"instances" yaml:"instances"`
+}
+
+type backingAppInstanceOutput struct {
+	Index       int     `json:"index" yaml:"index"`
+	State       string  `json:"state" yaml:"state"`
+	Since       string  `json:"since,omitempty" yaml:"since,omitempty"`
+	CPU         float64 `json:"cpu" yaml:"cpu"`
+	MemoryUsage int64   `json:"memory_usage" yaml:"memory_usage"`
+	MemoryQuota int64   `json:"memory_quota" yaml:"memory_quota"`
+	DiskUsage   int64   `json:"disk_usage" yaml:"disk_usage"`
+	DiskQuota   int64   `json:"disk_quota" yaml:"disk_quota"`
+	Details     string  `json:"details" yaml:"details"`
+}
+
+// RenderViewAs renders the service instance in a machine readable format, json or yaml, with the same fields as the
+// service broker's response but with timestamps in RFC3339 format.
+func RenderViewAs(viewInstanceResp *ViewInstanceResp, outputFormat string) (string, error) {
+	output := viewOutput{BackingApps: []backingAppOutput{}}
+	for _, backingApp := range viewInstanceResp.BackingApps {
+		appOutput := backingAppOutput{
+			Name:             backingApp.Name,
+			RequestedState:   backingApp.RequestedState,
+			NumInstances:     backingApp.NumInstances,
+			RunningInstances: backingApp.RunningInstances,
+			Memory:           backingApp.Memory,
+			Routes:           backingApp.Routes,
+			LastUploaded:     optionalRfc3339UtcDate(backingApp.LastUploaded),
+			Stack:            backingApp.Stack,
+			Buildpack:        backingApp.Buildpack,
+			Instances:        []backingAppInstanceOutput{},
+		}
+		if appOutput.Routes == nil {
+			appOutput.Routes = []string{}
+		}
+		for _, backingAI := range backingApp.Instances {
+			appOutput.Instances = append(appOutput.Instances, backingAppInstanceOutput{
+				Index:       backingAI.Index,
+				State:       backingAI.State,
+				Since:       optionalRfc3339UtcDate(backingAI.Since),
+				CPU:         backingAI.CPU,
+				MemoryUsage: backingAI.MemoryUsage,
+				MemoryQuota: backingAI.MemoryQuota,
+				DiskUsage:   backingAI.DiskUsage,
+				DiskQuota:   backingAI.DiskQuota,
+				Details:     backingAI.Details,
+			})
+		}
+		output.BackingApps = append(output.BackingApps, appOutput)
+	}
+
+	switch outputFormat {
+	case JsonOutputFormat:
+		contents, err := json.MarshalIndent(output, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(contents), nil
+	case YamlOutputFormat:
+		contents, err := yaml.Marshal(output)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(string(contents), "\n"), nil
+	default:
+		return "", fmt.Errorf("Unsupported output format '%s': use json or yaml", outputFormat)
+	}
+}
+
+func optionalRfc3339UtcDate(input int64) string {
+	if input == 0 {
+		return ""
+	}
+	return rfc3339UtcDate(input)
+}
+
 func iso8601LocalDate(input int64) string {
 	return toTime(input).Local().Format("Mon 02 Jan 15:04:05 MST 2006")
 }
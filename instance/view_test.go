@@ -163,6 +163,104 @@ buildpack:        container-certificate-trust-store=2.0.0_RELEASE java-buildpack
 	})
 })
 
+var _ = Describe("FormattedView", func() {
+
+	const viewJson = `{"backing_apps":[
+		{"name":"config-server",
+		 "buildpack":"java_buildpack",
+		 "stack":"cflinuxfs4",
+		 "memory":1024,
+		 "routes":["config-server.apps.example.com"],
+		 "instances":[
+		     {"index":0,
+		      "state":"CRASHED",
+		      "since":1500682722000,
+		      "cpu":0.5,
+		      "details":"",
+		      "memory_usage":1039134720,
+		      "memory_quota":1073741824,
+		      "disk_usage":195514368,
+		      "disk_quota":1073741824}
+		 ],
+		 "last_uploaded":1498494177000,
+		 "num_instances":2,
+		 "running_instances":0,
+		 "requested_state":"STARTED"}
+	]}`
+
+	var (
+		fakeAuthClient *httpclientfakes.FakeAuthenticatedClient
+		outputFormat   string
+		output         string
+		err            error
+	)
+
+	BeforeEach(func() {
+		fakeAuthClient = &httpclientfakes.FakeAuthenticatedClient{}
+		fakeAuthClient.DoAuthenticatedGetReturns(ioutil.NopCloser(bytes.NewBufferString(viewJson)), http.StatusOK, nil)
+	})
+
+	JustBeforeEach(func() {
+		output, err = instance.NewFormattedViewOperation(fakeAuthClient, outputFormat).Run(serviceutil.ManagementParameters{Url: "https://some.host/cli/instances/someguid"}, "someaccesstoken")
+	})
+
+	Context("when the format is json", func() {
+		BeforeEach(func() {
+			outputFormat = instance.JsonOutputFormat
+		})
+
+		It("should print the backing apps as JSON with RFC3339 timestamps", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output).To(MatchJSON(`{"backing_apps":[
+				{"name":"config-server",
+				 "requested_state":"STARTED",
+				 "num_instances":2,
+				 "running_instances":0,
+				 "memory":1024,
+				 "routes":["config-server.apps.example.com"],
+				 "last_uploaded":"2017-06-26T16:22:57Z",
+				 "stack":"cflinuxfs4",
+				 "buildpack":"java_buildpack",
+				 "instances":[
+				     {"index":0,
+				      "state":"CRASHED",
+				      "since":"2017-07-22T00:18:42Z",
+				      "cpu":0.5,
+				      "memory_usage":1039134720,
+				      "memory_quota":1073741824,
+				      "disk_usage":195514368,
+				      "disk_quota":1073741824,
+				      "details":""}
+				 ]}
+			]}`))
+		})
+	})
+
+	Context("when the format is yaml", func() {
+		BeforeEach(func() {
+			outputFormat = instance.YamlOutputFormat
+		})
+
+		It("should print the backing apps as YAML", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output).To(ContainSubstring("backing_apps:\n    - name: config-server\n      requested_state: STARTED\n      num_instances: 2\n      running_instances: 0\n"))
+			Expect(output).To(ContainSubstring("last_uploaded: \"2017-06-26T16:22:57Z\""))
+			Expect(output).To(ContainSubstring("since: \"2017-07-22T00:18:42Z\""))
+		})
+	})
+
+	Context("when the format is not supported", func() {
+		BeforeEach(func() {
+			outputFormat = "xml"
+		})
+
+		It("should return a suitable error", func() {
+			Expect(output).To(Equal(""))
+			Expect(err).To(MatchError("Unsupported output format 'xml': use json or yaml"))
+		})
+	})
+})
+
 type badReader struct{}
 
 func (b badReader) Read(p []byte) (n int, err error) {
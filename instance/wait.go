@@ -0,0 +1,147 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package instance
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/plugin"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/cfutil"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/httpclient"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/serviceutil"
+)
+
+const (
+	StartedState = "STARTED"
+	StoppedState = "STOPPED"
+	runningState = "RUNNING"
+)
+
+type waitingOperation struct {
+	operation           Operation
+	cliConnection       plugin.CliConnection
+	authenticatedClient httpclient.AuthenticatedClient
+	requestedState      string
+	restarts            bool
+	timeout             time.Duration
+	pollInterval        time.Duration
+	progressWriter      io.Writer
+}
+
+// NewWaitingOperation returns an operation which runs a lifecycle operation and then polls the service instance until
+// every backing app has reached the requested state: stopped, or started with all its instances running. If restarts
+// is set, the instances must also have started since the operation was run, so that a restart or restage is not
+// mistaken for complete before the old instances have stopped. The access token is fetched again before each poll, so
+// that a long wait outlives the token the operation was run with.
+func NewWaitingOperation(operation Operation, cliConnection plugin.CliConnection, authenticatedClient httpclient.AuthenticatedClient, requestedState string, restarts bool, timeout time.Duration, pollInterval time.Duration, progressWriter io.Writer) Operation {
+	return &waitingOperation{
+		operation:           operation,
+		cliConnection:       cliConnection,
+		authenticatedClient: authenticatedClient,
+		requestedState:      requestedState,
+		restarts:            restarts,
+		timeout:             timeout,
+		pollInterval:        pollInterval,
+		progressWriter:      progressWriter,
+	}
+}
+
+func (wo *waitingOperation) Run(serviceInstanceManagementParameters serviceutil.ManagementParameters, accessToken string) (string, error) {
+	startedBefore := map[string]int64{}
+	if wo.restarts {
+		before, err := fetchView(wo.authenticatedClient, serviceInstanceManagementParameters, accessToken)
+		if err != nil {
+			return "", err
+		}
+		for _, backingApp := range before.BackingApps {
+			startedBefore[backingApp.Name] = latestSince(backingApp)
+		}
+	}
+
+	output, err := wo.operation.Run(serviceInstanceManagementParameters, accessToken)
+	if err != nil {
+		return output, err
+	}
+
+	state := strings.ToLower(wo.requestedState)
+	fmt.Fprintf(wo.progressWriter, "Waiting for backing apps to be %s\n", state)
+	deadline := time.Now().Add(wo.timeout)
+	for {
+		accessToken, err := cfutil.GetToken(wo.cliConnection)
+		if err != nil {
+			return "", err
+		}
+		view, err := fetchView(wo.authenticatedClient, serviceInstanceManagementParameters, accessToken)
+		if err != nil {
+			return "", err
+		}
+
+		pending := []string{}
+		for _, backingApp := range view.BackingApps {
+			if !wo.reached(backingApp, startedBefore[backingApp.Name]) {
+				pending = append(pending, backingApp.Name)
+			}
+		}
+		if len(pending) == 0 {
+			fmt.Fprintf(wo.progressWriter, "All backing apps are %s\n", state)
+			return output, nil
+		}
+
+		if !time.Now().Add(wo.pollInterval).Before(deadline) {
+			return "", fmt.Errorf("timed out after %s waiting for backing apps to be %s: %s not %s", wo.timeout, state, strings.Join(pending, ", "), state)
+		}
+		for _, backingApp := range view.BackingApps {
+			fmt.Fprintf(wo.progressWriter, "%s: %s, %d/%d instances running\n", backingApp.Name, strings.ToLower(backingApp.RequestedState), backingApp.RunningInstances, backingApp.NumInstances)
+		}
+		time.Sleep(wo.pollInterval)
+	}
+}
+
+func (wo *waitingOperation) reached(backingApp BackingApp, startedBefore int64) bool {
+	if backingApp.RequestedState != wo.requestedState {
+		return false
+	}
+	if wo.requestedState == StoppedState {
+		return backingApp.RunningInstances == 0
+	}
+	if backingApp.RunningInstances != backingApp.NumInstances || len(backingApp.Instances) != backingApp.NumInstances {
+		return false
+	}
+	for _, backingAppInstance := range backingApp.Instances {
+		if backingAppInstance.State != runningState || (wo.restarts && backingAppInstance.Since <= startedBefore) {
+			return false
+		}
+	}
+	return true
+}
+
+func (wo *waitingOperation) IsServiceBrokerOperation() bool {
+	return wo.operation.IsServiceBrokerOperation()
+}
+
+func latestSince(backingApp BackingApp) int64 {
+	var latest int64
+	for _, backingAppInstance := range backingApp.Instances {
+		if backingAppInstance.Since > latest {
+			latest = backingAppInstance.Since
+		}
+	}
+	return latest
+}
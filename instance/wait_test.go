@@ -0,0 +1,184 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package instance_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/plugin/pluginfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/httpclient/httpclientfakes"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/instance"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/instance/instancefakes"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/serviceutil"
+)
+
+var _ = Describe("WaitingOperation", func() {
+
+	const (
+		testAccessToken = "someaccesstoken"
+		stopped         = `{"backing_apps": [{"name": "config-server", "requested_state": "STOPPED", "num_instances": 1, "running_instances": 0, "instances": []}]}`
+		stopping        = `{"backing_apps": [{"name": "config-server", "requested_state": "STOPPED", "num_instances": 1, "running_instances": 1, "instances": [{"index": 0, "state": "RUNNING", "since": 1000}]}]}`
+		runningBefore   = `{"backing_apps": [{"name": "config-server", "requested_state": "STARTED", "num_instances": 1, "running_instances": 1, "instances": [{"index": 0, "state": "RUNNING", "since": 1000}]}]}`
+		starting        = `{"backing_apps": [{"name": "config-server", "requested_state": "STARTED", "num_instances": 1, "running_instances": 0, "instances": [{"index": 0, "state": "STARTING", "since": 2000}]}]}`
+		runningAfter    = `{"backing_apps": [{"name": "config-server", "requested_state": "STARTED", "num_instances": 1, "running_instances": 1, "instances": [{"index": 0, "state": "RUNNING", "since": 2000}]}]}`
+	)
+
+	var (
+		fakeOperation     *instancefakes.FakeOperation
+		fakeCliConnection *pluginfakes.FakeCliConnection
+		fakeAuthClient    *httpclientfakes.FakeAuthenticatedClient
+		views             []string
+		requestedState    string
+		restarts          bool
+		timeout           time.Duration
+		progress          *bytes.Buffer
+		output            string
+		err               error
+	)
+
+	BeforeEach(func() {
+		fakeOperation = &instancefakes.FakeOperation{}
+		fakeOperation.RunReturns("", nil)
+		fakeOperation.IsServiceBrokerOperationReturns(true)
+		fakeCliConnection = &pluginfakes.FakeCliConnection{}
+		fakeCliConnection.AccessTokenStub = func() (string, error) {
+			return "bearer refreshedaccesstoken", nil
+		}
+		fakeAuthClient = &httpclientfakes.FakeAuthenticatedClient{}
+		fakeAuthClient.DoAuthenticatedGetStub = func(url string, accessToken string) (io.ReadCloser, int, error) {
+			view := views[0]
+			if len(views) > 1 {
+				views = views[1:]
+			}
+			return ioutil.NopCloser(strings.NewReader(view)), http.StatusOK, nil
+		}
+		restarts = false
+		timeout = time.Second
+		progress = &bytes.Buffer{}
+	})
+
+	JustBeforeEach(func() {
+		waitingOperation := instance.NewWaitingOperation(fakeOperation, fakeCliConnection, fakeAuthClient, requestedState, restarts, timeout, time.Millisecond, progress)
+		Expect(waitingOperation.IsServiceBrokerOperation()).To(BeTrue())
+		output, err = waitingOperation.Run(serviceutil.ManagementParameters{Url: "https://some.host/cli/instances/someguid"}, testAccessToken)
+	})
+
+	Context("when stopping", func() {
+		BeforeEach(func() {
+			requestedState = instance.StoppedState
+			views = []string{stopping, stopped}
+		})
+
+		It("runs the operation and waits until no instances are running, refreshing the access token for each poll", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeOperation.RunCallCount()).To(Equal(1))
+			_, accessToken := fakeOperation.RunArgsForCall(0)
+			Expect(accessToken).To(Equal(testAccessToken))
+			Expect(fakeAuthClient.DoAuthenticatedGetCallCount()).To(Equal(2))
+			Expect(fakeCliConnection.AccessTokenCallCount()).To(Equal(2))
+			url, accessToken := fakeAuthClient.DoAuthenticatedGetArgsForCall(0)
+			Expect(url).To(Equal("https://some.host/cli/instances/someguid"))
+			Expect(accessToken).To(Equal("refreshedaccesstoken"))
+			Expect(progress.String()).To(Equal("Waiting for backing apps to be stopped\nconfig-server: stopped, 1/1 instances running\nAll backing apps are stopped\n"))
+		})
+	})
+
+	Context("when restarting", func() {
+		BeforeEach(func() {
+			requestedState = instance.StartedState
+			restarts = true
+			views = []string{runningBefore, runningBefore, starting, runningAfter}
+		})
+
+		It("waits until the instances have started again", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeAuthClient.DoAuthenticatedGetCallCount()).To(Equal(4))
+			Expect(progress.String()).To(HaveSuffix("config-server: started, 0/1 instances running\nAll backing apps are started\n"))
+		})
+	})
+
+	Context("when starting", func() {
+		BeforeEach(func() {
+			requestedState = instance.StartedState
+			views = []string{runningBefore}
+		})
+
+		It("does not wait for instances which are already running", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeAuthClient.DoAuthenticatedGetCallCount()).To(Equal(1))
+		})
+	})
+
+	Context("when the operation fails", func() {
+		BeforeEach(func() {
+			requestedState = instance.StoppedState
+			fakeOperation.RunReturns("", errors.New("operation failed"))
+		})
+
+		It("does not wait", func() {
+			Expect(err).To(MatchError("operation failed"))
+			Expect(fakeAuthClient.DoAuthenticatedGetCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when the backing apps do not reach the requested state in time", func() {
+		BeforeEach(func() {
+			requestedState = instance.StoppedState
+			views = []string{stopping}
+			timeout = 5 * time.Millisecond
+		})
+
+		It("returns a suitable error", func() {
+			Expect(output).To(BeEmpty())
+			Expect(err).To(MatchError("timed out after 5ms waiting for backing apps to be stopped: config-server not stopped"))
+		})
+	})
+
+	Context("when the service instance cannot be viewed", func() {
+		BeforeEach(func() {
+			requestedState = instance.StoppedState
+			fakeAuthClient.DoAuthenticatedGetStub = nil
+			fakeAuthClient.DoAuthenticatedGetReturns(nil, http.StatusNotFound, nil)
+		})
+
+		It("returns the error", func() {
+			Expect(err).To(MatchError("Service broker view instance failed: 404"))
+		})
+	})
+
+	Context("when the access token cannot be refreshed", func() {
+		BeforeEach(func() {
+			requestedState = instance.StoppedState
+			fakeCliConnection.AccessTokenStub = func() (string, error) {
+				return "", errors.New("not logged in")
+			}
+		})
+
+		It("returns a suitable error", func() {
+			Expect(err).To(MatchError("Access token not available: not logged in"))
+			Expect(fakeAuthClient.DoAuthenticatedGetCallCount()).To(Equal(0))
+		})
+	})
+})
@@ -0,0 +1,253 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package instance
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/plugin"
+	"github.com/fatih/color"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/cfutil"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/format"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/httpclient"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/serviceutil"
+)
+
+const (
+	clearScreen    = "\033[H\033[2J"
+	sparklineWidth = 20
+)
+
+var sparklineLevels = []rune("▁▂▃▄▅▆▇█")
+
+type watchOperation struct {
+	cliConnection       plugin.CliConnection
+	authenticatedClient httpclient.AuthenticatedClient
+	interval            time.Duration
+	refreshes           int
+	writer              io.Writer
+}
+
+// NewWatchOperation returns an operation which refreshes the view of the service instance every interval until it is
+// interrupted or, if refreshes is positive, it has refreshed that many times. Each refresh obtains a fresh access
+// token, so that watching outlives the token it started with, and is followed by the CPU and memory usage of every
+// instance since watching started, with the instances which have restarted or changed state highlighted.
+func NewWatchOperation(cliConnection plugin.CliConnection, authenticatedClient httpclient.AuthenticatedClient, interval time.Duration, refreshes int, writer io.Writer) Operation {
+	return &watchOperation{
+		cliConnection:       cliConnection,
+		authenticatedClient: authenticatedClient,
+		interval:            interval,
+		refreshes:           refreshes,
+		writer:              writer,
+	}
+}
+
+func (wo *watchOperation) Run(serviceInstanceManagementParameters serviceutil.ManagementParameters, accessToken string) (string, error) {
+	history := newViewHistory()
+	for refresh := 1; wo.refreshes <= 0 || refresh <= wo.refreshes; refresh++ {
+		var err error
+		if refresh > 1 {
+			time.Sleep(wo.interval)
+			accessToken, err = cfutil.GetToken(wo.cliConnection)
+		}
+
+		now := time.Now()
+		var view *ViewInstanceResp
+		if err == nil {
+			view, err = fetchView(wo.authenticatedClient, serviceInstanceManagementParameters, accessToken)
+		}
+		if err != nil {
+			// Keep watching through transient failures once the service instance has been viewed.
+			if refresh == 1 {
+				return "", err
+			}
+			fmt.Fprintf(wo.writer, "%s\n", format.Red("Refresh failed at %s: %s", now.Local().Format("15:04:05"), err))
+			continue
+		}
+		history.record(view, now)
+
+		output, err := RenderView(view)
+		if err != nil {
+			return "", err
+		}
+		if !color.NoColor {
+			fmt.Fprint(wo.writer, clearScreen)
+		}
+		fmt.Fprintf(wo.writer, "Every %s, refreshed at %s. Press Ctrl-C to stop.\n%s%s", wo.interval, now.Local().Format("15:04:05"), output, history.render(view))
+	}
+	return "", nil
+}
+
+func (wo *watchOperation) IsServiceBrokerOperation() bool {
+	return true
+}
+
+// usageSummary keeps the minimum, total and maximum of a series of samples without keeping the samples.
+type usageSummary struct {
+	min   float64
+	max   float64
+	total float64
+	count int
+}
+
+func (us *usageSummary) add(sample float64) {
+	if us.count == 0 || sample < us.min {
+		us.min = sample
+	}
+	if us.count == 0 || sample > us.max {
+		us.max = sample
+	}
+	us.total += sample
+	us.count++
+}
+
+func (us *usageSummary) average() float64 {
+	return us.total / float64(us.count)
+}
+
+type instanceHistory struct {
+	cpu           []float64
+	memory        []int64
+	cpuSummary    usageSummary
+	memorySummary usageSummary
+	memoryQuota   int64
+	state         string
+	since         int64
+	restarts      int
+	change        string
+	changed       bool
+}
+
+// viewHistory summarises the usage of each instance since watching started, but holds the samples of at most the last
+// sparklineWidth refreshes, for the sparklines, so that a long watch does not keep growing it.
+type viewHistory struct {
+	started   time.Time
+	instances map[string]*instanceHistory
+}
+
+func newViewHistory() *viewHistory {
+	return &viewHistory{
+		instances: map[string]*instanceHistory{},
+	}
+}
+
+func instanceKey(backingApp BackingApp, backingAI BackingAppInstance) string {
+	return fmt.Sprintf("%s#%d", backingApp.Name, backingAI.Index)
+}
+
+// record adds the usage of each instance to its history and notes whether the instance has restarted, which moves its
+// since time, or changed state since the previous refresh.
+func (vh *viewHistory) record(view *ViewInstanceResp, now time.Time) {
+	at := now.Local().Format("15:04:05")
+	if vh.started.IsZero() {
+		vh.started = now
+	}
+	for _, backingApp := range view.BackingApps {
+		for _, backingAI := range backingApp.Instances {
+			key := instanceKey(backingApp, backingAI)
+			history, seen := vh.instances[key]
+			if !seen {
+				history = &instanceHistory{state: backingAI.State, since: backingAI.Since}
+				vh.instances[key] = history
+			}
+
+			history.changed = false
+			if backingAI.Since != history.since {
+				history.restarts++
+				history.change = fmt.Sprintf("restarted, now %s at %s", strings.ToLower(backingAI.State), at)
+				history.changed = true
+			} else if backingAI.State != history.state {
+				history.change = fmt.Sprintf("%s -> %s at %s", strings.ToLower(history.state), strings.ToLower(backingAI.State), at)
+				history.changed = true
+			}
+			history.state = backingAI.State
+			history.since = backingAI.Since
+
+			history.cpu = append(history.cpu, backingAI.CPU)
+			history.memory = append(history.memory, backingAI.MemoryUsage)
+			if len(history.cpu) > sparklineWidth {
+				history.cpu = history.cpu[1:]
+				history.memory = history.memory[1:]
+			}
+			history.cpuSummary.add(backingAI.CPU)
+			history.memorySummary.add(float64(backingAI.MemoryUsage))
+			history.memoryQuota = backingAI.MemoryQuota
+		}
+	}
+}
+
+func (vh *viewHistory) render(view *ViewInstanceResp) string {
+	var buffer bytes.Buffer
+	for _, backingApp := range view.BackingApps {
+		buffer.WriteString(fmt.Sprintf(`
+%s trends since %s:
+
+     cpu min/avg/max         cpu trend            memory min/avg/max     memory trend         restarts last change
+`, backingApp.Name, vh.started.Local().Format("15:04:05")))
+
+		for _, backingAI := range backingApp.Instances {
+			history := vh.instances[instanceKey(backingApp, backingAI)]
+			cpu, memory := history.cpuSummary, history.memorySummary
+
+			change := history.change
+			if history.changed {
+				change = format.Red(change)
+			}
+			buffer.WriteString(fmt.Sprintf(`#%-3d %-23s %-20s %-22s %-20s %-8d %s
+`,
+				backingAI.Index,
+				fmt.Sprintf("%.1f%%/%.1f%%/%.1f%%", 100*cpu.min, 100*cpu.average(), 100*cpu.max),
+				sparkline(history.cpu, cpu.max),
+				fmt.Sprintf("%s/%s/%s", byteSize(int64(memory.min)), byteSize(int64(memory.average())), byteSize(int64(memory.max))),
+				sparkline(toFloats(history.memory), float64(history.memoryQuota)),
+				history.restarts,
+				change))
+		}
+	}
+	return buffer.String()
+}
+
+func toFloats(samples []int64) []float64 {
+	floats := make([]float64, len(samples))
+	for i, sample := range samples {
+		floats[i] = float64(sample)
+	}
+	return floats
+}
+
+// sparkline renders the samples as bars whose heights are relative to the given ceiling.
+func sparkline(samples []float64, ceiling float64) string {
+	line := make([]rune, len(samples))
+	for i, sample := range samples {
+		level := 0
+		if ceiling > 0 {
+			level = int(sample / ceiling * float64(len(sparklineLevels)-1))
+		}
+		if level < 0 {
+			level = 0
+		}
+		if level >= len(sparklineLevels) {
+			level = len(sparklineLevels) - 1
+		}
+		line[i] = sparklineLevels[level]
+	}
+	return string(line)
+}
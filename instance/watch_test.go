@@ -0,0 +1,180 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package instance_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/plugin/pluginfakes"
+	"github.com/fatih/color"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/httpclient/httpclientfakes"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/instance"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/serviceutil"
+)
+
+var _ = Describe("WatchOperation", func() {
+
+	const (
+		testAccessToken = "someaccesstoken"
+		first           = `{"backing_apps": [{"name": "config-server", "requested_state": "STARTED", "num_instances": 1, "running_instances": 1, "instances": [{"index": 0, "state": "RUNNING", "since": 1000, "cpu": 0.1, "memory_usage": 268435456, "memory_quota": 1073741824}]}]}`
+		second          = `{"backing_apps": [{"name": "config-server", "requested_state": "STARTED", "num_instances": 1, "running_instances": 1, "instances": [{"index": 0, "state": "RUNNING", "since": 1000, "cpu": 0.3, "memory_usage": 536870912, "memory_quota": 1073741824}]}]}`
+		crashed         = `{"backing_apps": [{"name": "config-server", "requested_state": "STARTED", "num_instances": 1, "running_instances": 0, "instances": [{"index": 0, "state": "CRASHED", "since": 1000, "cpu": 0.0, "memory_usage": 0, "memory_quota": 1073741824}]}]}`
+		restarted       = `{"backing_apps": [{"name": "config-server", "requested_state": "STARTED", "num_instances": 1, "running_instances": 0, "instances": [{"index": 0, "state": "STARTING", "since": 5000, "cpu": 0.2, "memory_usage": 1073741824, "memory_quota": 1073741824}]}]}`
+	)
+
+	var (
+		fakeCliConnection *pluginfakes.FakeCliConnection
+		fakeAuthClient    *httpclientfakes.FakeAuthenticatedClient
+		views             []string
+		writer            *bytes.Buffer
+		output            string
+		err               error
+	)
+
+	BeforeEach(func() {
+		color.NoColor = true
+		fakeCliConnection = &pluginfakes.FakeCliConnection{}
+		fakeCliConnection.AccessTokenStub = func() (string, error) {
+			return fmt.Sprintf("bearer token-%d", fakeCliConnection.AccessTokenCallCount()), nil
+		}
+		fakeAuthClient = &httpclientfakes.FakeAuthenticatedClient{}
+		fakeAuthClient.DoAuthenticatedGetStub = func(url string, accessToken string) (io.ReadCloser, int, error) {
+			view := views[0]
+			views = views[1:]
+			if view == "" {
+				return nil, http.StatusBadGateway, nil
+			}
+			return ioutil.NopCloser(strings.NewReader(view)), http.StatusOK, nil
+		}
+		writer = &bytes.Buffer{}
+	})
+
+	JustBeforeEach(func() {
+		refreshes := len(views)
+		output, err = instance.NewWatchOperation(fakeCliConnection, fakeAuthClient, time.Millisecond, refreshes, writer).Run(serviceutil.ManagementParameters{Url: "https://some.host/cli/instances/someguid"}, testAccessToken)
+	})
+
+	Context("when the instances keep running", func() {
+		BeforeEach(func() {
+			views = []string{first, second}
+		})
+
+		It("refreshes the view and shows the usage trends", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output).To(BeEmpty())
+			Expect(fakeAuthClient.DoAuthenticatedGetCallCount()).To(Equal(2))
+
+			frames := strings.Split(writer.String(), "Every 1ms, refreshed at ")
+			Expect(frames).To(HaveLen(3))
+			Expect(frames[1]).To(ContainSubstring("backing app name: config-server\n"))
+			Expect(frames[1]).To(ContainSubstring("#0   10.0%/10.0%/10.0%       █                    256M/256M/256M         ▂                    0        \n"))
+			Expect(frames[2]).To(ContainSubstring("#0   10.0%/20.0%/30.0%       ▃█                   256M/384M/512M         ▂▄                   0        \n"))
+		})
+
+		It("obtains a fresh access token for each later refresh", func() {
+			_, accessToken := fakeAuthClient.DoAuthenticatedGetArgsForCall(0)
+			Expect(accessToken).To(Equal(testAccessToken))
+			_, accessToken = fakeAuthClient.DoAuthenticatedGetArgsForCall(1)
+			Expect(accessToken).To(Equal("token-1"))
+		})
+	})
+
+	Context("when watching for longer than the trends show", func() {
+		BeforeEach(func() {
+			views = []string{first}
+			for i := 0; i < 20; i++ {
+				views = append(views, second)
+			}
+		})
+
+		It("keeps only the most recent samples for the trends but summarises the usage since watching started", func() {
+			Expect(err).NotTo(HaveOccurred())
+
+			frames := strings.Split(writer.String(), "Every 1ms, refreshed at ")
+			Expect(frames).To(HaveLen(22))
+			Expect(frames[20]).To(ContainSubstring("#0   10.0%/29.0%/30.0%       ▃" + strings.Repeat("█", 19) + " "))
+			Expect(frames[21]).To(ContainSubstring("config-server trends since " + frames[1][:8] + ":\n"))
+			Expect(frames[21]).To(ContainSubstring("#0   10.0%/29.0%/30.0%       " + strings.Repeat("█", 20) + " 256M/499.8M/512M       " + strings.Repeat("▄", 20) + " 0        \n"))
+		})
+	})
+
+	Context("when an instance crashes and restarts", func() {
+		BeforeEach(func() {
+			views = []string{first, crashed, restarted, restarted}
+		})
+
+		It("highlights the changes and counts the restarts", func() {
+			Expect(err).NotTo(HaveOccurred())
+
+			frames := strings.Split(writer.String(), "Every 1ms, refreshed at ")
+			Expect(frames).To(HaveLen(5))
+			Expect(frames[2]).To(MatchRegexp(`0        running -> crashed at \d\d:\d\d:\d\d\n`))
+			Expect(frames[3]).To(MatchRegexp(`1        restarted, now starting at \d\d:\d\d:\d\d\n`))
+			Expect(frames[4]).To(MatchRegexp(`1        restarted, now starting at \d\d:\d\d:\d\d\n`))
+		})
+	})
+
+	Context("when a refresh fails", func() {
+		BeforeEach(func() {
+			views = []string{first, "", second}
+		})
+
+		It("reports the failure and keeps watching", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(writer.String()).To(MatchRegexp(`Refresh failed at \d\d:\d\d:\d\d: Service broker view instance failed: 502\n`))
+			Expect(fakeAuthClient.DoAuthenticatedGetCallCount()).To(Equal(3))
+		})
+	})
+
+	Context("when an access token cannot be obtained", func() {
+		BeforeEach(func() {
+			views = []string{first, second, second}
+			fakeCliConnection.AccessTokenStub = func() (string, error) {
+				if fakeCliConnection.AccessTokenCallCount() == 1 {
+					return "", errors.New("not logged in")
+				}
+				return "bearer token", nil
+			}
+		})
+
+		It("reports the failure and keeps watching", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(writer.String()).To(MatchRegexp(`Refresh failed at \d\d:\d\d:\d\d: Access token not available: not logged in\n`))
+			Expect(fakeAuthClient.DoAuthenticatedGetCallCount()).To(Equal(2))
+		})
+	})
+
+	Context("when the first refresh fails", func() {
+		BeforeEach(func() {
+			views = []string{""}
+		})
+
+		It("returns the error", func() {
+			Expect(err).To(MatchError("Service broker view instance failed: 502"))
+			Expect(writer.String()).To(BeEmpty())
+		})
+	})
+})
@@ -20,6 +20,8 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"io"
 
@@ -34,6 +36,9 @@ import (
 	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/serviceutil"
 )
 
+// Interval between polls when waiting for a configuration server or service instance to reach a desired state.
+const pollInterval = 5 * time.Second
+
 // Plugin version. Substitute "<major>.<minor>.<build>" at build time, e.g. using -ldflags='-X main.pluginVersion=1.2.3'
 var pluginVersion = "invalid version - plugin was not built correctly"
 
@@ -43,13 +48,79 @@ type Plugin struct{}
 
 func (c *Plugin) Run(cliConnection plugin.CliConnection, args []string) {
 	var cfInstanceIndex *int = nil
-	var fileToEncrypt string
+	var encryptFlags cli.EncryptFlags
+	var outputFormat string
+	var diffFlags cli.DiffFlags
+	var reveal bool
+	var syncMirrorsFlags cli.SyncMirrorsFlags
+	var addSecretFlags cli.AddSecretFlags
+	var credHubPathFlags cli.CredHubPathFlags
+	var rotateSecretFlags cli.RotateSecretFlags
+	var importSecretsFlags cli.ImportSecretsFlags
+	var backupFlags cli.BackupFlags
+	var fixWith string
+	var exportEnvFlags cli.ExportEnvFlags
+	var compareFlags cli.CompareFlags
+	var lifecycleFlags cli.LifecycleFlags
+	var viewFlags cli.ViewFlags
+	var allSpaces bool
+	var configurationFlags cli.ConfigurationFlags
+	var configurationDiffFlags cli.ConfigurationDiffFlags
+	var configurationSetFlags cli.ConfigurationSetFlags
+	var cloneFlags cli.CloneFlags
+	var exportManifestFlags cli.ExportManifestFlags
+	var applyManifestFlags cli.ApplyManifestFlags
 	var positionalArgs []string
 	var err error
-	if args[0] == "config-server-encrypt-value" {
+	switch args[0] {
+	case "config-server-encrypt-value":
 		// Enable encryption of a value starting with "-".
-		fileToEncrypt, positionalArgs, err = cli.ParseStringFlags(args)
-	} else {
+		encryptFlags, positionalArgs, err = cli.ParseEncryptFlags(args)
+	case "config-server-sync-mirrors":
+		syncMirrorsFlags, positionalArgs, err = cli.ParseSyncMirrorsFlags(args)
+	case "config-server-get":
+		outputFormat, positionalArgs, err = cli.ParseFormatFlags(args)
+	case "config-server-diff":
+		diffFlags, positionalArgs, err = cli.ParseDiffFlags(args)
+	case "config-server-explain", "config-server-list-credhub-secrets", "config-server-get-credhub-secret":
+		reveal, positionalArgs, err = cli.ParseRevealFlags(args)
+	case "config-server-add-credhub-secret":
+		addSecretFlags, positionalArgs, err = cli.ParseAddSecretFlags(args)
+	case "config-server-remove-credhub-secret":
+		credHubPathFlags, positionalArgs, err = cli.ParseRemoveSecretFlags(args)
+	case "config-server-rotate-credhub-secret":
+		rotateSecretFlags, positionalArgs, err = cli.ParseRotateSecretFlags(args)
+	case "config-server-import-credhub-secrets":
+		importSecretsFlags, positionalArgs, err = cli.ParseImportSecretsFlags(args)
+	case "config-server-export-credhub-secrets":
+		backupFlags, positionalArgs, err = cli.ParseExportSecretsFlags(args)
+	case "config-server-restore-credhub-secrets":
+		backupFlags, positionalArgs, err = cli.ParseRestoreSecretsFlags(args)
+	case "config-server-compare":
+		compareFlags, positionalArgs, err = cli.ParseCompareFlags(args)
+	case "config-server-export-env":
+		exportEnvFlags, positionalArgs, err = cli.ParseExportEnvFlags(args)
+	case "config-server-lint":
+		fixWith, positionalArgs, err = cli.ParseLintFlags(args)
+	case "spring-cloud-service-stop", "spring-cloud-service-start", "spring-cloud-service-restart", "spring-cloud-service-restage":
+		lifecycleFlags, positionalArgs, err = cli.ParseLifecycleFlags(args)
+	case "spring-cloud-service-view":
+		viewFlags, positionalArgs, err = cli.ParseViewFlags(args)
+	case "spring-cloud-service-list":
+		allSpaces, positionalArgs, err = cli.ParseListFlags(args)
+	case "spring-cloud-service-configuration":
+		configurationFlags, positionalArgs, err = cli.ParseConfigurationFlags(args)
+	case "spring-cloud-service-configuration-diff":
+		configurationDiffFlags, positionalArgs, err = cli.ParseConfigurationDiffFlags(args)
+	case "spring-cloud-service-configuration-set":
+		configurationSetFlags, positionalArgs, err = cli.ParseConfigurationSetFlags(args)
+	case "spring-cloud-service-clone":
+		cloneFlags, positionalArgs, err = cli.ParseCloneFlags(args)
+	case "spring-cloud-service-export":
+		exportManifestFlags, positionalArgs, err = cli.ParseExportManifestFlags(args)
+	case "spring-cloud-service-apply":
+		applyManifestFlags, positionalArgs, err = cli.ParseApplyManifestFlags(args)
+	default:
 		cfInstanceIndex, positionalArgs, err = cli.ParseFlags(args)
 	}
 	if err != nil {
@@ -80,82 +151,638 @@ func (c *Plugin) Run(cliConnection plugin.CliConnection, args []string) {
 	switch args[0] {
 
 	case "config-server-encrypt-value":
-		configServerInstanceName := getConfigServerInstanceName(argsConsumer)
-		plainText := getPlainText(argsConsumer)
+		fileToEncrypt := encryptFlags.FileToEncrypt
+		var configServerInstanceName, plainText string
+		if encryptFlags.Offline {
+			plainText = getOfflinePlainText(argsConsumer)
+		} else {
+			configServerInstanceName = getConfigServerInstanceName(argsConsumer)
+			plainText = getPlainText(argsConsumer)
+		}
 
 		if (plainText == "" && fileToEncrypt == "") || (plainText != "" && fileToEncrypt != "") {
 			diagnoseWithHelp(fmt.Sprintf("Provide either VALUE_TO_ENCRYPT or the --file-to-encrypt flag, but not both."), "config-server-encrypt-value")
 		}
+		if encryptFlags.Offline != (encryptFlags.PublicKey != "") {
+			diagnoseWithHelp("Provide the --offline and --public-key flags together.", "config-server-encrypt-value")
+		}
 
 		runActionQuietly(argsConsumer, cliConnection, func() (string, error) {
+			valueEncrypter := encrypter
+			if encryptFlags.Offline {
+				publicKey, err := config.ReadPublicKeyFile(encryptFlags.PublicKey)
+				if err != nil {
+					return "", err
+				}
+				valueEncrypter = config.NewOfflineEncrypter(publicKey)
+			}
 			if fileToEncrypt != "" {
-				return encrypter.EncryptFile(configServerInstanceName, fileToEncrypt)
+				return valueEncrypter.EncryptFile(configServerInstanceName, fileToEncrypt)
 			} else {
-				return encrypter.EncryptString(configServerInstanceName, plainText)
+				return valueEncrypter.EncryptString(configServerInstanceName, plainText)
 			}
 		})
 
-	case "config-server-sync-mirrors":
+	case "config-server-key-info":
 		configServerInstanceName := getConfigServerInstanceName(argsConsumer)
+		applicationName := getOptionalApplicationName(argsConsumer)
+		profile := getOptionalProfile(argsConsumer, 3)
 
 		runActionQuietly(argsConsumer, cliConnection, func() (string, error) {
+			keyInfo, err := config.NewKeyInfoFetcher(cliConnection, authClient, serviceInstanceUrlResolver).Fetch(configServerInstanceName, applicationName, profile)
+			if err != nil {
+				return "", err
+			}
+			return config.RenderKeyInfo(keyInfo)
+		})
+
+	case "config-server-get":
+		configServerInstanceName := getConfigServerInstanceName(argsConsumer)
+		applicationName := getApplicationName(argsConsumer)
+		profile := getOptionalProfile(argsConsumer, 3)
+		label := getOptionalLabel(argsConsumer, 4)
+
+		runActionQuietly(argsConsumer, cliConnection, func() (string, error) {
+			environmentFetcher := config.NewEnvironmentFetcher(cliConnection, authClient, serviceInstanceUrlResolver)
+			if outputFormat != "" {
+				return environmentFetcher.FetchFormatted(configServerInstanceName, applicationName, profile, label, outputFormat)
+			}
+			environment, err := environmentFetcher.Fetch(configServerInstanceName, applicationName, profile, label)
+			if err != nil {
+				return "", err
+			}
+			return config.RenderEnvironment(environment), nil
+		})
+
+	case "config-server-export-env":
+		configServerInstanceName := getConfigServerInstanceName(argsConsumer)
+		applicationName := getApplicationName(argsConsumer)
+		profile := getOptionalProfile(argsConsumer, 3)
+		label := getOptionalLabel(argsConsumer, 4)
+
+		runActionQuietly(argsConsumer, cliConnection, func() (string, error) {
+			environmentFetcher := config.NewEnvironmentFetcher(cliConnection, authClient, serviceInstanceUrlResolver)
+			environment, err := environmentFetcher.Fetch(configServerInstanceName, applicationName, profile, label)
+			if err != nil {
+				return "", err
+			}
+			contents, err := config.ExportEnvironment(environment, exportEnvFlags.Format, exportEnvFlags.ExcludeSecrets)
+			if err != nil {
+				return "", err
+			}
+			if exportEnvFlags.File == "" {
+				return contents, nil
+			}
+			err = config.WriteEnvironmentFile(exportEnvFlags.File, contents)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Exported the configuration of %s to %s", applicationName, exportEnvFlags.File), nil
+		})
+
+	case "config-server-diff":
+		from := config.EnvironmentCoordinates{
+			ConfigServerInstanceName: getConfigServerInstanceName(argsConsumer),
+			Application:              getApplicationName(argsConsumer),
+			Profile:                  getOptionalProfile(argsConsumer, 3),
+			Label:                    getOptionalLabel(argsConsumer, 4),
+		}
+		to := from
+		if diffFlags.ToConfigServer != "" {
+			to.ConfigServerInstanceName = diffFlags.ToConfigServer
+		}
+		if diffFlags.ToProfile != "" {
+			to.Profile = diffFlags.ToProfile
+		}
+		if diffFlags.ToLabel != "" {
+			to.Label = diffFlags.ToLabel
+		}
+		if to == from {
+			diagnoseWithHelp("Provide at least one of the --to-config-server, --to-profile or --to-label flags.", "config-server-diff")
+		}
+
+		runActionQuietly(argsConsumer, cliConnection, func() (string, error) {
+			environmentFetcher := config.NewEnvironmentFetcher(cliConnection, authClient, serviceInstanceUrlResolver)
+			fromEnvironment, err := config.FetchEnvironment(environmentFetcher, from)
+			if err != nil {
+				return "", err
+			}
+			toEnvironment, err := config.FetchEnvironment(environmentFetcher, to)
+			if err != nil {
+				return "", err
+			}
+			return config.RenderEnvironmentDiff(from, to, config.DiffEnvironments(fromEnvironment, toEnvironment), diffFlags.Reveal), nil
+		})
+
+	case "config-server-compare":
+		fromConfigServer := getConfigServerInstanceName(argsConsumer)
+		toConfigServer := getOtherConfigServerInstanceName(argsConsumer)
+
+		if len(compareFlags.Environments) == 0 && compareFlags.EnvironmentsFile == "" {
+			diagnoseWithHelp("Provide the --environment or --environments-file flag.", "config-server-compare")
+		}
+		if compareFlags.DryRun && !compareFlags.CopySecrets {
+			diagnoseWithHelp("The --dry-run flag requires the --copy-secrets flag.", "config-server-compare")
+		}
+
+		runActionQuietly(argsConsumer, cliConnection, func() (string, error) {
+			environments, err := config.ParseEnvironmentSpecs(compareFlags.Environments, compareFlags.EnvironmentsFile)
+			if err != nil {
+				return "", err
+			}
+			environmentFetcher := config.NewEnvironmentFetcher(cliConnection, authClient, serviceInstanceUrlResolver)
+			comparisons := config.CompareConfigServers(environmentFetcher, fromConfigServer, toConfigServer, environments)
+
+			var parameterDifferences []config.PropertyDifference
+			if compareFlags.Parameters {
+				fromParameters, err := operationRunner.RunOperation(fromConfigServer, instance.NewParametersOperation(authClient))
+				if err != nil {
+					return "", err
+				}
+				toParameters, err := operationRunner.RunOperation(toConfigServer, instance.NewParametersOperation(authClient))
+				if err != nil {
+					return "", err
+				}
+				parameterDifferences, err = instance.DiffParameters(fromConfigServer, fromParameters, toConfigServer, toParameters)
+				if err != nil {
+					return "", err
+				}
+			}
+			output, err := config.RenderConfigServerComparison(fromConfigServer, toConfigServer, comparisons, parameterDifferences, compareFlags.Reveal)
+			if err != nil || !compareFlags.CopySecrets {
+				return output, err
+			}
+
+			copies := config.CopySecrets(environmentFetcher, config.NewCredHubSecret(cliConnection, authClient, serviceInstanceUrlResolver), fromConfigServer, toConfigServer, environments, compareFlags.DryRun)
+			copyOutput, err := config.RenderSecretsCopies(fromConfigServer, toConfigServer, copies, compareFlags.DryRun)
+			if err != nil {
+				return "", fmt.Errorf("%s\n\n%s", output, err)
+			}
+			return output + "\n\n" + copyOutput, nil
+		})
+
+	case "config-server-explain":
+		configServerInstanceName := getConfigServerInstanceName(argsConsumer)
+		applicationName := getApplicationName(argsConsumer)
+		profile := getProfile(argsConsumer, 3)
+		propertyKey := getPropertyKey(argsConsumer, 4)
+
+		runActionQuietly(argsConsumer, cliConnection, func() (string, error) {
+			environmentFetcher := config.NewEnvironmentFetcher(cliConnection, authClient, serviceInstanceUrlResolver)
+			environment, err := environmentFetcher.Fetch(configServerInstanceName, applicationName, profile, "")
+			if err != nil {
+				return "", err
+			}
+			return config.RenderPropertyOrigins(environment, propertyKey, reveal)
+		})
+
+	case "config-server-sync-mirrors":
+		configServerInstanceName := getConfigServerInstanceName(argsConsumer)
+		if syncMirrorsFlags.Wait && syncMirrorsFlags.Application == "" {
+			diagnoseWithHelp("The --wait flag requires the --application flag.", "config-server-sync-mirrors")
+		}
+		if syncMirrorsFlags.Wait && len(syncMirrorsFlags.Repositories) > 1 {
+			diagnoseWithHelp("The --wait flag accepts at most one --repository flag.", "config-server-sync-mirrors")
+		}
+
+		if !syncMirrorsFlags.Wait {
+			runActionQuietly(argsConsumer, cliConnection, func() (string, error) {
+				refresher := config.NewRefresher(cliConnection, authClient, serviceInstanceUrlResolver)
+				mirrors, err := refresher.Refresh(configServerInstanceName, syncMirrorsFlags.Repositories)
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("Successfully refreshed mirrors\n\n%s", config.RenderMirrorRefreshes(mirrors)), nil
+			})
+			break
+		}
+
+		runAction(argsConsumer, cliConnection, fmt.Sprintf("Synchronizing mirrors of configuration server %s", format.Bold(format.Cyan(configServerInstanceName))), func(progressWriter io.Writer) (string, error) {
 			refresher := config.NewRefresher(cliConnection, authClient, serviceInstanceUrlResolver)
-			return "Successfully refreshed mirrors", refresher.Refresh(configServerInstanceName)
+			mirrors, err := refresher.Refresh(configServerInstanceName, syncMirrorsFlags.Repositories)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(progressWriter, "Successfully refreshed mirrors\n\n%s\n", config.RenderMirrorRefreshes(mirrors))
+
+			commitWaiter := config.NewCommitWaiter(config.NewEnvironmentFetcher(cliConnection, authClient, serviceInstanceUrlResolver),
+				time.Duration(syncMirrorsFlags.TimeoutSeconds)*time.Second, pollInterval)
+			return "", commitWaiter.WaitForCommit(config.EnvironmentCoordinates{
+				ConfigServerInstanceName: configServerInstanceName,
+				Application:              syncMirrorsFlags.Application,
+				Profile:                  syncMirrorsFlags.Profile,
+				Label:                    syncMirrorsFlags.Label,
+			}, config.CommitIds(mirrors), progressWriter)
 		})
 
 	case "config-server-add-credhub-secret":
 		configServerInstanceName := getConfigServerInstanceName(argsConsumer)
-		configServerCredHubPath := getConfigServerCredHubPath(argsConsumer)
-		configServerCredHubSecret := getConfigServerCredHubSecret(argsConsumer)
+		configServerCredHubPath, secretArg := getConfigServerCredHubPathOrFlags(argsConsumer, addSecretFlags.Path)
+		configServerCredHubSecret := getConfigServerCredHubSecret(argsConsumer, secretArg)
+		secretFile := addSecretFlags.File
+
+		if (configServerCredHubSecret == "" && secretFile == "") || (configServerCredHubSecret != "" && secretFile != "") {
+			diagnoseWithHelp("Provide either JSON_SECRET or the --file flag, but not both.", "config-server-add-credhub-secret")
+		}
 
 		runActionQuietly(argsConsumer, cliConnection, func() (string, error) {
+			credHubPath, err := resolveCredHubPath(configServerCredHubPath, addSecretFlags.Path)
+			if err != nil {
+				return "", err
+			}
+			if secretFile != "" {
+				configServerCredHubSecret, err = config.ReadSecretContents(secretFile, os.Stdin)
+				if err != nil {
+					return "", err
+				}
+			}
 			credHubSecret := config.NewCredHubSecret(cliConnection, authClient, serviceInstanceUrlResolver)
-			return "Successfully added secret", credHubSecret.Add(configServerInstanceName, configServerCredHubPath, configServerCredHubSecret)
+			return "Successfully added secret", credHubSecret.Add(configServerInstanceName, credHubPath, configServerCredHubSecret)
 		})
 
 	case "config-server-remove-credhub-secret":
+		configServerInstanceName := getConfigServerInstanceName(argsConsumer)
+		configServerCredHubPath, _ := getConfigServerCredHubPathOrFlags(argsConsumer, credHubPathFlags)
+
+		runActionQuietly(argsConsumer, cliConnection, func() (string, error) {
+			credHubPath, err := resolveCredHubPath(configServerCredHubPath, credHubPathFlags)
+			if err != nil {
+				return "", err
+			}
+			credHubSecret := config.NewCredHubSecret(cliConnection, authClient, serviceInstanceUrlResolver)
+			return "Successfully removed secret", credHubSecret.Remove(configServerInstanceName, credHubPath)
+		})
+
+	case "config-server-rotate-credhub-secret":
+		configServerInstanceName := getConfigServerInstanceName(argsConsumer)
+		configServerCredHubPath, valueArg := getConfigServerCredHubPathOrFlags(argsConsumer, rotateSecretFlags.Path)
+		newValue := getNewSecretValue(argsConsumer, valueArg)
+
+		valueSources := 0
+		for _, given := range []bool{newValue != "", rotateSecretFlags.Generate, rotateSecretFlags.File != ""} {
+			if given {
+				valueSources++
+			}
+		}
+		if valueSources != 1 {
+			diagnoseWithHelp("Provide exactly one of NEW_VALUE, the --generate flag or the --file flag.", "config-server-rotate-credhub-secret")
+		}
+
+		runAction(argsConsumer, cliConnection, fmt.Sprintf("Rotating secret in configuration server %s", format.Bold(format.Cyan(configServerInstanceName))), func(progressWriter io.Writer) (string, error) {
+			credHubPath, err := resolveCredHubPath(configServerCredHubPath, rotateSecretFlags.Path)
+			if err != nil {
+				return "", err
+			}
+			if rotateSecretFlags.Generate {
+				newValue, err = config.GeneratePassword(rotateSecretFlags.Length)
+			} else if rotateSecretFlags.File != "" {
+				newValue, err = config.ReadSecretContents(rotateSecretFlags.File, os.Stdin)
+				newValue = strings.TrimRight(newValue, "\r\n")
+			}
+			if err != nil {
+				return "", err
+			}
+
+			credHubSecret := config.NewCredHubSecret(cliConnection, authClient, serviceInstanceUrlResolver)
+			err = credHubSecret.Rotate(configServerInstanceName, credHubPath, rotateSecretFlags.Key, newValue)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(progressWriter, "Rotated secret %s\n", credHubPath)
+
+			rollouts := []config.AppRollout{}
+			for _, app := range rotateSecretFlags.Restart {
+				rollouts = append(rollouts, config.AppRollout{App: app, Action: config.RestartApp})
+			}
+			for _, app := range rotateSecretFlags.Refresh {
+				rollouts = append(rollouts, config.AppRollout{App: app, Action: config.RefreshApp})
+			}
+			if len(rollouts) == 0 {
+				return "", nil
+			}
+			rollouts, err = config.NewAppRoller(cliConnection, client).Roll(configServerInstanceName, rollouts, progressWriter)
+			if err != nil {
+				return "", fmt.Errorf("The secret was rotated, but %s", err)
+			}
+			return config.RenderAppRollouts(rollouts), nil
+		})
+
+	case "config-server-list-credhub-secrets":
+		configServerInstanceName := getConfigServerInstanceName(argsConsumer)
+		environmentPath := getOptionalEnvironmentPath(argsConsumer)
+
+		runActionQuietly(argsConsumer, cliConnection, func() (string, error) {
+			credHubSecret := config.NewCredHubSecret(cliConnection, authClient, serviceInstanceUrlResolver)
+			properties, err := credHubSecret.List(configServerInstanceName, environmentPath)
+			if err != nil {
+				return "", err
+			}
+			return config.RenderCredHubSecrets(properties, reveal), nil
+		})
+
+	case "config-server-get-credhub-secret":
 		configServerInstanceName := getConfigServerInstanceName(argsConsumer)
 		configServerCredHubPath := getConfigServerCredHubPath(argsConsumer)
 
 		runActionQuietly(argsConsumer, cliConnection, func() (string, error) {
 			credHubSecret := config.NewCredHubSecret(cliConnection, authClient, serviceInstanceUrlResolver)
-			return "Successfully removed secret", credHubSecret.Remove(configServerInstanceName, configServerCredHubPath)
+			properties, err := credHubSecret.Get(configServerInstanceName, configServerCredHubPath)
+			if err != nil {
+				return "", err
+			}
+			return config.RenderCredHubSecrets(properties, reveal), nil
+		})
+
+	case "config-server-import-credhub-secrets":
+		configServerInstanceName := getConfigServerInstanceName(argsConsumer)
+		applicationName := getApplicationName(argsConsumer)
+		profile := getProfile(argsConsumer, 3)
+		label := getLabel(argsConsumer, 4)
+
+		if importSecretsFlags.File == "" {
+			diagnoseWithHelp("Provide the --file flag.", "config-server-import-credhub-secrets")
+		}
+
+		runActionQuietly(argsConsumer, cliConnection, func() (string, error) {
+			secrets, err := config.ReadSecretsFile(importSecretsFlags.File)
+			if err != nil {
+				return "", err
+			}
+			credHubSecret := config.NewCredHubSecret(cliConnection, authClient, serviceInstanceUrlResolver)
+			imports, err := credHubSecret.Import(configServerInstanceName, applicationName, profile, label, secrets, importSecretsFlags.DryRun)
+			if err != nil {
+				return "", err
+			}
+			return config.RenderSecretImports(imports, importSecretsFlags.DryRun), nil
+		})
+
+	case "config-server-export-credhub-secrets":
+		configServerInstanceName := getConfigServerInstanceName(argsConsumer)
+		applicationName := getApplicationName(argsConsumer)
+		profile := getProfile(argsConsumer, 3)
+		label := getLabel(argsConsumer, 4)
+
+		if backupFlags.File == "" {
+			diagnoseWithHelp("Provide the --file flag.", "config-server-export-credhub-secrets")
+		}
+
+		runActionQuietly(argsConsumer, cliConnection, func() (string, error) {
+			passphrase, err := config.ReadPassphrase(backupFlags.PassphraseFile)
+			if err != nil {
+				return "", err
+			}
+			credHubSecret := config.NewCredHubSecret(cliConnection, authClient, serviceInstanceUrlResolver)
+			backup, err := credHubSecret.Export(configServerInstanceName, applicationName, profile, label)
+			if err != nil {
+				return "", err
+			}
+			err = config.WriteCredHubBackup(backupFlags.File, backup, passphrase)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Exported %d secrets of %s to %s", len(backup.Secrets), backup, backupFlags.File), nil
+		})
+
+	case "config-server-lint":
+		lintPath := getLintPath(argsConsumer)
+
+		runActionQuietly(argsConsumer, cliConnection, func() (string, error) {
+			findings, err := config.LintPath(lintPath)
+			if err != nil {
+				return "", err
+			}
+			fix := fixWith != ""
+			if fix {
+				findings, err = config.FixLintFindings(findings, encrypter, fixWith)
+				if err != nil {
+					return "", err
+				}
+			}
+			err = config.CheckLintFindings(findings, fix)
+			if err != nil {
+				return "", err
+			}
+			return config.RenderLintFindings(findings, fix), nil
+		})
+
+	case "config-server-restore-credhub-secrets":
+		configServerInstanceName := getConfigServerInstanceName(argsConsumer)
+
+		if backupFlags.File == "" {
+			diagnoseWithHelp("Provide the --file flag.", "config-server-restore-credhub-secrets")
+		}
+
+		runActionQuietly(argsConsumer, cliConnection, func() (string, error) {
+			passphrase, err := config.ReadPassphrase(backupFlags.PassphraseFile)
+			if err != nil {
+				return "", err
+			}
+			backup, err := config.ReadCredHubBackup(backupFlags.File, passphrase)
+			if err != nil {
+				return "", err
+			}
+			credHubSecret := config.NewCredHubSecret(cliConnection, authClient, serviceInstanceUrlResolver)
+			imports, err := credHubSecret.Import(configServerInstanceName, backup.Application, backup.Profile, backup.Label, backup.Secrets, backupFlags.DryRun)
+			if err != nil {
+				return "", err
+			}
+			return config.RenderSecretImports(imports, backupFlags.DryRun), nil
 		})
 
 	case "spring-cloud-service-stop":
 		serviceInstanceName := getServiceInstanceName(argsConsumer)
 		runAction(argsConsumer, cliConnection, fmt.Sprintf("Stopping service instance %s", format.Bold(format.Cyan(serviceInstanceName))), func(progressWriter io.Writer) (string, error) {
-			return operationRunner.RunOperation(serviceInstanceName, instance.NewStopOperation(authClient))
+			return operationRunner.RunOperation(serviceInstanceName, waitForLifecycle(lifecycleFlags, cliConnection, instance.NewStopOperation(authClient), authClient, instance.StoppedState, false, progressWriter))
 		})
 
 	case "spring-cloud-service-start":
 		serviceInstanceName := getServiceInstanceName(argsConsumer)
 		runAction(argsConsumer, cliConnection, fmt.Sprintf("Starting service instance %s", format.Bold(format.Cyan(serviceInstanceName))), func(progressWriter io.Writer) (string, error) {
-			return operationRunner.RunOperation(serviceInstanceName, instance.NewStartOperation(authClient))
+			return operationRunner.RunOperation(serviceInstanceName, waitForLifecycle(lifecycleFlags, cliConnection, instance.NewStartOperation(authClient), authClient, instance.StartedState, false, progressWriter))
 		})
 
 	case "spring-cloud-service-restart":
 		serviceInstanceName := getServiceInstanceName(argsConsumer)
 		runAction(argsConsumer, cliConnection, fmt.Sprintf("Restarting service instance %s", format.Bold(format.Cyan(serviceInstanceName))), func(progressWriter io.Writer) (string, error) {
-			return operationRunner.RunOperation(serviceInstanceName, instance.NewRestartOperation(authClient))
+			return operationRunner.RunOperation(serviceInstanceName, waitForLifecycle(lifecycleFlags, cliConnection, instance.NewRestartOperation(authClient), authClient, instance.StartedState, true, progressWriter))
 		})
 
 	case "spring-cloud-service-restage":
 		serviceInstanceName := getServiceInstanceName(argsConsumer)
 		runAction(argsConsumer, cliConnection, fmt.Sprintf("Restaging service instance %s", format.Bold(format.Cyan(serviceInstanceName))), func(progressWriter io.Writer) (string, error) {
-			return operationRunner.RunOperation(serviceInstanceName, instance.NewRestageOperation(authClient))
+			return operationRunner.RunOperation(serviceInstanceName, waitForLifecycle(lifecycleFlags, cliConnection, instance.NewRestageOperation(authClient), authClient, instance.StartedState, true, progressWriter))
 		})
 
 	case "spring-cloud-service-view":
 		serviceInstanceName := getServiceInstanceName(argsConsumer)
+		if viewFlags.Watch {
+			if viewFlags.Output != "" {
+				diagnoseWithHelp("Provide either the --watch or the --output flag, but not both.", "spring-cloud-service-view")
+			}
+			if viewFlags.IntervalSeconds <= 0 {
+				diagnoseWithHelp("The --interval flag must be a positive number of seconds.", "spring-cloud-service-view")
+			}
+			runAction(argsConsumer, cliConnection, fmt.Sprintf("Watching service instance %s", format.Bold(format.Cyan(serviceInstanceName))), func(progressWriter io.Writer) (string, error) {
+				return operationRunner.RunOperation(serviceInstanceName, instance.NewWatchOperation(cliConnection, authClient, time.Duration(viewFlags.IntervalSeconds)*time.Second, 0, progressWriter))
+			})
+			break
+		}
+		if viewFlags.Output != "" {
+			// Keep the output machine readable.
+			runActionQuietly(argsConsumer, cliConnection, func() (string, error) {
+				return operationRunner.RunOperation(serviceInstanceName, instance.NewFormattedViewOperation(authClient, viewFlags.Output))
+			})
+			break
+		}
 		runAction(argsConsumer, cliConnection, fmt.Sprintf("Viewing service instance %s", format.Bold(format.Cyan(serviceInstanceName))), func(progressWriter io.Writer) (string, error) {
 			return operationRunner.RunOperation(serviceInstanceName, instance.NewViewOperation(authClient))
 		})
 
+	case "spring-cloud-service-list":
+		runAction(argsConsumer, cliConnection, "Listing Spring Cloud Services service instances", func(progressWriter io.Writer) (string, error) {
+			return instance.ListServiceInstances(cliConnection, serviceutil.NewServiceInstanceLister(cliConnection, authClient), authClient, allSpaces)
+		})
+
 	case "spring-cloud-service-configuration":
 		serviceInstanceName := getServiceInstanceName(argsConsumer)
 		runActionQuietly(argsConsumer, cliConnection, func() (string, error) {
-			return operationRunner.RunOperation(serviceInstanceName, instance.NewParametersOperation(authClient))
+			return operationRunner.RunOperation(serviceInstanceName, instance.NewFormattedParametersOperation(authClient, configurationFlags.Output, configurationFlags.Query, configurationFlags.Reveal))
+		})
+
+	case "spring-cloud-service-configuration-diff":
+		fromServiceInstanceName := getServiceInstanceName(argsConsumer)
+		toServiceInstanceName := getOptionalOtherServiceInstanceName(argsConsumer)
+		if (toServiceInstanceName == "") == (configurationDiffFlags.File == "") {
+			diagnoseWithHelp("Provide either SERVICE_INSTANCE_NAME_B or the --file flag, but not both.", "spring-cloud-service-configuration-diff")
+		}
+
+		runActionQuietly(argsConsumer, cliConnection, func() (string, error) {
+			fromParameters, err := operationRunner.RunOperation(fromServiceInstanceName, instance.NewParametersOperation(authClient))
+			if err != nil {
+				return "", err
+			}
+
+			to := toServiceInstanceName
+			var toParameters string
+			if configurationDiffFlags.File != "" {
+				to = configurationDiffFlags.File
+				toParameters, err = config.ReadFileContents(configurationDiffFlags.File)
+			} else {
+				toParameters, err = operationRunner.RunOperation(toServiceInstanceName, instance.NewParametersOperation(authClient))
+			}
+			if err != nil {
+				return "", err
+			}
+
+			differences, err := instance.DiffParameters(fromServiceInstanceName, fromParameters, to, toParameters)
+			if err != nil {
+				return "", err
+			}
+			return instance.RenderParametersDiff(fromServiceInstanceName, to, differences, configurationDiffFlags.Reveal), nil
+		})
+
+	case "spring-cloud-service-configuration-set":
+		serviceInstanceName := getServiceInstanceName(argsConsumer)
+		edits := getParameterEdits(argsConsumer)
+		if configurationSetFlags.File == "" && len(edits) == 0 {
+			diagnoseWithHelp("Provide the --file flag or at least one KEY.PATH=VALUE edit.", "spring-cloud-service-configuration-set")
+		}
+		if configurationSetFlags.TimeoutSeconds <= 0 {
+			diagnoseWithHelp("The --timeout flag must be a positive number of seconds.", "spring-cloud-service-configuration-set")
+		}
+
+		runAction(argsConsumer, cliConnection, fmt.Sprintf("Updating configuration parameters of service instance %s", format.Bold(format.Cyan(serviceInstanceName))), func(progressWriter io.Writer) (string, error) {
+			update := instance.ParametersUpdate{
+				Edits:  edits,
+				DryRun: configurationSetFlags.DryRun,
+				Reveal: configurationSetFlags.Reveal,
+			}
+			if configurationSetFlags.File != "" {
+				changes, err := instance.ReadParametersFile(configurationSetFlags.File)
+				if err != nil {
+					return "", err
+				}
+				update.Changes = changes
+			}
+			timeout := time.Duration(configurationSetFlags.TimeoutSeconds) * time.Second
+			return operationRunner.RunOperation(serviceInstanceName, instance.NewUpdateParametersOperation(cliConnection, authClient, serviceInstanceName, update, timeout, pollInterval, progressWriter))
+		})
+
+	case "spring-cloud-service-clone":
+		sourceInstanceName := getServiceInstanceName(argsConsumer)
+		newInstanceName := getNewServiceInstanceName(argsConsumer)
+		if cloneFlags.TimeoutSeconds <= 0 {
+			diagnoseWithHelp("The --timeout flag must be a positive number of seconds.", "spring-cloud-service-clone")
+		}
+
+		runAction(argsConsumer, cliConnection, fmt.Sprintf("Cloning service instance %s to %s", format.Bold(format.Cyan(sourceInstanceName)), format.Bold(format.Cyan(newInstanceName))), func(progressWriter io.Writer) (string, error) {
+			options := instance.CloneOptions{
+				Plan:     cloneFlags.Plan,
+				BindApps: cloneFlags.BindApps,
+			}
+			timeout := time.Duration(cloneFlags.TimeoutSeconds) * time.Second
+			return operationRunner.RunOperation(sourceInstanceName, instance.NewCloneOperation(cliConnection, authClient, sourceInstanceName, newInstanceName, options, timeout, pollInterval, progressWriter))
+		})
+
+	case "spring-cloud-service-export":
+		runActionQuietly(argsConsumer, cliConnection, func() (string, error) {
+			var secretCipher *config.SecretCipher
+			if exportManifestFlags.Encrypt {
+				passphrase, err := config.ReadPassphrase(exportManifestFlags.PassphraseFile)
+				if err != nil {
+					return "", err
+				}
+				secretCipher, err = config.NewSecretCipher(passphrase)
+				if err != nil {
+					return "", err
+				}
+			}
+			manifest, err := instance.ExportManifest(cliConnection, operationRunner, authClient, secretCipher)
+			if err != nil {
+				return "", err
+			}
+			contents, err := instance.RenderManifest(manifest)
+			if err != nil {
+				return "", err
+			}
+			if exportManifestFlags.File == "" {
+				return strings.TrimSuffix(contents, "\n"), nil
+			}
+			err = instance.WriteManifestFile(exportManifestFlags.File, contents)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Exported %d service instances to %s", len(manifest.ServiceInstances), exportManifestFlags.File), nil
+		})
+
+	case "spring-cloud-service-apply":
+		manifestFile := getManifestFile(argsConsumer)
+		if applyManifestFlags.TimeoutSeconds <= 0 {
+			diagnoseWithHelp("The --timeout flag must be a positive number of seconds.", "spring-cloud-service-apply")
+		}
+
+		runAction(argsConsumer, cliConnection, fmt.Sprintf("Applying manifest %s", format.Bold(format.Cyan(manifestFile))), func(progressWriter io.Writer) (string, error) {
+			manifest, err := instance.ReadManifest(manifestFile)
+			if err != nil {
+				return "", err
+			}
+			var secretCipher *config.SecretCipher
+			if manifest.Encryption != nil {
+				passphrase, err := config.ReadPassphrase(applyManifestFlags.PassphraseFile)
+				if err != nil {
+					return "", err
+				}
+				secretCipher, err = instance.OpenManifestCipher(manifest, passphrase)
+				if err != nil {
+					return "", err
+				}
+			}
+			options := instance.ManifestApplyOptions{
+				DryRun:       applyManifestFlags.DryRun,
+				Timeout:      time.Duration(applyManifestFlags.TimeoutSeconds) * time.Second,
+				PollInterval: pollInterval,
+			}
+			return instance.ApplyManifest(cliConnection, operationRunner, authClient, manifest, secretCipher, options, progressWriter)
 		})
 
 	case "service-registry-enable":
@@ -204,12 +831,77 @@ func getConfigServerInstanceName(ac *cli.ArgConsumer) string {
 	return ac.Consume(1, "configuration server instance name")
 }
 
+func getOtherConfigServerInstanceName(ac *cli.ArgConsumer) string {
+	return ac.Consume(2, "configuration server instance name to compare with")
+}
+
+func getApplicationName(ac *cli.ArgConsumer) string {
+	return ac.Consume(2, "application name")
+}
+
+func getOptionalApplicationName(ac *cli.ArgConsumer) string {
+	return ac.ConsumeOptional(2, "application name")
+}
+
+func getProfile(ac *cli.ArgConsumer, arg int) string {
+	return ac.Consume(arg, "profile")
+}
+
+func getLabel(ac *cli.ArgConsumer, arg int) string {
+	return ac.Consume(arg, "label")
+}
+
+func getPropertyKey(ac *cli.ArgConsumer, arg int) string {
+	return ac.Consume(arg, "property key")
+}
+
+func getOptionalProfile(ac *cli.ArgConsumer, arg int) string {
+	return ac.ConsumeOptional(arg, "profile")
+}
+
+func getOptionalLabel(ac *cli.ArgConsumer, arg int) string {
+	return ac.ConsumeOptional(arg, "label")
+}
+
 func getConfigServerCredHubPath(ac *cli.ArgConsumer) string {
 	return ac.Consume(2, "configuration server credhub path")
 }
 
-func getConfigServerCredHubSecret(ac *cli.ArgConsumer) string {
-	return ac.Consume(3, "configuration server credhub secret")
+func getOptionalEnvironmentPath(ac *cli.ArgConsumer) string {
+	return ac.ConsumeOptional(2, "application, profile and label")
+}
+
+// getConfigServerCredHubPathOrFlags consumes CREDHUB_PATH unless the path was given as flags, and returns the position
+// of the argument which follows it.
+func getConfigServerCredHubPathOrFlags(ac *cli.ArgConsumer, pathFlags cli.CredHubPathFlags) (string, int) {
+	if pathFlags.IsSet() {
+		return "", 2
+	}
+	return getConfigServerCredHubPath(ac), 3
+}
+
+func resolveCredHubPath(credHubPath string, pathFlags cli.CredHubPathFlags) (config.CredHubPath, error) {
+	if !pathFlags.IsSet() {
+		return config.ParseCredHubPath(credHubPath)
+	}
+	return config.CredHubPath{
+		Application: pathFlags.Application,
+		Profile:     pathFlags.Profile,
+		Label:       pathFlags.Label,
+		Property:    pathFlags.Property,
+	}, nil
+}
+
+func getNewSecretValue(ac *cli.ArgConsumer, arg int) string {
+	return ac.ConsumeOptional(arg, "new secret value")
+}
+
+func getConfigServerCredHubSecret(ac *cli.ArgConsumer, arg int) string {
+	return ac.ConsumeOptional(arg, "configuration server credhub secret")
+}
+
+func getLintPath(ac *cli.ArgConsumer) string {
+	return ac.Consume(1, "file or directory path")
 }
 
 func getServiceInstanceName(ac *cli.ArgConsumer) string {
@@ -220,6 +912,33 @@ func getPlainText(ac *cli.ArgConsumer) string {
 	return ac.ConsumeOptional(2, "string to encrypt")
 }
 
+func getOfflinePlainText(ac *cli.ArgConsumer) string {
+	return ac.ConsumeOptional(1, "string to encrypt")
+}
+
+func getOptionalOtherServiceInstanceName(ac *cli.ArgConsumer) string {
+	return ac.ConsumeOptional(2, "service instance name to compare with")
+}
+
+func getManifestFile(ac *cli.ArgConsumer) string {
+	return ac.Consume(1, "manifest file")
+}
+
+func getNewServiceInstanceName(ac *cli.ArgConsumer) string {
+	return ac.Consume(2, "new service instance name")
+}
+
+func getParameterEdits(ac *cli.ArgConsumer) []string {
+	edits := []string{}
+	for arg := 2; ; arg++ {
+		edit := ac.ConsumeOptional(arg, "configuration parameter edit")
+		if edit == "" {
+			return edits
+		}
+		edits = append(edits, edit)
+	}
+}
+
 func getServiceRegistryInstanceName(ac *cli.ArgConsumer) string {
 	return ac.Consume(1, "service registry instance name")
 }
@@ -240,6 +959,13 @@ func runActionQuietly(argsConsumer *cli.ArgConsumer, cliConnection plugin.CliCon
 	})
 }
 
+func waitForLifecycle(lifecycleFlags cli.LifecycleFlags, cliConnection plugin.CliConnection, operation instance.Operation, authClient httpclient.AuthenticatedClient, requestedState string, restarts bool, progressWriter io.Writer) instance.Operation {
+	if !lifecycleFlags.Wait {
+		return operation
+	}
+	return instance.NewWaitingOperation(operation, cliConnection, authClient, requestedState, restarts, time.Duration(lifecycleFlags.TimeoutSeconds)*time.Second, pollInterval, progressWriter)
+}
+
 func diagnoseWithHelp(message string, command string) {
 	fmt.Printf("%s See 'cf help %s'.\n", message, command)
 	os.Exit(1)
@@ -270,9 +996,95 @@ func (c *Plugin) GetMetadata() plugin.PluginMetadata {
 				Alias:    "csev",
 				UsageDetails: plugin.Usage{
 					Usage: `   cf config-server-encrypt-value CONFIG_SERVER_INSTANCE_NAME [VALUE_TO_ENCRYPT]
+      cf config-server-encrypt-value --offline --public-key PUBLIC_KEY_FILE [VALUE_TO_ENCRYPT]
+
+      NOTE: Either VALUE_TO_ENCRYPT or --file-to-encrypt flag is required, but not both. Offline encryption produces the same format as a configuration server with an RSA key and the default encrypt.rsa settings.`,
+					Options: map[string]string{
+						"-f/--file-to-encrypt": cli.FileNameUsage,
+						"--offline":            cli.OfflineUsage,
+						"--public-key":         cli.PublicKeyUsage,
+					},
+				},
+			},
+			{
+				Name:     "config-server-key-info",
+				HelpText: "Display whether a Spring Cloud Services configuration server can encrypt values, and its public key",
+				Alias:    "cs-key-info",
+				UsageDetails: plugin.Usage{
+					Usage: `   cf config-server-key-info CONFIG_SERVER_INSTANCE_NAME [APPLICATION_NAME] [PROFILE]
+
+      NOTE: The public key of an RSA key is printed in PEM format, so values can be encrypted offline. Given APPLICATION_NAME, the key used for that application and PROFILE, which defaults to "default", is shown. The configuration server exposes neither the alias nor the id of its key, so the alias cannot be determined: the fingerprint is the only identifier of the key.`,
+				},
+			},
+			{
+				Name:     "config-server-get",
+				HelpText: "Display the configuration served by a Spring Cloud Services configuration server for an application",
+				Alias:    "csg",
+				UsageDetails: plugin.Usage{
+					Usage: `   cf config-server-get CONFIG_SERVER_INSTANCE_NAME APPLICATION_NAME [PROFILE] [LABEL]
+
+      NOTE: PROFILE defaults to "default" and LABEL defaults to the label configured in the configuration server.`,
+					Options: map[string]string{"--format": cli.FormatUsage},
+				},
+			},
+			{
+				Name:     "config-server-export-env",
+				HelpText: "Export the configuration served for an application to run it locally",
+				Alias:    "cs-export-env",
+				UsageDetails: plugin.Usage{
+					Usage: `   cf config-server-export-env CONFIG_SERVER_INSTANCE_NAME APPLICATION_NAME [PROFILE] [LABEL] [--format FORMAT] [--file OUTPUT_FILE] [--exclude-secrets]
+
+      NOTE: PROFILE defaults to "default" and LABEL defaults to the label configured in the configuration server. Values are exported as the configuration server serves them, that is decrypted. The dotenv format names each property by its Spring Boot environment variable, e.g. SPRING_DATASOURCE_URL.`,
+					Options: map[string]string{
+						"--format":          cli.ExportFormatUsage,
+						"-f/--file":         cli.OutputFileUsage,
+						"--exclude-secrets": cli.ExcludeSecretsUsage,
+					},
+				},
+			},
+			{
+				Name:     "config-server-diff",
+				HelpText: "Compare the configuration served for an application by Spring Cloud Services configuration servers, profiles or labels",
+				Alias:    "csd",
+				UsageDetails: plugin.Usage{
+					Usage: `   cf config-server-diff CONFIG_SERVER_INSTANCE_NAME APPLICATION_NAME [PROFILE] [LABEL] [--to-config-server CONFIG_SERVER_INSTANCE_NAME] [--to-profile PROFILE] [--to-label LABEL]
+
+      NOTE: At least one of --to-config-server, --to-profile or --to-label is required. Secrets are masked unless --reveal is given.`,
+					Options: map[string]string{
+						"--to-config-server": cli.ToConfigServerUsage,
+						"--to-profile":       cli.ToProfileUsage,
+						"--to-label":         cli.ToLabelUsage,
+						"--reveal":           cli.RevealUsage,
+					},
+				},
+			},
+			{
+				Name:     "config-server-compare",
+				HelpText: "Compare the configuration served by two Spring Cloud Services configuration servers for several applications, and optionally copy their CredHub secrets",
+				Alias:    "cs-compare",
+				UsageDetails: plugin.Usage{
+					Usage: `   cf config-server-compare CONFIG_SERVER_INSTANCE_NAME OTHER_CONFIG_SERVER_INSTANCE_NAME (--environment APP_NAME[/PROFILE[/LABEL]] ... | --environments-file ENVIRONMENTS_FILE) [--parameters] [--reveal] [--copy-secrets [--dry-run]]
 
-      NOTE: Either VALUE_TO_ENCRYPT or --file-to-encrypt flag is required, but not both.`,
-					Options: map[string]string{"-f/--file-to-encrypt": cli.FileNameUsage},
+      NOTE: PROFILE defaults to "default" and LABEL defaults to the label configured in each configuration server. Secrets are masked unless --reveal is given. --copy-secrets copies the properties served from CredHub for exactly APP_NAME/PROFILE/LABEL, which must include the label, each as a secret APP_NAME/PROFILE/LABEL/{propertyName}. Properties served from Git or Vault are not copied.`,
+					Options: map[string]string{
+						"-e/--environment":    cli.EnvironmentUsage,
+						"--environments-file": cli.EnvironmentsFileUsage,
+						"--parameters":        cli.ParametersUsage,
+						"--reveal":            cli.RevealUsage,
+						"--copy-secrets":      cli.CopySecretsUsage,
+						"--dry-run":           cli.CopySecretsDryRunUsage,
+					},
+				},
+			},
+			{
+				Name:     "config-server-explain",
+				HelpText: "Show which property sources of a Spring Cloud Services configuration server define a property, and which one wins",
+				Alias:    "cse",
+				UsageDetails: plugin.Usage{
+					Usage: `   cf config-server-explain CONFIG_SERVER_INSTANCE_NAME APPLICATION_NAME PROFILE PROPERTY_KEY
+
+      NOTE: Secrets are masked unless --reveal is given.`,
+					Options: map[string]string{"--reveal": cli.RevealUsage},
 				},
 			},
 			{
@@ -280,7 +1092,17 @@ func (c *Plugin) GetMetadata() plugin.PluginMetadata {
 				HelpText: "Synchronize Git mirrors associated with given Spring Cloud Services configuration server",
 				Alias:    "cssm",
 				UsageDetails: plugin.Usage{
-					Usage: `   cf config-server-sync-mirrors CONFIG_SERVER_INSTANCE_NAME`,
+					Usage: `   cf config-server-sync-mirrors CONFIG_SERVER_INSTANCE_NAME [--repository URI_OR_PATTERN ...] [--wait --application APPLICATION_NAME [--profile PROFILE] [--label LABEL] [--timeout SECONDS]]
+
+      NOTE: All mirrors are refreshed. --repository limits which mirrors are reported, checked for failure and waited for. The application is served from one repository, so --wait checks the commit of exactly one mirror: select it with --repository if there are several.`,
+					Options: map[string]string{
+						"-r/--repository":  cli.RepositoryUsage,
+						"-w/--wait":        cli.WaitUsage,
+						"-a/--application": cli.ApplicationUsage,
+						"-p/--profile":     cli.ProfileUsage,
+						"-l/--label":       cli.LabelUsage,
+						"-t/--timeout":     cli.TimeoutUsage,
+					},
 				},
 			},
 			{
@@ -288,7 +1110,18 @@ func (c *Plugin) GetMetadata() plugin.PluginMetadata {
 				HelpText: "Add secret in JSON format to the given path",
 				Alias:    "cs-add",
 				UsageDetails: plugin.Usage{
-					Usage: `   cf config-server-add-credhub-secret CONFIG_SERVER_INSTANCE_NAME CREDHUB_PATH JSON_SECRET`,
+					Usage: `   cf config-server-add-credhub-secret CONFIG_SERVER_INSTANCE_NAME CREDHUB_PATH [JSON_SECRET | --file SECRET_FILE]
+      cf config-server-add-credhub-secret CONFIG_SERVER_INSTANCE_NAME --app APP_NAME --profile PROFILE --label LABEL --property PROPERTY_NAME [JSON_SECRET | --file SECRET_FILE]
+
+      NOTE: Either JSON_SECRET or --file flag is required, but not both. JSON_SECRET must be a JSON object, such as '{"key": "value"}'. Use --file - to read the secret from standard input and keep it out of the shell history.
+      CREDHUB_PATH is {appName}/{profile}/{label}/{propertyName}. Use the --app, --profile, --label and --property flags instead for a label containing "/".`,
+					Options: map[string]string{
+						"-f/--file":  cli.SecretFileUsage,
+						"--app":      cli.CredHubAppUsage,
+						"--profile":  cli.CredHubProfileUsage,
+						"--label":    cli.CredHubLabelUsage,
+						"--property": cli.CredHubPropertyUsage,
+					},
 				},
 			},
 			{
@@ -296,7 +1129,192 @@ func (c *Plugin) GetMetadata() plugin.PluginMetadata {
 				HelpText: "Remove secrets from the given path",
 				Alias:    "cs-remove",
 				UsageDetails: plugin.Usage{
-					Usage: `   cf config-server-remove-credhub-secret CONFIG_SERVER_INSTANCE_NAME CREDHUB_PATH`,
+					Usage: `   cf config-server-remove-credhub-secret CONFIG_SERVER_INSTANCE_NAME CREDHUB_PATH
+      cf config-server-remove-credhub-secret CONFIG_SERVER_INSTANCE_NAME --app APP_NAME --profile PROFILE --label LABEL --property PROPERTY_NAME
+
+      NOTE: CREDHUB_PATH is {appName}/{profile}/{label}/{propertyName}. Use the --app, --profile, --label and --property flags instead for a label containing "/".`,
+					Options: map[string]string{
+						"--app":      cli.CredHubAppUsage,
+						"--profile":  cli.CredHubProfileUsage,
+						"--label":    cli.CredHubLabelUsage,
+						"--property": cli.CredHubPropertyUsage,
+					},
+				},
+			},
+			{
+				Name:     "config-server-rotate-credhub-secret",
+				HelpText: "Replace the value of a secret and optionally restart or refresh the applications which use it",
+				Alias:    "cs-rotate",
+				UsageDetails: plugin.Usage{
+					Usage: `   cf config-server-rotate-credhub-secret CONFIG_SERVER_INSTANCE_NAME CREDHUB_PATH [NEW_VALUE | --generate [--length LENGTH] | --file VALUE_FILE] [--key KEY] [--restart APP_NAME ...] [--refresh APP_NAME ...]
+      cf config-server-rotate-credhub-secret CONFIG_SERVER_INSTANCE_NAME --app APP_NAME --profile PROFILE --label LABEL --property PROPERTY_NAME [NEW_VALUE | --generate [--length LENGTH] | --file VALUE_FILE] [--key KEY] [--restart APP_NAME ...] [--refresh APP_NAME ...]
+
+      NOTE: The secret is replaced with just KEY and its new value: any other key stored in the same secret is not kept. Applications are restarted with a rolling restart, which requires cf CLI v7 or later, then refreshed, one at a time; a failure is reported without stopping the remaining applications. A generated value is not displayed: use config-server-get-credhub-secret --reveal to display it.`,
+					Options: map[string]string{
+						"--app":         cli.CredHubAppUsage,
+						"--profile":     cli.CredHubProfileUsage,
+						"--label":       cli.CredHubLabelUsage,
+						"--property":    cli.CredHubPropertyUsage,
+						"--key":         cli.SecretKeyUsage,
+						"-g/--generate": cli.GenerateUsage,
+						"--length":      cli.LengthUsage,
+						"-f/--file":     cli.ValueFileUsage,
+						"--restart":     cli.RestartAppUsage,
+						"--refresh":     cli.RefreshAppUsage,
+					},
+				},
+			},
+			{
+				Name:     "config-server-list-credhub-secrets",
+				HelpText: "List the secrets a Spring Cloud Services configuration server serves from CredHub",
+				Alias:    "cs-list",
+				UsageDetails: plugin.Usage{
+					Usage: `   cf config-server-list-credhub-secrets CONFIG_SERVER_INSTANCE_NAME [APP_NAME[/PROFILE[/LABEL]]]
+
+      NOTE: APP_NAME defaults to "application", PROFILE to "default" and LABEL to the label configured in the configuration server. Values are masked unless --reveal is given.`,
+					Options: map[string]string{"--reveal": cli.RevealUsage},
+				},
+			},
+			{
+				Name:     "config-server-get-credhub-secret",
+				HelpText: "Display a secret a Spring Cloud Services configuration server serves from CredHub",
+				Alias:    "cs-get",
+				UsageDetails: plugin.Usage{
+					Usage: `   cf config-server-get-credhub-secret CONFIG_SERVER_INSTANCE_NAME CREDHUB_PATH
+
+      NOTE: CREDHUB_PATH is {appName}/{profile}/{label}/{propertyName}. The configuration server serves the secrets stored for {appName}/{profile}/{label} together, so the keys of all of them are displayed. Values are masked unless --reveal is given.`,
+					Options: map[string]string{"--reveal": cli.RevealUsage},
+				},
+			},
+			{
+				Name:     "config-server-import-credhub-secrets",
+				HelpText: "Import the properties of a file as secrets for an application, profile and label",
+				Alias:    "cs-import",
+				UsageDetails: plugin.Usage{
+					Usage: `   cf config-server-import-credhub-secrets CONFIG_SERVER_INSTANCE_NAME APP_NAME PROFILE LABEL --file SECRETS_FILE [--dry-run]
+
+      NOTE: Each property of SECRETS_FILE is added as the secret APP_NAME/PROFILE/LABEL/{propertyName}. Nested YAML and JSON properties are flattened, e.g. to "spring.datasource.password". A YAML file must hold a single document.`,
+					Options: map[string]string{
+						"-f/--file": cli.SecretsFileUsage,
+						"--dry-run": cli.DryRunUsage,
+					},
+				},
+			},
+			{
+				Name:     "config-server-export-credhub-secrets",
+				HelpText: "Export the secrets of an application, profile and label to an encrypted backup file",
+				Alias:    "cs-export",
+				UsageDetails: plugin.Usage{
+					Usage: `   cf config-server-export-credhub-secrets CONFIG_SERVER_INSTANCE_NAME APP_NAME PROFILE LABEL --file BACKUP_FILE [--passphrase-file PASSPHRASE_FILE]
+
+      NOTE: The backup is encrypted with a key derived from the passphrase, so it can be restored into a configuration server in another foundation. Only secrets stored at APP_NAME/PROFILE/LABEL are exported.`,
+					Options: map[string]string{
+						"-f/--file":         cli.BackupFileUsage,
+						"--passphrase-file": cli.PassphraseFileUsage,
+					},
+				},
+			},
+			{
+				Name:     "config-server-restore-credhub-secrets",
+				HelpText: "Restore the secrets of an encrypted backup file into a configuration server",
+				Alias:    "cs-restore",
+				UsageDetails: plugin.Usage{
+					Usage: `   cf config-server-restore-credhub-secrets CONFIG_SERVER_INSTANCE_NAME --file BACKUP_FILE [--passphrase-file PASSPHRASE_FILE] [--dry-run]
+
+      NOTE: The secrets are restored to the application, profile and label they were exported from.`,
+					Options: map[string]string{
+						"-f/--file":         cli.BackupFileUsage,
+						"--passphrase-file": cli.PassphraseFileUsage,
+						"--dry-run":         cli.DryRunUsage,
+					},
+				},
+			},
+			{
+				Name:     "config-server-lint",
+				HelpText: "Find unencrypted secrets in local properties and YAML files",
+				Alias:    "cs-lint",
+				UsageDetails: plugin.Usage{
+					Usage: `   cf config-server-lint PATH [--fix CONFIG_SERVER_INSTANCE_NAME]
+
+      NOTE: PATH is a .properties, .yml or .yaml file, or a directory which is scanned recursively. Values of keys such as "password", "secret" or "token", and high-entropy values, are reported unless they start with {cipher} or reference CredHub. The command fails while any secret remains unencrypted, so it can be used as a pre-commit hook.`,
+					Options: map[string]string{
+						"--fix": cli.FixUsage,
+					},
+				},
+			},
+			{
+				Name:     "spring-cloud-service-configuration-diff",
+				HelpText: "Compare the configuration parameters of two Spring Cloud Services service instances, or of a service instance and a file",
+				Alias:    "scs-config-diff",
+				UsageDetails: plugin.Usage{
+					Usage: `   cf scs-config-diff SERVICE_INSTANCE_NAME_A (SERVICE_INSTANCE_NAME_B | --file PARAMETERS_FILE) [--reveal]
+
+      NOTE: Credentials are masked unless --reveal is given, and a masked value in PARAMETERS_FILE matches any value.`,
+					Options: map[string]string{
+						"-f/--file": cli.ParametersFileUsage,
+						"--reveal":  cli.RevealUsage,
+					},
+				},
+			},
+			{
+				Name:     "spring-cloud-service-configuration-set",
+				HelpText: "Update the configuration parameters of a Spring Cloud Services service instance",
+				Alias:    "scs-config-set",
+				UsageDetails: plugin.Usage{
+					Usage: `   cf scs-config-set SERVICE_INSTANCE_NAME [KEY.PATH=VALUE...] [--file PARAMETERS_FILE] [--dry-run] [--reveal] [--timeout TIMEOUT]
+
+      NOTE: The changes in PARAMETERS_FILE are merged into the current parameters, then each edit, such as git.label=main or composite[0].uri=URI, is applied. VALUE is parsed as JSON if possible, and null removes a parameter. The differences are shown and the parameters are validated before the service instance is updated.`,
+					Options: map[string]string{
+						"-f/--file":    cli.UpdateParametersFileUsage,
+						"--dry-run":    cli.UpdateParametersDryRunUsage,
+						"--reveal":     cli.RevealUsage,
+						"-t/--timeout": cli.TimeoutUsage,
+					},
+				},
+			},
+			{
+				Name:     "spring-cloud-service-clone",
+				HelpText: "Create a Spring Cloud Services service instance with the configuration of an existing one",
+				Alias:    "scs-clone",
+				UsageDetails: plugin.Usage{
+					Usage: `   cf scs-clone SOURCE_SERVICE_INSTANCE_NAME NEW_SERVICE_INSTANCE_NAME [--plan PLAN] [--bind-apps] [--timeout TIMEOUT]
+
+      NOTE: The new service instance has the service offering and configuration parameters of the source service instance. The command waits for it to be provisioned before binding any apps.`,
+					Options: map[string]string{
+						"-p/--plan":    cli.PlanUsage,
+						"--bind-apps":  cli.BindAppsUsage,
+						"-t/--timeout": cli.TimeoutUsage,
+					},
+				},
+			},
+			{
+				Name:     "spring-cloud-service-export",
+				HelpText: "Export the Spring Cloud Services service instances of the targeted space to a manifest",
+				Alias:    "scs-export",
+				UsageDetails: plugin.Usage{
+					Usage: `   cf scs-export [--file MANIFEST_FILE] [--encrypt [--passphrase-file PASSPHRASE_FILE]]
+
+      NOTE: The manifest declares the service offering, plan, configuration parameters and bound apps of each service instance. Credentials are redacted unless --encrypt is given.`,
+					Options: map[string]string{
+						"-f/--file":         cli.ManifestFileUsage,
+						"--encrypt":         cli.EncryptUsage,
+						"--passphrase-file": cli.ManifestPassphraseFileUsage,
+					},
+				},
+			},
+			{
+				Name:     "spring-cloud-service-apply",
+				HelpText: "Create or update Spring Cloud Services service instances to match a manifest",
+				Alias:    "scs-apply",
+				UsageDetails: plugin.Usage{
+					Usage: `   cf scs-apply MANIFEST_FILE [--dry-run] [--passphrase-file PASSPHRASE_FILE] [--timeout TIMEOUT]
+
+      NOTE: The plan of changes is shown before any are made. Redacted credentials of existing service instances are left unchanged. Service instances missing from the manifest are left alone and apps are not unbound.`,
+					Options: map[string]string{
+						"--dry-run":         cli.ApplyDryRunUsage,
+						"--passphrase-file": cli.ManifestPassphraseFileUsage,
+						"-t/--timeout":      cli.TimeoutUsage,
+					},
 				},
 			},
 			{
@@ -304,7 +1322,11 @@ func (c *Plugin) GetMetadata() plugin.PluginMetadata {
 				HelpText: "Stop a Spring Cloud Services service instance",
 				Alias:    "scs-stop",
 				UsageDetails: plugin.Usage{
-					Usage: "   cf scs-stop SERVICE_INSTANCE_NAME",
+					Usage: "   cf scs-stop SERVICE_INSTANCE_NAME [--wait] [--timeout TIMEOUT]",
+					Options: map[string]string{
+						"-w/--wait":    cli.LifecycleWaitUsage,
+						"-t/--timeout": cli.TimeoutUsage,
+					},
 				},
 			},
 			{
@@ -312,7 +1334,11 @@ func (c *Plugin) GetMetadata() plugin.PluginMetadata {
 				HelpText: "Start a Spring Cloud Services service instance",
 				Alias:    "scs-start",
 				UsageDetails: plugin.Usage{
-					Usage: "   cf scs-start SERVICE_INSTANCE_NAME",
+					Usage: "   cf scs-start SERVICE_INSTANCE_NAME [--wait] [--timeout TIMEOUT]",
+					Options: map[string]string{
+						"-w/--wait":    cli.LifecycleWaitUsage,
+						"-t/--timeout": cli.TimeoutUsage,
+					},
 				},
 			},
 			{
@@ -320,7 +1346,11 @@ func (c *Plugin) GetMetadata() plugin.PluginMetadata {
 				HelpText: "Restart a Spring Cloud Services service instance",
 				Alias:    "scs-restart",
 				UsageDetails: plugin.Usage{
-					Usage: "   cf scs-restart SERVICE_INSTANCE_NAME",
+					Usage: "   cf scs-restart SERVICE_INSTANCE_NAME [--wait] [--timeout TIMEOUT]",
+					Options: map[string]string{
+						"-w/--wait":    cli.LifecycleWaitUsage,
+						"-t/--timeout": cli.TimeoutUsage,
+					},
 				},
 			},
 			{
@@ -328,7 +1358,11 @@ func (c *Plugin) GetMetadata() plugin.PluginMetadata {
 				HelpText: "Restage a Spring Cloud Services service instance",
 				Alias:    "scs-restage",
 				UsageDetails: plugin.Usage{
-					Usage: "   cf scs-restage SERVICE_INSTANCE_NAME",
+					Usage: "   cf scs-restage SERVICE_INSTANCE_NAME [--wait] [--timeout TIMEOUT]",
+					Options: map[string]string{
+						"-w/--wait":    cli.LifecycleWaitUsage,
+						"-t/--timeout": cli.TimeoutUsage,
+					},
 				},
 			},
 			{
@@ -336,7 +1370,23 @@ func (c *Plugin) GetMetadata() plugin.PluginMetadata {
 				HelpText: "Display health and status for a Spring Cloud Services service instance",
 				Alias:    "scs-view",
 				UsageDetails: plugin.Usage{
-					Usage: "   cf scs-view SERVICE_INSTANCE_NAME",
+					Usage: "   cf scs-view SERVICE_INSTANCE_NAME [--output json|yaml | --watch [--interval SECONDS]]",
+					Options: map[string]string{
+						"-o/--output":   cli.ViewOutputUsage,
+						"-w/--watch":    cli.WatchUsage,
+						"-i/--interval": cli.IntervalUsage,
+					},
+				},
+			},
+			{
+				Name:     "spring-cloud-service-list",
+				HelpText: "List the Spring Cloud Services service instances in the targeted space, with their health",
+				Alias:    "scs-list",
+				UsageDetails: plugin.Usage{
+					Usage: "   cf scs-list [--all-spaces]",
+					Options: map[string]string{
+						"--all-spaces": cli.AllSpacesUsage,
+					},
 				},
 			},
 			{
@@ -344,7 +1394,14 @@ func (c *Plugin) GetMetadata() plugin.PluginMetadata {
 				HelpText: "Display configuration parameters for a Spring Cloud Services service instance",
 				Alias:    "scs-config",
 				UsageDetails: plugin.Usage{
-					Usage: "   cf scs-config SERVICE_INSTANCE_NAME",
+					Usage: `   cf scs-config SERVICE_INSTANCE_NAME [--output json|yaml] [--query PATH] [--reveal]
+
+      NOTE: Credentials are masked in every output format, including json and yaml, so that the output can be shared or saved safely. Give --reveal to print their values, for example to save parameters which will be applied to another service instance.`,
+					Options: map[string]string{
+						"-o/--output": cli.ParametersOutputUsage,
+						"-q/--query":  cli.QueryUsage,
+						"--reveal":    cli.RevealUsage,
+					},
 				},
 			},
 			{
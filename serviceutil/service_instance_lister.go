@@ -0,0 +1,260 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package serviceutil
+
+import (
+	plugin_models "code.cloudfoundry.org/cli/plugin/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+
+	"code.cloudfoundry.org/cli/plugin"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/httpclient"
+)
+
+// The service offerings of Spring Cloud Services, without the "p-" prefix of SCS 2 or the "p." prefix of SCS 3, and
+// the type of service instance each provides.
+var scsServiceTypes = map[string]string{
+	"config-server":             "config server",
+	"service-registry":          "service registry",
+	"circuit-breaker-dashboard": "circuit breaker dashboard",
+}
+
+// ServiceInstance is a Spring Cloud Services service instance as listed by the Cloud Controller.
+type ServiceInstance struct {
+	Name                string
+	Guid                string
+	SpaceName           string
+	ServiceOfferingName string
+	ServicePlanName     string
+	DashboardUrl        string
+	ManagementUrl       string
+}
+
+// Type is the kind of service instance, such as "config server".
+func (s ServiceInstance) Type() string {
+	return scsServiceTypes[scsServiceOffering(s.ServiceOfferingName)]
+}
+
+// Generation is "v2" for an SCS 2 service instance and "v3" for an SCS 3 service instance.
+func (s ServiceInstance) Generation() string {
+	if isV2ServiceInstance(s.serviceModel()) {
+		return "v2"
+	}
+	return "v3"
+}
+
+func (s ServiceInstance) serviceModel() plugin_models.GetService_Model {
+	return plugin_models.GetService_Model{
+		Guid:            s.Guid,
+		Name:            s.Name,
+		DashboardUrl:    s.DashboardUrl,
+		ServiceOffering: plugin_models.GetService_ServiceFields{Name: s.ServiceOfferingName},
+		ServicePlan:     plugin_models.GetService_ServicePlan{Name: s.ServicePlanName},
+	}
+}
+
+func scsServiceOffering(serviceOfferingName string) string {
+	for _, prefix := range []string{"p-", "p."} {
+		if strings.HasPrefix(serviceOfferingName, prefix) {
+			return strings.TrimPrefix(serviceOfferingName, prefix)
+		}
+	}
+	return ""
+}
+
+// IsScsServiceOffering reports whether a service offering is one of Spring Cloud Services.
+func IsScsServiceOffering(serviceOfferingName string) bool {
+	_, ok := scsServiceTypes[scsServiceOffering(serviceOfferingName)]
+	return ok
+}
+
+type ServiceInstanceLister interface {
+	// List returns the Spring Cloud Services service instances of the targeted space or, if allSpaces is set, of
+	// every space of the targeted org, with the service broker URL which manages each of them.
+	List(accessToken string, allSpaces bool) ([]ServiceInstance, error)
+}
+
+type serviceInstanceLister struct {
+	cliConnection plugin.CliConnection
+	authClient    httpclient.AuthenticatedClient
+	resolver      *serviceInstanceUrlResolver
+}
+
+func NewServiceInstanceLister(cliConnection plugin.CliConnection, authClient httpclient.AuthenticatedClient) ServiceInstanceLister {
+	return &serviceInstanceLister{
+		cliConnection: cliConnection,
+		authClient:    authClient,
+		resolver: &serviceInstanceUrlResolver{
+			cliConnection: cliConnection,
+			authClient:    authClient,
+		},
+	}
+}
+
+type relationship struct {
+	Data struct {
+		Guid string
+	}
+}
+
+type serviceInstancesResp struct {
+	Pagination struct {
+		Next *struct {
+			Href string
+		}
+	}
+	Resources []struct {
+		Guid          string
+		Name          string
+		DashboardUrl  string `json:"dashboard_url"`
+		Relationships struct {
+			Space       relationship
+			ServicePlan relationship `json:"service_plan"`
+		}
+	}
+	Included struct {
+		Spaces []struct {
+			Guid string
+			Name string
+		}
+		ServicePlans []struct {
+			Guid          string
+			Name          string
+			Relationships struct {
+				ServiceOffering relationship `json:"service_offering"`
+			}
+		} `json:"service_plans"`
+		ServiceOfferings []struct {
+			Guid string
+			Name string
+		} `json:"service_offerings"`
+	}
+}
+
+func (l *serviceInstanceLister) List(accessToken string, allSpaces bool) ([]ServiceInstance, error) {
+	apiUrl, err := l.cliConnection.ApiEndpoint()
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	query.Set("type", "managed")
+	query.Set("fields[space]", "guid,name")
+	query.Set("fields[service_plan]", "guid,name,relationships.service_offering")
+	query.Set("fields[service_plan.service_offering]", "guid,name")
+	if allSpaces {
+		org, err := l.cliConnection.GetCurrentOrg()
+		if err != nil {
+			return nil, err
+		}
+		if org.Guid == "" {
+			return nil, errors.New("No org targeted. Use 'cf target -o ORG' to target an org.")
+		}
+		query.Set("organization_guids", org.Guid)
+	} else {
+		space, err := l.cliConnection.GetCurrentSpace()
+		if err != nil {
+			return nil, err
+		}
+		if space.Guid == "" {
+			return nil, errors.New("No space targeted. Use 'cf target -s SPACE' to target a space.")
+		}
+		query.Set("space_guids", space.Guid)
+	}
+
+	serviceInstances := []ServiceInstance{}
+	nextUrl := fmt.Sprintf("%s/v3/service_instances?%s", strings.TrimSuffix(apiUrl, "/"), query.Encode())
+	for nextUrl != "" {
+		page, err := l.getPage(nextUrl, accessToken)
+		if err != nil {
+			return nil, err
+		}
+
+		spaceNames := map[string]string{}
+		for _, space := range page.Included.Spaces {
+			spaceNames[space.Guid] = space.Name
+		}
+		serviceOfferingNames := map[string]string{}
+		for _, serviceOffering := range page.Included.ServiceOfferings {
+			serviceOfferingNames[serviceOffering.Guid] = serviceOffering.Name
+		}
+		servicePlanNames := map[string]string{}
+		servicePlanOfferings := map[string]string{}
+		for _, servicePlan := range page.Included.ServicePlans {
+			servicePlanNames[servicePlan.Guid] = servicePlan.Name
+			servicePlanOfferings[servicePlan.Guid] = serviceOfferingNames[servicePlan.Relationships.ServiceOffering.Data.Guid]
+		}
+
+		for _, resource := range page.Resources {
+			servicePlanGuid := resource.Relationships.ServicePlan.Data.Guid
+			if !IsScsServiceOffering(servicePlanOfferings[servicePlanGuid]) {
+				continue
+			}
+
+			serviceInstance := ServiceInstance{
+				Name:                resource.Name,
+				Guid:                resource.Guid,
+				SpaceName:           spaceNames[resource.Relationships.Space.Data.Guid],
+				ServiceOfferingName: servicePlanOfferings[servicePlanGuid],
+				ServicePlanName:     servicePlanNames[servicePlanGuid],
+				DashboardUrl:        resource.DashboardUrl,
+			}
+			if isV2ServiceInstance(serviceInstance.serviceModel()) {
+				serviceInstance.ManagementUrl, err = l.resolver.getV2ManagementUrl(serviceInstance.serviceModel())
+			} else {
+				serviceInstance.ManagementUrl, err = l.resolver.getV3ManagementUrl(serviceInstance.serviceModel(), true)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("unable to resolve management url of %s: %s", serviceInstance.Name, err)
+			}
+			serviceInstances = append(serviceInstances, serviceInstance)
+		}
+
+		nextUrl = ""
+		if page.Pagination.Next != nil {
+			nextUrl = page.Pagination.Next.Href
+		}
+	}
+	return serviceInstances, nil
+}
+
+func (l *serviceInstanceLister) getPage(pageUrl string, accessToken string) (*serviceInstancesResp, error) {
+	bodyReader, _, err := l.authClient.DoAuthenticatedGet(pageUrl, accessToken)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot list service instances: %s", err)
+	}
+	if bodyReader == nil {
+		return nil, errors.New("Service instances response body missing")
+	}
+	defer bodyReader.Close()
+
+	body, err := ioutil.ReadAll(bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read service instances response body: %s", err)
+	}
+
+	var page serviceInstancesResp
+	err = json.Unmarshal(body, &page)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid service instances response JSON: %s, response body: '%s'", err, string(body))
+	}
+	return &page, nil
+}
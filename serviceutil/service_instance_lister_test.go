@@ -0,0 +1,182 @@
+/*
+ * Copyright (C) 2017-Present Pivotal Software, Inc. All rights reserved.
+ *
+ * This program and the accompanying materials are made available under
+ * the terms of the under the Apache License, Version 2.0 (the "License”);
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package serviceutil_test
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"code.cloudfoundry.org/cli/plugin/models"
+	"code.cloudfoundry.org/cli/plugin/pluginfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/httpclient/httpclientfakes"
+	"github.com/pivotal-cf/spring-cloud-services-cli-plugin/serviceutil"
+)
+
+var _ = Describe("ServiceInstanceLister", func() {
+
+	const (
+		testAccessToken = "someaccesstoken"
+		firstPage       = `{
+			"pagination": {"next": {"href": "https://api.example.com/v3/service_instances?page=2"}},
+			"resources": [
+				{"guid": "guid-1", "name": "config-server", "dashboard_url": "https://spring-cloud-broker.example.com/dashboard/p-config-server/guid-1",
+				 "relationships": {"space": {"data": {"guid": "space-1"}}, "service_plan": {"data": {"guid": "plan-1"}}}},
+				{"guid": "guid-2", "name": "mysql", "dashboard_url": "https://mysql.example.com",
+				 "relationships": {"space": {"data": {"guid": "space-1"}}, "service_plan": {"data": {"guid": "plan-2"}}}}
+			],
+			"included": {
+				"spaces": [{"guid": "space-1", "name": "dev"}],
+				"service_plans": [
+					{"guid": "plan-1", "name": "standard", "relationships": {"service_offering": {"data": {"guid": "offering-1"}}}},
+					{"guid": "plan-2", "name": "db-small", "relationships": {"service_offering": {"data": {"guid": "offering-2"}}}}
+				],
+				"service_offerings": [{"guid": "offering-1", "name": "p-config-server"}, {"guid": "offering-2", "name": "p-mysql"}]
+			}
+		}`
+		secondPage = `{
+			"pagination": {"next": null},
+			"resources": [
+				{"guid": "guid-3", "name": "registry", "dashboard_url": "https://service-registry-guid-3.example.com/dashboard",
+				 "relationships": {"space": {"data": {"guid": "space-2"}}, "service_plan": {"data": {"guid": "plan-3"}}}}
+			],
+			"included": {
+				"spaces": [{"guid": "space-2", "name": "prod"}],
+				"service_plans": [{"guid": "plan-3", "name": "standard", "relationships": {"service_offering": {"data": {"guid": "offering-3"}}}}],
+				"service_offerings": [{"guid": "offering-3", "name": "p.service-registry"}]
+			}
+		}`
+	)
+
+	var (
+		fakeCliConnection *pluginfakes.FakeCliConnection
+		fakeAuthClient    *httpclientfakes.FakeAuthenticatedClient
+		allSpaces         bool
+		serviceInstances  []serviceutil.ServiceInstance
+		err               error
+	)
+
+	BeforeEach(func() {
+		fakeCliConnection = &pluginfakes.FakeCliConnection{}
+		fakeCliConnection.ApiEndpointReturns("https://api.example.com", nil)
+		fakeCliConnection.GetCurrentSpaceReturns(plugin_models.Space{SpaceFields: plugin_models.SpaceFields{Guid: "space-1", Name: "dev"}}, nil)
+		fakeCliConnection.GetCurrentOrgReturns(plugin_models.Organization{OrganizationFields: plugin_models.OrganizationFields{Guid: "org-1", Name: "org"}}, nil)
+
+		fakeAuthClient = &httpclientfakes.FakeAuthenticatedClient{}
+		fakeAuthClient.DoAuthenticatedGetStub = func(pageUrl string, accessToken string) (io.ReadCloser, int, error) {
+			if strings.Contains(pageUrl, "page=2") {
+				return ioutil.NopCloser(strings.NewReader(secondPage)), http.StatusOK, nil
+			}
+			return ioutil.NopCloser(strings.NewReader(firstPage)), http.StatusOK, nil
+		}
+		allSpaces = false
+	})
+
+	JustBeforeEach(func() {
+		serviceInstances, err = serviceutil.NewServiceInstanceLister(fakeCliConnection, fakeAuthClient).List(testAccessToken, allSpaces)
+	})
+
+	It("lists the SCS service instances of every page with their management URLs", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(serviceInstances).To(Equal([]serviceutil.ServiceInstance{
+			{
+				Name:                "config-server",
+				Guid:                "guid-1",
+				SpaceName:           "dev",
+				ServiceOfferingName: "p-config-server",
+				ServicePlanName:     "standard",
+				DashboardUrl:        "https://spring-cloud-broker.example.com/dashboard/p-config-server/guid-1",
+				ManagementUrl:       "https://spring-cloud-broker.example.com/cli/instances/guid-1",
+			},
+			{
+				Name:                "registry",
+				Guid:                "guid-3",
+				SpaceName:           "prod",
+				ServiceOfferingName: "p.service-registry",
+				ServicePlanName:     "standard",
+				DashboardUrl:        "https://service-registry-guid-3.example.com/dashboard",
+				ManagementUrl:       "https://scs-service-broker.example.com/cli/instances/guid-3",
+			},
+		}))
+		Expect(serviceInstances[0].Type()).To(Equal("config server"))
+		Expect(serviceInstances[0].Generation()).To(Equal("v2"))
+		Expect(serviceInstances[1].Type()).To(Equal("service registry"))
+		Expect(serviceInstances[1].Generation()).To(Equal("v3"))
+	})
+
+	It("lists the service instances of the targeted space", func() {
+		Expect(fakeAuthClient.DoAuthenticatedGetCallCount()).To(Equal(2))
+		pageUrl, accessToken := fakeAuthClient.DoAuthenticatedGetArgsForCall(0)
+		Expect(accessToken).To(Equal(testAccessToken))
+		parsedUrl, err := url.Parse(pageUrl)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(parsedUrl.Path).To(Equal("/v3/service_instances"))
+		Expect(parsedUrl.Query().Get("space_guids")).To(Equal("space-1"))
+		Expect(parsedUrl.Query().Get("organization_guids")).To(BeEmpty())
+	})
+
+	Context("when listing all spaces", func() {
+		BeforeEach(func() {
+			allSpaces = true
+		})
+
+		It("lists the service instances of the targeted org", func() {
+			pageUrl, _ := fakeAuthClient.DoAuthenticatedGetArgsForCall(0)
+			parsedUrl, err := url.Parse(pageUrl)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(parsedUrl.Query().Get("organization_guids")).To(Equal("org-1"))
+			Expect(parsedUrl.Query().Get("space_guids")).To(BeEmpty())
+		})
+	})
+
+	Context("when no space is targeted", func() {
+		BeforeEach(func() {
+			fakeCliConnection.GetCurrentSpaceReturns(plugin_models.Space{}, nil)
+		})
+
+		It("returns a suitable error", func() {
+			Expect(err).To(MatchError("No space targeted. Use 'cf target -s SPACE' to target a space."))
+		})
+	})
+
+	Context("when the service instances cannot be listed", func() {
+		BeforeEach(func() {
+			fakeAuthClient.DoAuthenticatedGetStub = nil
+			fakeAuthClient.DoAuthenticatedGetReturns(nil, http.StatusForbidden, errors.New("forbidden"))
+		})
+
+		It("returns a suitable error", func() {
+			Expect(err).To(MatchError("Cannot list service instances: forbidden"))
+		})
+	})
+
+	Context("when the response is invalid", func() {
+		BeforeEach(func() {
+			fakeAuthClient.DoAuthenticatedGetStub = nil
+			fakeAuthClient.DoAuthenticatedGetReturns(ioutil.NopCloser(strings.NewReader("{")), http.StatusOK, nil)
+		})
+
+		It("returns a suitable error", func() {
+			Expect(err).To(MatchError("Invalid service instances response JSON: unexpected end of JSON input, response body: '{'"))
+		})
+	})
+})